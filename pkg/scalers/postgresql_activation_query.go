@@ -0,0 +1,38 @@
+package scalers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// parsePostgreSQLActivationQueryMetadata parses the activationQuery, which replaces the activation by the value
+func parsePostgreSQLActivationQueryMetadata(config *ScalerConfig, meta *postgreSQLMetadata) error {
+	if val, ok := config.TriggerMetadata["activationQuery"]; ok && val != "" {
+		for _, key := range []string{"activationTargetQueryValue", "activationOperator"} {
+			if _, ok := config.TriggerMetadata[key]; ok {
+				return fmt.Errorf("%s can't be used with activationQuery", key)
+			}
+		}
+		meta.activationQuery = val
+	}
+	return nil
+}
+
+// queryActivation runs the activationQuery, which decides whether the workload runs at all, apart from
+// the value of the query which decides how far it's scaled. NULL is treated as inactive
+func (s *postgreSQLScaler) queryActivation(ctx context.Context) (bool, error) {
+	var active sql.NullBool
+	err := s.withCircuitBreaker(func() error {
+		return s.withSession(ctx, func(session *postgreSQLSession) error {
+			if err := session.querier.QueryRowContext(session.ctx, s.metadata.activationQuery).Scan(&active); err != nil {
+				return fmt.Errorf("error running activationQuery: %w", session.timeoutErr(err))
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return false, err
+	}
+	return active.Valid && active.Bool, nil
+}
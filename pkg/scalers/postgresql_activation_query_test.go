@@ -0,0 +1,77 @@
+package scalers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+var testPostgreSQLActivationQueryMetadata = []parsePostgresMetadataTestData{
+	// activationQuery
+	{
+		metadata:   map[string]string{"query": "select 1", "targetQueryValue": "1", "activationQuery": "select true"},
+		authParams: map[string]string{"connection": "test_connection_string"},
+	},
+	// activationQuery with activationTargetQueryValue
+	{
+		metadata:    map[string]string{"query": "select 1", "targetQueryValue": "1", "activationQuery": "select true", "activationTargetQueryValue": "5"},
+		authParams:  map[string]string{"connection": "test_connection_string"},
+		raisesError: true,
+	},
+	// activationQuery with activationOperator
+	{
+		metadata:    map[string]string{"query": "select 1", "targetQueryValue": "1", "activationQuery": "select true", "activationOperator": "lt"},
+		authParams:  map[string]string{"connection": "test_connection_string"},
+		raisesError: true,
+	},
+}
+
+func TestParsePostgreSQLActivationQueryMetadata(t *testing.T) {
+	testParsePostgreSQLMetadata(t, testPostgreSQLActivationQueryMetadata)
+}
+
+func TestPostgreSQLActivationQuery(t *testing.T) {
+	scaler, mock := newPostgreSQLMockScaler(t, &ScalerConfig{
+		TriggerMetadata: map[string]string{"query": "SELECT count(*) FROM jobs", "targetQueryValue": "5", "activationQuery": "SELECT enabled FROM feature_flags"},
+		AuthParams:      map[string]string{"connection": "host=localhost"},
+	})
+	columns := []string{"enabled"}
+	// the activation doesn't depend on the value of the query, which isn't run
+	for _, testData := range []struct {
+		result interface{}
+		active bool
+	}{
+		{result: true, active: true},
+		{result: false, active: false},
+		{result: nil, active: false},
+	} {
+		mock.ExpectQuery("SELECT enabled FROM feature_flags").WillReturnRows(sqlmock.NewRows(columns).AddRow(testData.result))
+		active, err := scaler.IsActive(context.Background())
+		if err != nil {
+			t.Fatal("Unexpected error checking activation:", err)
+		}
+		if active != testData.active {
+			t.Errorf("Expected active %v for %v but got %v", testData.active, testData.result, active)
+		}
+	}
+
+	// the metric still comes from the query, even if activationQuery says inactive
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM jobs").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(7))
+	metrics, err := scaler.GetMetrics(context.Background(), "s0-postgresql")
+	if err != nil {
+		t.Fatal("Unexpected error getting metrics:", err)
+	}
+	if metrics[0].Value.Value() != 7 {
+		t.Errorf("Expected metric value 7 but got %d", metrics[0].Value.Value())
+	}
+
+	mock.ExpectQuery("SELECT enabled FROM feature_flags").WillReturnError(errors.New("relation \"feature_flags\" does not exist"))
+	if _, err := scaler.IsActive(context.Background()); err == nil {
+		t.Error("Expected error for a failed activationQuery but got success")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,137 @@
+package scalers
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path"
+	"strings"
+)
+
+// postgreSQLAllowedHostsEnv is the environment variable of the KEDA operator restricting the PostgreSQL
+// servers the scalers may connect to, a comma separated list of host[:port] patterns. A host is either
+// a glob such as *.db.internal or a CIDR such as 10.0.0.0/8. Without it any server is allowed
+const postgreSQLAllowedHostsEnv = "KEDA_POSTGRESQL_ALLOWED_HOSTS"
+
+// parsePostgreSQLAllowlistMetadata checks the servers of the final connections against the allowlist of the operator.
+// An unparseable connection can't be checked, so it's rejected
+func parsePostgreSQLAllowlistMetadata(_ *ScalerConfig, meta *postgreSQLMetadata) error {
+	if strings.TrimSpace(os.Getenv(postgreSQLAllowedHostsEnv)) == "" {
+		return nil
+	}
+	connections := meta.databaseConnections
+	if len(connections) == 0 {
+		connections = []string{meta.connection}
+	}
+	for _, connection := range connections {
+		params, err := parsePostgreSQLConnectionString(connection)
+		if err != nil {
+			return fmt.Errorf("error parsing connection for %s: %s", postgreSQLAllowedHostsEnv, err)
+		}
+		if err := checkPostgreSQLAllowedHosts(params); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// postgreSQLAllowedHost is a parsed entry of postgreSQLAllowedHostsEnv, an empty port allows any port
+type postgreSQLAllowedHost struct {
+	pattern string
+	network *net.IPNet
+	port    string
+}
+
+// parsePostgreSQLAllowedHosts parses the allowlist, nil means no restriction
+func parsePostgreSQLAllowedHosts(allowlist string) ([]postgreSQLAllowedHost, error) {
+	var allowed []postgreSQLAllowedHost
+	for _, entry := range strings.Split(allowlist, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		host, port, err := net.SplitHostPort(entry)
+		if err != nil {
+			// no port, e.g. db.internal or an unbracketed IPv6 address
+			host, port = entry, ""
+		}
+		host = strings.ToLower(host)
+		if _, err := path.Match(host, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %s", entry, err)
+		}
+		allowedHost := postgreSQLAllowedHost{pattern: host, port: port}
+		if strings.Contains(host, "/") {
+			_, network, err := net.ParseCIDR(host)
+			if err != nil {
+				return nil, fmt.Errorf("invalid CIDR %q: %s", entry, err)
+			}
+			allowedHost.network = network
+		}
+		allowed = append(allowed, allowedHost)
+	}
+	return allowed, nil
+}
+
+// allows reports whether the entry matches the host and port
+func (a postgreSQLAllowedHost) allows(host, port string) bool {
+	if a.port != "" && a.port != port {
+		return false
+	}
+	if a.network != nil {
+		ip := net.ParseIP(host)
+		return ip != nil && a.network.Contains(ip)
+	}
+	matched, _ := path.Match(a.pattern, strings.ToLower(host))
+	return matched
+}
+
+// checkPostgreSQLAllowedHosts rejects a connection to a server which isn't allowed by postgreSQLAllowedHostsEnv.
+// Every host of a multi-host connection and hostaddr have to be allowed, as the driver may connect to any of them
+func checkPostgreSQLAllowedHosts(params map[string]string) error {
+	allowlist, ok := os.LookupEnv(postgreSQLAllowedHostsEnv)
+	if !ok || strings.TrimSpace(allowlist) == "" {
+		return nil
+	}
+	allowed, err := parsePostgreSQLAllowedHosts(allowlist)
+	if err != nil {
+		return fmt.Errorf("%s parsing error %s", postgreSQLAllowedHostsEnv, err.Error())
+	}
+
+	// like the driver, fall back to the PG* environment variables and the defaults
+	host, port := params["host"], params["port"]
+	if host == "" {
+		host = os.Getenv("PGHOST")
+	}
+	if host == "" {
+		host = "localhost"
+	}
+	if port == "" {
+		port = os.Getenv("PGPORT")
+	}
+	if port == "" {
+		port = "5432"
+	}
+	hosts, ports := strings.Split(host, ","), strings.Split(port, ",")
+	if params["hostaddr"] != "" {
+		hosts = append(hosts, params["hostaddr"])
+	}
+	for i, host := range hosts {
+		port := ports[0]
+		if i < len(ports) {
+			port = ports[i]
+		}
+		if !isPostgreSQLHostAllowed(allowed, host, port) {
+			return fmt.Errorf("connecting to postgreSQL host %s port %s isn't allowed by %s", host, port, postgreSQLAllowedHostsEnv)
+		}
+	}
+	return nil
+}
+
+func isPostgreSQLHostAllowed(allowed []postgreSQLAllowedHost, host, port string) bool {
+	for _, entry := range allowed {
+		if entry.allows(host, port) {
+			return true
+		}
+	}
+	return false
+}
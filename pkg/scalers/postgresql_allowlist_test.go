@@ -0,0 +1,92 @@
+package scalers
+
+import "testing"
+
+func TestCheckPostgreSQLAllowedHosts(t *testing.T) {
+	testData := []struct {
+		allowlist   string
+		params      map[string]string
+		raisesError bool
+	}{
+		// no allowlist
+		{allowlist: "", params: map[string]string{"host": "anything.example.com"}, raisesError: false},
+		// exact host on any port
+		{allowlist: "db.internal", params: map[string]string{"host": "db.internal", "port": "6432"}, raisesError: false},
+		// host names are case insensitive
+		{allowlist: "db.internal", params: map[string]string{"host": "DB.Internal"}, raisesError: false},
+		// glob
+		{allowlist: "*.db.internal:5432", params: map[string]string{"host": "orders.db.internal", "port": "5432"}, raisesError: false},
+		// glob with another port
+		{allowlist: "*.db.internal:5432", params: map[string]string{"host": "orders.db.internal", "port": "5433"}, raisesError: true},
+		// default port
+		{allowlist: "*.db.internal:5432", params: map[string]string{"host": "orders.db.internal"}, raisesError: false},
+		// denied host
+		{allowlist: "*.db.internal, 10.0.0.0/8", params: map[string]string{"host": "169.254.169.254", "port": "80"}, raisesError: true},
+		// CIDR
+		{allowlist: "*.db.internal, 10.0.0.0/8", params: map[string]string{"host": "10.1.2.3"}, raisesError: false},
+		// CIDR with port
+		{allowlist: "10.0.0.0/8:5432", params: map[string]string{"host": "10.1.2.3", "port": "5432"}, raisesError: false},
+		// CIDR doesn't match host names
+		{allowlist: "10.0.0.0/8", params: map[string]string{"host": "ten.example.com"}, raisesError: true},
+		// IPv6
+		{allowlist: "[fd00::1]:5432", params: map[string]string{"host": "fd00::1", "port": "5432"}, raisesError: false},
+		// default host
+		{allowlist: "localhost", params: map[string]string{}, raisesError: false},
+		// every host of a multi-host connection has to be allowed
+		{allowlist: "a.db.internal,b.db.internal", params: map[string]string{"host": "a.db.internal,b.db.internal"}, raisesError: false},
+		{allowlist: "a.db.internal", params: map[string]string{"host": "a.db.internal,evil.example.com"}, raisesError: true},
+		// per-host ports
+		{allowlist: "a.db.internal:5432,b.db.internal:5433", params: map[string]string{"host": "a.db.internal,b.db.internal", "port": "5432,5433"}, raisesError: false},
+		// hostaddr is connected to instead of host
+		{allowlist: "db.internal", params: map[string]string{"host": "db.internal", "hostaddr": "203.0.113.7"}, raisesError: true},
+		// invalid CIDR
+		{allowlist: "10.0.0.0/33", params: map[string]string{"host": "10.1.2.3"}, raisesError: true},
+		// invalid glob
+		{allowlist: "[db", params: map[string]string{"host": "db"}, raisesError: true},
+	}
+
+	for _, testData := range testData {
+		t.Setenv(postgreSQLAllowedHostsEnv, testData.allowlist)
+		t.Setenv("PGHOST", "")
+		t.Setenv("PGPORT", "")
+		err := checkPostgreSQLAllowedHosts(testData.params)
+		if err != nil && !testData.raisesError {
+			t.Errorf("Expected %v to be allowed by %q but got error %s", testData.params, testData.allowlist, err)
+		}
+		if err == nil && testData.raisesError {
+			t.Errorf("Expected %v to be denied by %q but got success", testData.params, testData.allowlist)
+		}
+	}
+}
+
+func TestParsePostgreSQLMetadataAllowedHosts(t *testing.T) {
+	t.Setenv(postgreSQLAllowedHostsEnv, "*.db.internal:5432")
+
+	testData := []struct {
+		authParams  map[string]string
+		metadata    map[string]string
+		raisesError bool
+	}{
+		{authParams: map[string]string{"connection": "host=orders.db.internal port=5432"}, raisesError: false},
+		{authParams: map[string]string{"connection": "postgres://user@orders.db.internal:5432/jobs"}, raisesError: false},
+		{authParams: map[string]string{"connection": "host=metadata.google.internal port=80"}, raisesError: true},
+		{authParams: map[string]string{"connection": "postgres://user@127.0.0.1:5432/jobs"}, raisesError: true},
+		{metadata: map[string]string{"host": "evil.example.com", "port": "5432", "userName": "user", "dbName": "jobs", "sslmode": "disable"}, raisesError: true},
+		{authParams: map[string]string{"connections": `["host=eu.db.internal port=5432", "host=evil.example.com port=5432"]`}, raisesError: true},
+		{authParams: map[string]string{"connections": `["host=eu.db.internal port=5432", "host=us.db.internal port=5432"]`}, raisesError: false},
+	}
+
+	for _, testData := range testData {
+		metadata := map[string]string{"query": "SELECT 1", "targetQueryValue": "5"}
+		for key, value := range testData.metadata {
+			metadata[key] = value
+		}
+		_, err := parsePostgreSQLMetadata(&ScalerConfig{TriggerMetadata: metadata, AuthParams: testData.authParams})
+		if err != nil && !testData.raisesError {
+			t.Errorf("Expected success for %v but got error %s", testData.authParams, err)
+		}
+		if err == nil && testData.raisesError {
+			t.Errorf("Expected error for %v but got success", testData.authParams)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package scalers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// postgreSQLAnyOfMetadataKeys are the options of metricMode anyOf
+var postgreSQLAnyOfMetadataKeys = []string{"countQuery", "countThreshold", "ageQuery", "ageThresholdSeconds"}
+
+// postgreSQLAnyOf are the two conditions of metricMode anyOf: more than countThreshold rows pending
+// or the oldest pending row older than ageThreshold seconds
+type postgreSQLAnyOf struct {
+	countQuery     string
+	countThreshold float64
+	// ageQuery returns a timestamp or an age like the query of metricMode age
+	ageQuery     string
+	ageThreshold float64
+}
+
+// parsePostgreSQLAnyOfMetadata parses the conditions of metricMode anyOf, the countQuery is its query
+func parsePostgreSQLAnyOfMetadata(config *ScalerConfig, meta *postgreSQLMetadata) error {
+	if meta.metricMode != postgreSQLMetricModeAnyOf {
+		for _, key := range postgreSQLAnyOfMetadataKeys {
+			if _, ok := config.TriggerMetadata[key]; ok {
+				return fmt.Errorf("%s can only be used with metricMode %s", key, postgreSQLMetricModeAnyOf)
+			}
+		}
+		return nil
+	}
+	anyOf := &postgreSQLAnyOf{
+		countQuery: config.TriggerMetadata["countQuery"],
+		ageQuery:   config.TriggerMetadata["ageQuery"],
+	}
+	if anyOf.countQuery == "" || anyOf.ageQuery == "" {
+		return fmt.Errorf("metricMode %s requires countQuery and ageQuery", postgreSQLMetricModeAnyOf)
+	}
+	countThreshold, err := strconv.ParseFloat(config.TriggerMetadata["countThreshold"], 64)
+	if err != nil {
+		return fmt.Errorf("countThreshold parsing error %s", err.Error())
+	}
+	if countThreshold <= 0 {
+		return fmt.Errorf("countThreshold must be positive, got %v", countThreshold)
+	}
+	anyOf.countThreshold = countThreshold
+	ageThreshold, err := strconv.ParseFloat(config.TriggerMetadata["ageThresholdSeconds"], 64)
+	if err != nil {
+		return fmt.Errorf("ageThresholdSeconds parsing error %s", err.Error())
+	}
+	if ageThreshold <= 0 {
+		return fmt.Errorf("ageThresholdSeconds must be positive, got %v", ageThreshold)
+	}
+	anyOf.ageThreshold = ageThreshold
+	meta.anyOf = anyOf
+	meta.query = anyOf.countQuery
+	return nil
+}
+
+// computePostgreSQLAnyOfValue returns how many times the larger of count and age is its threshold.
+// It's above 1 as soon as either condition holds, which is the default activationTargetQueryValue
+func computePostgreSQLAnyOfValue(count, age float64, anyOf *postgreSQLAnyOf) float64 {
+	return math.Max(count/anyOf.countThreshold, age/anyOf.ageThreshold)
+}
+
+// queryAnyOf runs the countQuery and the ageQuery and combines their results
+func (s *postgreSQLScaler) queryAnyOf(ctx context.Context, connection postgreSQLQuerier) (float64, error) {
+	anyOf := s.metadata.anyOf
+	var value sql.NullString
+	if err := connection.QueryRowContext(ctx, anyOf.countQuery).Scan(&value); err != nil {
+		return 0, fmt.Errorf("error running countQuery: %w", err)
+	}
+	count, err := parsePostgreSQLResultValue(value, 0)
+	if err != nil {
+		return 0, fmt.Errorf("error parsing countQuery result: %w", err)
+	}
+	age, err := s.queryAge(ctx, connection, anyOf.ageQuery)
+	if err != nil {
+		return 0, fmt.Errorf("error running ageQuery: %w", err)
+	}
+	return computePostgreSQLAnyOfValue(count, age, anyOf), nil
+}
@@ -0,0 +1,114 @@
+package scalers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+var postgreSQLAnyOfTestMetadata = map[string]string{
+	"metricMode":          postgreSQLMetricModeAnyOf,
+	"countQuery":          "SELECT count(*) FROM jobs WHERE state = 'pending'",
+	"countThreshold":      "100",
+	"ageQuery":            "SELECT min(created_at) FROM jobs WHERE state = 'pending'",
+	"ageThresholdSeconds": "60",
+	"targetQueryValue":    "1",
+}
+
+func TestParsePostgreSQLAnyOfMetadata(t *testing.T) {
+	testData := []struct {
+		name        string
+		metadata    map[string]string
+		raisesError bool
+	}{
+		{name: "valid", metadata: map[string]string{}},
+		{name: "missing countQuery", metadata: map[string]string{"countQuery": ""}, raisesError: true},
+		{name: "missing ageQuery", metadata: map[string]string{"ageQuery": ""}, raisesError: true},
+		{name: "missing countThreshold", metadata: map[string]string{"countThreshold": ""}, raisesError: true},
+		{name: "zero countThreshold", metadata: map[string]string{"countThreshold": "0"}, raisesError: true},
+		{name: "negative ageThresholdSeconds", metadata: map[string]string{"ageThresholdSeconds": "-1"}, raisesError: true},
+		{name: "invalid ageThresholdSeconds", metadata: map[string]string{"ageThresholdSeconds": "1m"}, raisesError: true},
+		{name: "query", metadata: map[string]string{"query": "SELECT 1"}, raisesError: true},
+		{name: "bindWorkloadParameters", metadata: map[string]string{"bindWorkloadParameters": "true"}, raisesError: true},
+		{name: "countQuery without metricMode anyOf", metadata: map[string]string{"metricMode": postgreSQLMetricModeAbsolute, "query": "SELECT 1"}, raisesError: true},
+	}
+
+	for _, testData := range testData {
+		t.Run(testData.name, func(t *testing.T) {
+			metadata := map[string]string{}
+			for key, value := range postgreSQLAnyOfTestMetadata {
+				metadata[key] = value
+			}
+			for key, value := range testData.metadata {
+				metadata[key] = value
+			}
+			meta, err := parsePostgreSQLMetadata(&ScalerConfig{TriggerMetadata: metadata, AuthParams: map[string]string{"connection": "host=localhost"}})
+			if err != nil && !testData.raisesError {
+				t.Error("Expected success but got error", err)
+			}
+			if err == nil && testData.raisesError {
+				t.Error("Expected error but got success")
+			}
+			if err == nil && meta.activationTargetQueryValue != 1 {
+				t.Errorf("Expected the default activationTargetQueryValue 1 but got %v", meta.activationTargetQueryValue)
+			}
+		})
+	}
+}
+
+func TestComputePostgreSQLAnyOfValue(t *testing.T) {
+	anyOf := &postgreSQLAnyOf{countThreshold: 100, ageThreshold: 60}
+	testData := []struct {
+		count, age, value float64
+	}{
+		{count: 0, age: 0, value: 0},
+		{count: 50, age: 30, value: 0.5},
+		{count: 250, age: 30, value: 2.5},
+		{count: 50, age: 180, value: 3},
+		{count: 300, age: 120, value: 3},
+	}
+
+	for _, testData := range testData {
+		if value := computePostgreSQLAnyOfValue(testData.count, testData.age, anyOf); value != testData.value {
+			t.Errorf("Expected %v for count %v and age %v but got %v", testData.value, testData.count, testData.age, value)
+		}
+	}
+}
+
+func TestPostgreSQLAnyOfActivation(t *testing.T) {
+	testData := []struct {
+		name   string
+		count  interface{}
+		age    interface{}
+		active bool
+	}{
+		{name: "neither", count: 20, age: 10, active: false},
+		{name: "count only", count: 150, age: 10, active: true},
+		{name: "age only", count: 20, age: 90, active: true},
+		{name: "both", count: 150, age: 90, active: true},
+		{name: "empty queue", count: 0, age: nil, active: false},
+	}
+
+	for _, testData := range testData {
+		t.Run(testData.name, func(t *testing.T) {
+			scaler, mock := newPostgreSQLMockScaler(t, &ScalerConfig{
+				TriggerMetadata: postgreSQLAnyOfTestMetadata,
+				AuthParams:      map[string]string{"connection": "host=localhost"},
+			})
+			mock.ExpectQuery("SELECT count").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(testData.count))
+			mock.ExpectQuery("SELECT min").WillReturnRows(sqlmock.NewRows([]string{"min"}).AddRow(testData.age))
+
+			active, err := scaler.IsActive(context.Background())
+			if err != nil {
+				t.Fatal("Unexpected error:", err)
+			}
+			if active != testData.active {
+				t.Errorf("Expected active %v but got %v", testData.active, active)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package scalers
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// postgreSQLAuthOnlyMetadataKeys tell where the database is and how to log in. With requireAuthParams they
+// must come from the TriggerAuthentication, so they don't sit in plain text in the ScaledObject
+var postgreSQLAuthOnlyMetadataKeys = []string{
+	"connection", "connectionJSON", "connectionShards", "connections",
+	"host", "port", "userName", "dbName", "password", "sslkeyPassword",
+}
+
+// checkPostgreSQLAuthOnlyMetadata rejects the postgreSQLAuthOnlyMetadataKeys and their FromEnv variants
+// in the trigger metadata. requireAuthParams itself is only read from the authentication parameters,
+// so the author of a ScaledObject can't turn it off
+func checkPostgreSQLAuthOnlyMetadata(config *ScalerConfig) error {
+	val, ok := config.AuthParams["requireAuthParams"]
+	if !ok || val == "" {
+		return nil
+	}
+	required, err := strconv.ParseBool(val)
+	if err != nil {
+		return fmt.Errorf("requireAuthParams parsing error %s", err.Error())
+	}
+	if !required {
+		return nil
+	}
+	for _, key := range postgreSQLAuthOnlyMetadataKeys {
+		for _, field := range []string{key, key + "FromEnv"} {
+			if _, ok := config.TriggerMetadata[field]; ok {
+				return fmt.Errorf("%s can't be given in the trigger metadata with requireAuthParams, set %s in the TriggerAuthentication", field, key)
+			}
+		}
+	}
+	return nil
+}
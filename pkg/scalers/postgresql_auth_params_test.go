@@ -0,0 +1,49 @@
+package scalers
+
+import "testing"
+
+var testPostgreSQLAuthParamsMetadata = []parsePostgresMetadataTestData{
+	// requireAuthParams with the host in the trigger metadata
+	{
+		metadata:    map[string]string{"query": "test_query", "targetQueryValue": "5", "host": "localhost", "port": "5432", "userName": "test_user_name", "dbName": "test_db_name", "sslmode": "require"},
+		authParams:  map[string]string{"requireAuthParams": "true", "password": "test_password"},
+		raisesError: true,
+	},
+	// requireAuthParams turned off
+	{
+		metadata:   map[string]string{"query": "test_query", "targetQueryValue": "5", "host": "localhost", "port": "5432", "userName": "test_user_name", "dbName": "test_db_name", "sslmode": "require"},
+		authParams: map[string]string{"requireAuthParams": "false", "password": "test_password"},
+	},
+	// invalid requireAuthParams
+	{
+		metadata:    map[string]string{"query": "test_query", "targetQueryValue": "5"},
+		authParams:  map[string]string{"requireAuthParams": "always", "connection": "test_connection_string"},
+		raisesError: true,
+	},
+}
+
+func TestParsePostgreSQLAuthParamsMetadata(t *testing.T) {
+	testParsePostgreSQLMetadata(t, testPostgreSQLAuthParamsMetadata)
+}
+
+func TestPostgreSQLRequireAuthParams(t *testing.T) {
+	authParams := map[string]string{"requireAuthParams": "true", "host": "localhost", "port": "5432", "userName": "keda", "dbName": "app", "password": "secret"}
+	if _, err := parsePostgreSQLMetadata(&ScalerConfig{
+		TriggerMetadata: map[string]string{"query": "SELECT 1", "targetQueryValue": "1", "sslmode": "require"},
+		AuthParams:      authParams,
+	}); err != nil {
+		t.Fatal("Expected success with every connection parameter in the authentication parameters but got", err)
+	}
+
+	for _, key := range postgreSQLAuthOnlyMetadataKeys {
+		for _, field := range []string{key, key + "FromEnv"} {
+			if _, err := parsePostgreSQLMetadata(&ScalerConfig{
+				TriggerMetadata: map[string]string{"query": "SELECT 1", "targetQueryValue": "1", "sslmode": "require", field: "inline"},
+				AuthParams:      authParams,
+				ResolvedEnv:     map[string]string{"inline": "inline"},
+			}); err == nil {
+				t.Errorf("Expected error for %s in the trigger metadata but got success", field)
+			}
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package scalers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// postgreSQLBlockedQueriesMetadataKeys are the filters of metricMode blockedQueries
+var postgreSQLBlockedQueriesMetadataKeys = []string{"minWaitDuration", "waitingUserName", "waitingApplicationName"}
+
+// postgreSQLBlockedQueriesQuery counts the sessions of the current database waiting for a lock which
+// another session holds. $1 is the minimum seconds since the query started, $2 and $3 optionally
+// restrict the count to a role and an application_name
+const postgreSQLBlockedQueriesQuery = `SELECT count(DISTINCT a.pid) FROM pg_locks l JOIN pg_stat_activity a ON a.pid = l.pid ` +
+	`WHERE NOT l.granted AND a.datname = current_database() ` +
+	`AND a.query_start <= now() - make_interval(secs => $1) ` +
+	`AND ($2::text IS NULL OR a.usename = $2) AND ($3::text IS NULL OR a.application_name = $3)`
+
+// parsePostgreSQLBlockedQueriesMetadata parses the filters of the sessions metricMode blockedQueries counts
+func parsePostgreSQLBlockedQueriesMetadata(config *ScalerConfig, meta *postgreSQLMetadata) error {
+	if meta.metricMode != postgreSQLMetricModeBlockedQueries {
+		for _, key := range postgreSQLBlockedQueriesMetadataKeys {
+			if _, ok := config.TriggerMetadata[key]; ok {
+				return fmt.Errorf("%s can only be used with metricMode %s", key, postgreSQLMetricModeBlockedQueries)
+			}
+		}
+		return nil
+	}
+	if meta.dialect == postgreSQLDialectCockroach {
+		return fmt.Errorf("metricMode %s can't be used with dialect %s", meta.metricMode, meta.dialect)
+	}
+	args, err := parsePostgreSQLBlockedQueriesArgs(config)
+	if err != nil {
+		return err
+	}
+	meta.query = postgreSQLBlockedQueriesQuery
+	meta.queryArgs = args
+	return nil
+}
+
+// parsePostgreSQLBlockedQueriesArgs returns the arguments of postgreSQLBlockedQueriesQuery. Filters which
+// aren't set are NULL and match every session
+func parsePostgreSQLBlockedQueriesArgs(config *ScalerConfig) ([]interface{}, error) {
+	// by default every waiting session counts, however briefly it waits
+	var minWait time.Duration
+	if val, ok := config.TriggerMetadata["minWaitDuration"]; ok && val != "" {
+		var err error
+		if minWait, err = parsePostgreSQLDuration("minWaitDuration", val); err != nil {
+			return nil, err
+		}
+		if minWait < 0 {
+			return nil, fmt.Errorf("minWaitDuration must not be negative, got %s", minWait)
+		}
+	}
+	args := []interface{}{minWait.Seconds()}
+	for _, key := range []string{"waitingUserName", "waitingApplicationName"} {
+		if val := config.TriggerMetadata[key]; val != "" {
+			args = append(args, val)
+		} else {
+			args = append(args, nil)
+		}
+	}
+	return args, nil
+}
+
+// queryBlockedQueries returns the number of sessions waiting for a lock
+func (s *postgreSQLScaler) queryBlockedQueries(ctx context.Context, connection postgreSQLQuerier) (float64, error) {
+	var count sql.NullString
+	if err := connection.QueryRowContext(ctx, s.metadata.query, s.metadata.queryArgs...).Scan(&count); err != nil {
+		return 0, err
+	}
+	value, err := parsePostgreSQLResultValue(count, 0)
+	if err != nil {
+		return 0, fmt.Errorf("error parsing blocked query count: %w", err)
+	}
+	return value, nil
+}
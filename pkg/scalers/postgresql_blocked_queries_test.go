@@ -0,0 +1,99 @@
+package scalers
+
+import (
+	"context"
+	"database/sql/driver"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+var testPostgreSQLBlockedQueriesMetadata = []parsePostgresMetadataTestData{
+	// blockedQueries with filters
+	{
+		metadata:   map[string]string{"metricMode": "blockedQueries", "targetQueryValue": "3", "minWaitDuration": "1m", "waitingUserName": "worker", "waitingApplicationName": "billing"},
+		authParams: map[string]string{"connection": "test_connection_string"},
+	},
+	// blockedQueries with a query
+	{
+		metadata:    map[string]string{"metricMode": "blockedQueries", "query": "SELECT 1", "targetQueryValue": "3"},
+		authParams:  map[string]string{"connection": "test_connection_string"},
+		raisesError: true,
+	},
+	// blockedQueries with a negative minWaitDuration
+	{
+		metadata:    map[string]string{"metricMode": "blockedQueries", "targetQueryValue": "3", "minWaitDuration": "-1m"},
+		authParams:  map[string]string{"connection": "test_connection_string"},
+		raisesError: true,
+	},
+	// blockedQueries with dialect cockroach
+	{
+		metadata:    map[string]string{"metricMode": "blockedQueries", "dialect": "cockroach", "targetQueryValue": "3"},
+		authParams:  map[string]string{"connection": "test_connection_string"},
+		raisesError: true,
+	},
+	// waitingUserName without metricMode blockedQueries
+	{
+		metadata:    map[string]string{"query": "test_query", "targetQueryValue": "3", "waitingUserName": "worker"},
+		authParams:  map[string]string{"connection": "test_connection_string"},
+		raisesError: true,
+	},
+}
+
+func TestParsePostgreSQLBlockedQueriesMetadata(t *testing.T) {
+	testParsePostgreSQLMetadata(t, testPostgreSQLBlockedQueriesMetadata)
+}
+
+func TestPostgreSQLBlockedQueriesQuery(t *testing.T) {
+	testData := []struct {
+		name        string
+		metadata    map[string]string
+		args        []driver.Value
+		count       interface{}
+		expected    int64
+		raisesError bool
+	}{
+		{name: "count", args: []driver.Value{0.0, nil, nil}, count: 4, expected: 4},
+		{name: "no blocked query", args: []driver.Value{0.0, nil, nil}, count: 0, expected: 0},
+		{name: "minWaitDuration", metadata: map[string]string{"minWaitDuration": "30s"}, args: []driver.Value{30.0, nil, nil}, count: 2, expected: 2},
+		{
+			name:     "waiting user and application",
+			metadata: map[string]string{"waitingUserName": "worker", "waitingApplicationName": "billing"},
+			args:     []driver.Value{0.0, "worker", "billing"},
+			count:    1,
+			expected: 1,
+		},
+		{name: "unparseable count", args: []driver.Value{0.0, nil, nil}, count: "many", raisesError: true},
+	}
+
+	// only the sessions of the database waiting for a lock which wasn't granted are counted
+	lockFilter := regexp.QuoteMeta("pg_locks l JOIN pg_stat_activity a ON a.pid = l.pid WHERE NOT l.granted AND a.datname = current_database()")
+	for _, testData := range testData {
+		t.Run(testData.name, func(t *testing.T) {
+			metadata := map[string]string{"metricMode": "blockedQueries", "targetQueryValue": "1"}
+			for key, value := range testData.metadata {
+				metadata[key] = value
+			}
+			scaler, mock := newPostgreSQLMockScaler(t, &ScalerConfig{
+				TriggerMetadata: metadata,
+				AuthParams:      map[string]string{"connection": "host=localhost"},
+			})
+			mock.ExpectQuery(lockFilter).WithArgs(testData.args...).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(testData.count))
+
+			metrics, err := scaler.GetMetrics(context.Background(), "s0-postgresql")
+			if err != nil && !testData.raisesError {
+				t.Fatal("Expected success but got error", err)
+			}
+			if err == nil && testData.raisesError {
+				t.Fatal("Expected error but got success")
+			}
+			if err == nil && metrics[0].Value.Value() != testData.expected {
+				t.Errorf("Expected metric value %d but got %d", testData.expected, metrics[0].Value.Value())
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package scalers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// postgreSQLCancelTimeout bounds the cancellation of an abandoned query, the context of the query is done already
+const postgreSQLCancelTimeout = 5 * time.Second
+
+// parsePostgreSQLCancelMetadata parses cancelAbandonedQueries, which the cancel requests of cockroach don't support
+func parsePostgreSQLCancelMetadata(config *ScalerConfig, meta *postgreSQLMetadata) error {
+	if val, ok := config.TriggerMetadata["cancelAbandonedQueries"]; ok {
+		cancelAbandonedQueries, err := strconv.ParseBool(val)
+		if err != nil {
+			return fmt.Errorf("cancelAbandonedQueries parsing error %s", err.Error())
+		}
+		if cancelAbandonedQueries && meta.dialect == postgreSQLDialectCockroach {
+			return fmt.Errorf("cancelAbandonedQueries can't be used with dialect %s", meta.dialect)
+		}
+		meta.cancelAbandonedQueries = cancelAbandonedQueries
+	}
+	return nil
+}
+
+// queryPostgreSQLBackendPID returns the process ID of the backend serving connection
+func queryPostgreSQLBackendPID(ctx context.Context, connection postgreSQLQuerier) (int, error) {
+	var pid int
+	err := connection.QueryRowContext(ctx, "SELECT pg_backend_pid()").Scan(&pid)
+	return pid, err
+}
+
+// cancelPostgreSQLBackend asks the server to cancel the query of the backend pid through another connection
+// of db, as the connection of the query is busy. It returns whether there was a query to cancel
+func cancelPostgreSQLBackend(db *sql.DB, pid int) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), postgreSQLCancelTimeout)
+	defer cancel()
+	var canceled bool
+	err := db.QueryRowContext(ctx, "SELECT pg_cancel_backend($1)", pid).Scan(&canceled)
+	return canceled, err
+}
+
+// cancelAbandonedQuery makes sure the query of the backend pid doesn't keep running on the server after its
+// context was done. The driver sends a cancel request itself, but that may get lost, e.g. behind a proxy
+func (s *postgreSQLScaler) cancelAbandonedQuery(db *sql.DB, pid int) {
+	canceled, err := cancelPostgreSQLBackend(db, pid)
+	if err != nil {
+		s.logger.V(1).Info("could not cancel abandoned postgreSQL query", "pid", pid, "error", err.Error())
+		return
+	}
+	if canceled {
+		s.logger.V(1).Info("canceled abandoned postgreSQL query", "pid", pid)
+	}
+}
@@ -0,0 +1,58 @@
+package scalers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+var testPostgreSQLCancelMetadata = []parsePostgresMetadataTestData{
+	// cancelAbandonedQueries
+	{
+		metadata:   map[string]string{"query": "select 1", "targetQueryValue": "1", "cancelAbandonedQueries": "true"},
+		authParams: map[string]string{"connection": "test_connection_string"},
+	},
+	// cancelAbandonedQueries invalid
+	{
+		metadata:    map[string]string{"query": "select 1", "targetQueryValue": "1", "cancelAbandonedQueries": "sometimes"},
+		authParams:  map[string]string{"connection": "test_connection_string"},
+		raisesError: true,
+	},
+	// cancelAbandonedQueries with dialect cockroach
+	{
+		metadata:    map[string]string{"query": "select 1", "targetQueryValue": "1", "cancelAbandonedQueries": "true", "dialect": "cockroach"},
+		authParams:  map[string]string{"connection": "test_connection_string"},
+		raisesError: true,
+	},
+}
+
+func TestParsePostgreSQLCancelMetadata(t *testing.T) {
+	testParsePostgreSQLMetadata(t, testPostgreSQLCancelMetadata)
+}
+
+func TestPostgreSQLCancelAbandonedQueries(t *testing.T) {
+	scaler, mock := newPostgreSQLMockScaler(t, &ScalerConfig{
+		TriggerMetadata: map[string]string{"query": "SELECT count(*) FROM jobs", "targetQueryValue": "5", "queryTimeout": "100ms", "cancelAbandonedQueries": "true"},
+		AuthParams:      map[string]string{"connection": "host=localhost"},
+	})
+
+	// a query in time isn't canceled
+	mock.ExpectQuery("SELECT pg_backend_pid").WillReturnRows(sqlmock.NewRows([]string{"pg_backend_pid"}).AddRow(4242))
+	mock.ExpectQuery("SELECT count").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	if _, err := scaler.getActiveNumber(context.Background()); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	// a query exceeding queryTimeout is canceled on the server through another connection
+	mock.ExpectQuery("SELECT pg_backend_pid").WillReturnRows(sqlmock.NewRows([]string{"pg_backend_pid"}).AddRow(4242))
+	mock.ExpectQuery("SELECT count").WillDelayFor(5 * time.Second).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	mock.ExpectQuery("SELECT pg_cancel_backend").WithArgs(4242).WillReturnRows(sqlmock.NewRows([]string{"pg_cancel_backend"}).AddRow(true))
+	if _, err := scaler.getActiveNumber(context.Background()); err == nil {
+		t.Fatal("Expected error for a query exceeding queryTimeout but got success")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,129 @@
+package scalers
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// parsePostgreSQLCircuitBreakerMetadata parses after how many consecutive failures the circuit opens and for how long
+func parsePostgreSQLCircuitBreakerMetadata(config *ScalerConfig, meta *postgreSQLMetadata) error {
+	if val, ok := config.TriggerMetadata["circuitBreakerThreshold"]; ok {
+		circuitBreakerThreshold, err := strconv.Atoi(val)
+		if err != nil {
+			return fmt.Errorf("circuitBreakerThreshold parsing error %s", err.Error())
+		}
+		if circuitBreakerThreshold < 0 {
+			return fmt.Errorf("circuitBreakerThreshold must not be negative, got %d", circuitBreakerThreshold)
+		}
+		meta.circuitBreakerThreshold = circuitBreakerThreshold
+	}
+
+	meta.circuitBreakerCooldown = defaultPostgreSQLCircuitBreakerCooldown
+	if val, ok := config.TriggerMetadata["circuitBreakerCooldown"]; ok {
+		circuitBreakerCooldown, err := parsePostgreSQLDuration("circuitBreakerCooldown", val)
+		if err != nil {
+			return err
+		}
+		if circuitBreakerCooldown <= 0 {
+			return fmt.Errorf("circuitBreakerCooldown must be positive, got %s", circuitBreakerCooldown)
+		}
+		meta.circuitBreakerCooldown = circuitBreakerCooldown
+	}
+	return nil
+}
+
+type postgreSQLCircuitState int
+
+const (
+	// postgreSQLCircuitClosed lets every query through
+	postgreSQLCircuitClosed postgreSQLCircuitState = iota
+	// postgreSQLCircuitOpen rejects queries until the cooldown is over
+	postgreSQLCircuitOpen
+	// postgreSQLCircuitHalfOpen lets a single probe query through
+	postgreSQLCircuitHalfOpen
+)
+
+// defaultPostgreSQLCircuitBreakerCooldown is how long the circuit stays open when no cooldown is configured
+const defaultPostgreSQLCircuitBreakerCooldown = 30 * time.Second
+
+// postgreSQLCircuitBreaker stops querying a database after consecutive failures,
+// so an unavailable database isn't hammered with connection attempts
+type postgreSQLCircuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mutex    sync.Mutex
+	state    postgreSQLCircuitState
+	failures int
+	openedAt time.Time
+	// cause is the failure which opened the circuit. The rejected queries fail with it, so they keep
+	// its category for onErrorByCategory and its reason in the Ready condition
+	cause error
+}
+
+func newPostgreSQLCircuitBreaker(threshold int, cooldown time.Duration) *postgreSQLCircuitBreaker {
+	return &postgreSQLCircuitBreaker{threshold: threshold, cooldown: cooldown}
+}
+
+// allow reports whether a query may be run. Once the cooldown of an open circuit is over,
+// the circuit becomes half-open and a single probe is allowed until its result is recorded
+func (cb *postgreSQLCircuitBreaker) allow(now time.Time) bool {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+
+	switch cb.state {
+	case postgreSQLCircuitOpen:
+		if now.Sub(cb.openedAt) < cb.cooldown {
+			return false
+		}
+		cb.state = postgreSQLCircuitHalfOpen
+		return true
+	case postgreSQLCircuitHalfOpen:
+		return false
+	default:
+		return true
+	}
+}
+
+// recordSuccess closes the circuit and resets the failure counter
+func (cb *postgreSQLCircuitBreaker) recordSuccess() {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+
+	cb.state = postgreSQLCircuitClosed
+	cb.failures = 0
+}
+
+// recordFailure counts a query which failed with err, opening the circuit once the threshold is reached
+// or when the probe of a half-open circuit failed. It returns true if the circuit was opened
+func (cb *postgreSQLCircuitBreaker) recordFailure(now time.Time, err error) bool {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+
+	cb.failures++
+	if cb.state == postgreSQLCircuitHalfOpen || (cb.state == postgreSQLCircuitClosed && cb.failures >= cb.threshold) {
+		cb.state = postgreSQLCircuitOpen
+		cb.openedAt = now
+		cb.cause = err
+		return true
+	}
+	return false
+}
+
+// openError returns the error of the queries rejected by the open circuit, wrapping the failure which opened it
+func (cb *postgreSQLCircuitBreaker) openError() error {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+
+	return newPostgreSQLError(fmt.Errorf("postgreSQL circuit breaker is open after %d consecutive failures: %w", cb.failures, cb.cause))
+}
+
+// consecutiveFailures returns the number of failed queries since the last success
+func (cb *postgreSQLCircuitBreaker) consecutiveFailures() int {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+
+	return cb.failures
+}
@@ -0,0 +1,129 @@
+package scalers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+var testPostgreSQLCircuitBreakerMetadata = []parsePostgresMetadataTestData{
+	// circuit breaker with onError lastValue
+	{
+		metadata:    map[string]string{"query": "query", "targetQueryValue": "12", "connectionFromEnv": "POSTGRE_CONN_STR", "circuitBreakerThreshold": "5", "circuitBreakerCooldown": "1m", "onError": "lastValue"},
+		authParams:  map[string]string{},
+		resolvedEnv: testPostgresResolvedEnv,
+		raisesError: false,
+	},
+	// negative circuitBreakerThreshold
+	{
+		metadata:    map[string]string{"query": "query", "targetQueryValue": "12", "connectionFromEnv": "POSTGRE_CONN_STR", "circuitBreakerThreshold": "-1"},
+		authParams:  map[string]string{},
+		resolvedEnv: testPostgresResolvedEnv,
+		raisesError: true,
+	},
+	// invalid circuitBreakerCooldown
+	{
+		metadata:    map[string]string{"query": "query", "targetQueryValue": "12", "connectionFromEnv": "POSTGRE_CONN_STR", "circuitBreakerThreshold": "5", "circuitBreakerCooldown": "-1s"},
+		authParams:  map[string]string{},
+		resolvedEnv: testPostgresResolvedEnv,
+		raisesError: true,
+	},
+}
+
+func TestParsePostgreSQLCircuitBreakerMetadata(t *testing.T) {
+	testParsePostgreSQLMetadata(t, testPostgreSQLCircuitBreakerMetadata)
+}
+
+func TestPostgreSQLCircuitBreakerTransitions(t *testing.T) {
+	start := time.Now()
+	cb := newPostgreSQLCircuitBreaker(3, time.Minute)
+
+	// closed: failures below the threshold keep the circuit closed
+	for i := 0; i < 2; i++ {
+		if !cb.allow(start) {
+			t.Fatal("Expected closed circuit to allow queries")
+		}
+		if cb.recordFailure(start, errors.New("connection refused")) {
+			t.Fatal("Expected circuit to stay closed below the threshold")
+		}
+	}
+	// a success resets the counter
+	cb.recordSuccess()
+	if cb.consecutiveFailures() != 0 {
+		t.Errorf("Expected failures to be reset after success, got %d", cb.consecutiveFailures())
+	}
+
+	// closed -> open after reaching the threshold
+	for i := 0; i < 2; i++ {
+		cb.recordFailure(start, errors.New("connection refused"))
+	}
+	if !cb.recordFailure(start, errors.New("connection refused")) {
+		t.Fatal("Expected circuit to open at the threshold")
+	}
+	if cb.state != postgreSQLCircuitOpen {
+		t.Fatalf("Expected open circuit, got %d", cb.state)
+	}
+	if cb.allow(start.Add(30 * time.Second)) {
+		t.Error("Expected open circuit to reject queries during the cooldown")
+	}
+
+	// open -> half-open after the cooldown, allowing a single probe
+	if !cb.allow(start.Add(time.Minute)) {
+		t.Fatal("Expected a probe to be allowed after the cooldown")
+	}
+	if cb.state != postgreSQLCircuitHalfOpen {
+		t.Fatalf("Expected half-open circuit, got %d", cb.state)
+	}
+	if cb.allow(start.Add(time.Minute)) {
+		t.Error("Expected only one probe while half-open")
+	}
+
+	// half-open -> open when the probe fails
+	if !cb.recordFailure(start.Add(time.Minute), errors.New("connection refused")) {
+		t.Fatal("Expected circuit to reopen after a failed probe")
+	}
+	if cb.allow(start.Add(90 * time.Second)) {
+		t.Error("Expected reopened circuit to start a new cooldown")
+	}
+
+	// half-open -> closed when the probe succeeds
+	if !cb.allow(start.Add(2 * time.Minute)) {
+		t.Fatal("Expected a probe to be allowed after the second cooldown")
+	}
+	cb.recordSuccess()
+	if cb.state != postgreSQLCircuitClosed || !cb.allow(start.Add(2*time.Minute)) {
+		t.Error("Expected circuit to close after a successful probe")
+	}
+}
+
+func TestPostgreSQLScalerCircuitBreaker(t *testing.T) {
+	scaler, mock := newPostgreSQLMockScaler(t, &ScalerConfig{
+		TriggerMetadata: map[string]string{"query": "test_query", "targetQueryValue": "5", "circuitBreakerThreshold": "2", "circuitBreakerCooldown": "1h", "onError": "lastValue"},
+		AuthParams:      map[string]string{"connection": "host=localhost"},
+	})
+
+	mock.ExpectQuery("test_query").WillReturnRows(sqlmock.NewRows([]string{"value"}).AddRow(4))
+	mock.ExpectQuery("test_query").WillReturnError(errors.New("connection refused"))
+	mock.ExpectQuery("test_query").WillReturnError(errors.New("connection refused"))
+	for i := 0; i < 3; i++ {
+		// failures fall back to the last value
+		if value, err := scaler.getActiveNumber(context.Background()); err != nil || value != 4 {
+			t.Errorf("Expected value 4 but got %v (%v)", value, err)
+		}
+	}
+
+	// the circuit is open now, so the database isn't queried
+	if value, err := scaler.getActiveNumber(context.Background()); err != nil || value != 4 {
+		t.Errorf("Expected last value 4 from open circuit but got %v (%v)", value, err)
+	}
+	scaler.metadata.onError = postgreSQLOnErrorFail
+	if _, err := scaler.getActiveNumber(context.Background()); err == nil {
+		t.Error("Expected fast error from open circuit")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
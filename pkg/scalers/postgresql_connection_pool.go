@@ -0,0 +1,180 @@
+package scalers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// defaultPostgreSQLConnectionReuseGracePeriod is how long an unused connection is kept open. KEDA closes
+// the scalers of a ScaledObject before creating the new ones on every change, so without it a query only
+// change would always reconnect
+const defaultPostgreSQLConnectionReuseGracePeriod = time.Minute
+
+// parsePostgreSQLConnectionPoolMetadata parses how long the connections of the database handle are kept open
+func parsePostgreSQLConnectionPoolMetadata(config *ScalerConfig, meta *postgreSQLMetadata) error {
+	if val, ok := config.TriggerMetadata["idleConnectionTimeout"]; ok {
+		idleConnectionTimeout, err := parsePostgreSQLDuration("idleConnectionTimeout", val)
+		if err != nil {
+			return err
+		}
+		if idleConnectionTimeout <= 0 {
+			return fmt.Errorf("idleConnectionTimeout must be positive, got %s", idleConnectionTimeout)
+		}
+		meta.idleConnectionTimeout = idleConnectionTimeout
+	}
+
+	if val, ok := config.TriggerMetadata["connectionMaxLifetime"]; ok {
+		connectionMaxLifetime, err := parsePostgreSQLDuration("connectionMaxLifetime", val)
+		if err != nil {
+			return err
+		}
+		if connectionMaxLifetime <= 0 {
+			return fmt.Errorf("connectionMaxLifetime must be positive, got %s", connectionMaxLifetime)
+		}
+		meta.connectionMaxLifetime = connectionMaxLifetime
+	}
+	return nil
+}
+
+// postgreSQLConnectionPoolKey holds the settings a database handle depends on. Scalers with equal keys
+// share the handle, other metadata such as the query or the targets doesn't matter. The TLS
+// settings are part of it, a handle verifying the server mustn't be shared with one which doesn't
+type postgreSQLConnectionPoolKey struct {
+	connection            string
+	sslServerName         string
+	sslKeyPassword        string
+	sslRevocationCheck    string
+	idleConnectionTimeout time.Duration
+	connectionMaxLifetime time.Duration
+}
+
+// postgreSQLConnectionPool shares database handles between scalers with the same connection settings
+type postgreSQLConnectionPool struct {
+	open        postgreSQLConnectionOpener
+	gracePeriod time.Duration
+	mutex       sync.Mutex
+	connections map[postgreSQLConnectionPoolKey]*postgreSQLPooledConnection
+}
+
+// postgreSQLPooledConnection is a reference counted database handle of a postgreSQLConnectionPool
+type postgreSQLPooledConnection struct {
+	pool       *postgreSQLConnectionPool
+	key        postgreSQLConnectionPoolKey
+	db         *sql.DB
+	refs       int
+	closeTimer *time.Timer
+}
+
+var postgreSQLConnections = newPostgreSQLConnectionPool(openPostgreSQLConnection, defaultPostgreSQLConnectionReuseGracePeriod)
+
+func newPostgreSQLConnectionPool(open postgreSQLConnectionOpener, gracePeriod time.Duration) *postgreSQLConnectionPool {
+	return &postgreSQLConnectionPool{
+		open:        open,
+		gracePeriod: gracePeriod,
+		connections: map[postgreSQLConnectionPoolKey]*postgreSQLPooledConnection{},
+	}
+}
+
+func getPostgreSQLConnectionPoolKey(meta *postgreSQLMetadata) postgreSQLConnectionPoolKey {
+	return postgreSQLConnectionPoolKey{
+		connection:            normalizePostgreSQLConnectionString(meta.connection),
+		sslServerName:         meta.sslServerName,
+		sslKeyPassword:        meta.sslKeyPassword,
+		sslRevocationCheck:    meta.sslRevocationCheck,
+		idleConnectionTimeout: meta.idleConnectionTimeout,
+		connectionMaxLifetime: meta.connectionMaxLifetime,
+	}
+}
+
+// acquire returns the shared connection for the metadata, connecting if there is none yet
+func (p *postgreSQLConnectionPool) acquire(ctx context.Context, meta *postgreSQLMetadata, logger logr.Logger) (*postgreSQLPooledConnection, error) {
+	key := getPostgreSQLConnectionPoolKey(meta)
+	p.mutex.Lock()
+	if connection, ok := p.connections[key]; ok {
+		connection.retain()
+		p.mutex.Unlock()
+		logger.V(1).Info("Reusing postgreSQL connection")
+		return connection, nil
+	}
+	p.mutex.Unlock()
+	return p.connect(ctx, key, meta, logger, nil)
+}
+
+// refresh returns a new connection replacing old, e.g. after the TLS files were rotated. If another scaler
+// already replaced it, that connection is reused. The caller releases old once it switched to the new one
+func (p *postgreSQLConnectionPool) refresh(ctx context.Context, old *postgreSQLPooledConnection, meta *postgreSQLMetadata, logger logr.Logger) (*postgreSQLPooledConnection, error) {
+	p.mutex.Lock()
+	connection, ok := p.connections[old.key]
+	if ok && connection != old {
+		connection.retain()
+	}
+	p.mutex.Unlock()
+
+	if ok && connection != old {
+		return connection, nil
+	}
+	return p.connect(ctx, old.key, meta, logger, old)
+}
+
+// connect opens a new connection and makes it the shared one, unless another scaler connected meanwhile.
+// Connecting happens without holding the lock as it can take a while with connectRetries
+func (p *postgreSQLConnectionPool) connect(ctx context.Context, key postgreSQLConnectionPoolKey, meta *postgreSQLMetadata, logger logr.Logger, replaced *postgreSQLPooledConnection) (*postgreSQLPooledConnection, error) {
+	db, err := getConnection(ctx, meta, p.open, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if current, ok := p.connections[key]; ok && current != replaced {
+		db.Close()
+		current.retain()
+		return current, nil
+	}
+	connection := &postgreSQLPooledConnection{pool: p, key: key, db: db, refs: 1}
+	p.connections[key] = connection
+	return connection, nil
+}
+
+// retain adds a reference, the pool lock must be held
+func (c *postgreSQLPooledConnection) retain() {
+	c.refs++
+	if c.closeTimer != nil {
+		c.closeTimer.Stop()
+		c.closeTimer = nil
+	}
+}
+
+// release drops a reference. The last one closes the connection after the grace period,
+// or right away if the connection was replaced in the meantime
+func (c *postgreSQLPooledConnection) release() error {
+	p := c.pool
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	c.refs--
+	if c.refs > 0 {
+		return nil
+	}
+	current := p.connections[c.key] == c
+	if current && p.gracePeriod > 0 {
+		c.closeTimer = time.AfterFunc(p.gracePeriod, func() {
+			p.mutex.Lock()
+			defer p.mutex.Unlock()
+			if c.refs == 0 && p.connections[c.key] == c {
+				delete(p.connections, c.key)
+				c.db.Close()
+			}
+		})
+		return nil
+	}
+	if current {
+		delete(p.connections, c.key)
+	}
+	return c.db.Close()
+}
@@ -0,0 +1,344 @@
+package scalers
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+var testPostgreSQLConnectionPoolMetadata = []parsePostgresMetadataTestData{
+	// idleConnectionTimeout
+	{
+		metadata:    map[string]string{"query": "query", "targetQueryValue": "5", "idleConnectionTimeout": "5m"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		resolvedEnv: map[string]string{},
+		raisesError: false,
+	},
+	// idleConnectionTimeout not positive
+	{
+		metadata:    map[string]string{"query": "query", "targetQueryValue": "5", "idleConnectionTimeout": "0"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		resolvedEnv: map[string]string{},
+		raisesError: true,
+	},
+}
+
+func TestParsePostgreSQLConnectionPoolMetadata(t *testing.T) {
+	testParsePostgreSQLMetadata(t, testPostgreSQLConnectionPoolMetadata)
+}
+
+// newPostgreSQLCountingPool returns a pool opening sqlmock connections, which expect the creation ping
+// and the close, and the number of connections opened so far
+func newPostgreSQLCountingPool(t *testing.T, gracePeriod time.Duration) (*postgreSQLConnectionPool, *[]sqlmock.Sqlmock) {
+	t.Helper()
+	mocks := &[]sqlmock.Sqlmock{}
+	return newPostgreSQLConnectionPool(func(*postgreSQLMetadata) (*sql.DB, error) {
+		db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+		if err != nil {
+			t.Fatal("Could not create sqlmock:", err)
+		}
+		mock.MatchExpectationsInOrder(false)
+		mock.ExpectPing()
+		mock.ExpectClose()
+		*mocks = append(*mocks, mock)
+		return db, nil
+	}, gracePeriod), mocks
+}
+
+func newPostgreSQLPooledTestScaler(t *testing.T, pool *postgreSQLConnectionPool, metadata map[string]string, connection string) *postgreSQLScaler {
+	t.Helper()
+	scaler, err := newPostgreSQLScaler(&ScalerConfig{
+		TriggerMetadata: metadata,
+		AuthParams:      map[string]string{"connection": connection},
+	}, pool)
+	if err != nil {
+		t.Fatal("Could not create scaler:", err)
+	}
+	return scaler
+}
+
+func TestPostgreSQLConnectionPoolReuse(t *testing.T) {
+	pool, mocks := newPostgreSQLCountingPool(t, time.Minute)
+
+	testData := []struct {
+		name       string
+		metadata   map[string]string
+		connection string
+		opened     int
+	}{
+		{name: "initial", metadata: map[string]string{"query": "SELECT 1", "targetQueryValue": "5"}, connection: "host=localhost dbname=jobs", opened: 1},
+		{name: "query changed", metadata: map[string]string{"query": "SELECT 2", "targetQueryValue": "5"}, connection: "host=localhost dbname=jobs", opened: 1},
+		{name: "target changed", metadata: map[string]string{"query": "SELECT 2", "targetQueryValue": "10", "activationTargetQueryValue": "2"}, connection: "host=localhost dbname=jobs", opened: 1},
+		{name: "connection reordered", metadata: map[string]string{"query": "SELECT 2", "targetQueryValue": "10"}, connection: "  dbname = jobs host='localhost' ", opened: 1},
+		{name: "connection changed", metadata: map[string]string{"query": "SELECT 2", "targetQueryValue": "10"}, connection: "host=localhost dbname=orders", opened: 2},
+		{name: "connectionMaxLifetime changed", metadata: map[string]string{"query": "SELECT 2", "targetQueryValue": "10", "connectionMaxLifetime": "5m"}, connection: "host=localhost dbname=orders", opened: 3},
+		{name: "statementTimeout changed", metadata: map[string]string{"query": "SELECT 2", "targetQueryValue": "10", "statementTimeout": "5s"}, connection: "host=localhost dbname=orders", opened: 4},
+		{name: "sslmode changed", metadata: map[string]string{"query": "SELECT 2", "targetQueryValue": "10"}, connection: "host=localhost dbname=orders sslmode=verify-full", opened: 5},
+		{name: "sslRevocationCheck added", metadata: map[string]string{"query": "SELECT 2", "targetQueryValue": "10", "sslRevocationCheck": "ocsp"}, connection: "host=localhost dbname=orders sslmode=verify-full", opened: 6},
+	}
+
+	// like KEDA, the previous scaler is closed before the new one is created
+	var previous *postgreSQLScaler
+	for _, testData := range testData {
+		if previous != nil {
+			if err := previous.Close(context.Background()); err != nil {
+				t.Fatalf("%s: could not close scaler: %s", testData.name, err)
+			}
+		}
+		previous = newPostgreSQLPooledTestScaler(t, pool, testData.metadata, testData.connection)
+		if len(*mocks) != testData.opened {
+			t.Errorf("%s: expected %d opened connections but got %d", testData.name, testData.opened, len(*mocks))
+		}
+	}
+}
+
+func TestPostgreSQLConnectionPoolRevocationCheck(t *testing.T) {
+	pool, mocks := newPostgreSQLCountingPool(t, time.Minute)
+	connection := "host=localhost dbname=jobs sslmode=verify-full"
+	unchecked := newPostgreSQLPooledTestScaler(t, pool, map[string]string{"query": "SELECT 1", "targetQueryValue": "5"}, connection)
+	defer unchecked.Close(context.Background())
+	// the handle with the OCSP verification mustn't be replaced by the one without it
+	checked := newPostgreSQLPooledTestScaler(t, pool, map[string]string{"query": "SELECT 1", "targetQueryValue": "5", "sslRevocationCheck": "ocsp"}, connection)
+	defer checked.Close(context.Background())
+
+	if len(*mocks) != 2 {
+		t.Errorf("Expected 2 opened connections but got %d", len(*mocks))
+	}
+	if checked.connection.db == unchecked.connection.db {
+		t.Error("Expected scalers differing in sslRevocationCheck to use different database handles")
+	}
+}
+
+func TestNormalizePostgreSQLConnectionString(t *testing.T) {
+	testData := []struct {
+		name  string
+		a, b  string
+		equal bool
+	}{
+		{name: "keyword order", a: "host=localhost port=5432 dbname=jobs", b: "dbname=jobs host=localhost port=5432", equal: true},
+		{name: "whitespace", a: "host=localhost dbname=jobs", b: "  host = localhost\tdbname=jobs  ", equal: true},
+		{name: "quoting", a: "host=localhost password='s3cret'", b: "password=s3cret host='localhost'", equal: true},
+		{name: "URL", a: "postgresql://keda@localhost:5432/jobs?sslmode=disable", b: "user=keda host=localhost port=5432 dbname=jobs sslmode=disable", equal: true},
+		{name: "repeated keyword", a: "host=other host=localhost", b: "host=localhost", equal: true},
+		{name: "different database", a: "host=localhost dbname=jobs", b: "host=localhost dbname=orders", equal: false},
+		{name: "different user", a: "host=localhost user=a", b: "host=localhost user=b", equal: false},
+		{name: "quoted whitespace", a: "host=localhost application_name='keda '", b: "host=localhost application_name=keda", equal: false},
+		{name: "keyword case", a: "host=localhost", b: "HOST=localhost", equal: false},
+	}
+
+	for _, testData := range testData {
+		a, b := normalizePostgreSQLConnectionString(testData.a), normalizePostgreSQLConnectionString(testData.b)
+		if (a == b) != testData.equal {
+			t.Errorf("%s: expected equal %v but normalized to %q and %q", testData.name, testData.equal, a, b)
+		}
+	}
+
+	if invalid := "host=localhost dbname"; normalizePostgreSQLConnectionString(invalid) != invalid {
+		t.Errorf("Expected an invalid connection to be returned as it is")
+	}
+}
+
+func TestPostgreSQLConnectionPoolSharedUntilLastRelease(t *testing.T) {
+	pool, mocks := newPostgreSQLCountingPool(t, 0)
+	first := newPostgreSQLPooledTestScaler(t, pool, map[string]string{"query": "SELECT 1", "targetQueryValue": "5"}, "host=localhost")
+	second := newPostgreSQLPooledTestScaler(t, pool, map[string]string{"query": "SELECT 2", "targetQueryValue": "5"}, "host=localhost")
+	if len(*mocks) != 1 || first.connection != second.connection {
+		t.Fatalf("Expected both scalers to share one connection, opened %d", len(*mocks))
+	}
+
+	if err := first.Close(context.Background()); err != nil {
+		t.Fatal("Could not close scaler:", err)
+	}
+	mock := (*mocks)[0]
+	mock.ExpectQuery("SELECT 2").WillReturnRows(sqlmock.NewRows([]string{"value"}).AddRow(3))
+	if value, err := second.getActiveNumber(context.Background()); err != nil || value != 3 {
+		t.Errorf("Expected 3 from the remaining scaler but got %v, %v", value, err)
+	}
+
+	if err := second.Close(context.Background()); err != nil {
+		t.Fatal("Could not close scaler:", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+	if len(pool.connections) != 0 {
+		t.Errorf("Expected the pool to be empty but it has %d connections", len(pool.connections))
+	}
+}
+
+func TestPostgreSQLConnectionPoolGracePeriod(t *testing.T) {
+	pool, mocks := newPostgreSQLCountingPool(t, 20*time.Millisecond)
+	scaler := newPostgreSQLPooledTestScaler(t, pool, map[string]string{"query": "SELECT 1", "targetQueryValue": "5"}, "host=localhost")
+	if err := scaler.Close(context.Background()); err != nil {
+		t.Fatal("Could not close scaler:", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for (*mocks)[0].ExpectationsWereMet() != nil {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the unused connection to be closed after the grace period")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	pool.mutex.Lock()
+	remaining := len(pool.connections)
+	pool.mutex.Unlock()
+	if remaining != 0 {
+		t.Errorf("Expected the pool to be empty but it has %d connections", remaining)
+	}
+
+	newPostgreSQLPooledTestScaler(t, pool, map[string]string{"query": "SELECT 1", "targetQueryValue": "5"}, "host=localhost")
+	if len(*mocks) != 2 {
+		t.Errorf("Expected a new connection after the grace period but opened %d", len(*mocks))
+	}
+}
+
+// postgreSQLCountingConnector is a database/sql driver counting the connections it opened, all of
+// its queries return the single value 3
+type postgreSQLCountingConnector struct {
+	opened int32
+}
+
+type postgreSQLCountingConn struct{}
+
+type postgreSQLCountingRows struct {
+	done bool
+}
+
+func (c *postgreSQLCountingConnector) Connect(context.Context) (driver.Conn, error) {
+	atomic.AddInt32(&c.opened, 1)
+	return postgreSQLCountingConn{}, nil
+}
+
+func (c *postgreSQLCountingConnector) Driver() driver.Driver {
+	return nil
+}
+
+func (postgreSQLCountingConn) QueryContext(context.Context, string, []driver.NamedValue) (driver.Rows, error) {
+	return &postgreSQLCountingRows{}, nil
+}
+
+func (postgreSQLCountingConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("prepared statements are not supported")
+}
+
+func (postgreSQLCountingConn) Close() error {
+	return nil
+}
+
+func (postgreSQLCountingConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("transactions are not supported")
+}
+
+func (r *postgreSQLCountingRows) Columns() []string {
+	return []string{"count"}
+}
+
+func (r *postgreSQLCountingRows) Close() error {
+	return nil
+}
+
+func (r *postgreSQLCountingRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = int64(3)
+	return nil
+}
+
+func TestPostgreSQLIdleConnectionTimeout(t *testing.T) {
+	connector := &postgreSQLCountingConnector{}
+	db := sql.OpenDB(connector)
+	scaler, err := newPostgreSQLScaler(&ScalerConfig{
+		TriggerMetadata: map[string]string{"query": "SELECT count(*) FROM jobs", "targetQueryValue": "5", "idleConnectionTimeout": "10ms"},
+		AuthParams:      map[string]string{"connection": "host=localhost"},
+	}, newPostgreSQLConnectionPool(func(*postgreSQLMetadata) (*sql.DB, error) {
+		return db, nil
+	}, 0))
+	if err != nil {
+		t.Fatal("Could not create scaler:", err)
+	}
+	defer scaler.Close(context.Background())
+
+	for round := int32(1); round <= 2; round++ {
+		value, err := scaler.getActiveNumber(context.Background())
+		if err != nil {
+			t.Fatal("Unexpected error querying:", err)
+		}
+		if value != 3 {
+			t.Errorf("Expected value 3 but got %v", value)
+		}
+		if opened := atomic.LoadInt32(&connector.opened); opened != round {
+			t.Fatalf("Expected %d opened connections but got %d", round, opened)
+		}
+
+		// database/sql checks for idle connections at most once per second
+		deadline := time.Now().Add(5 * time.Second)
+		for db.Stats().OpenConnections > 0 && time.Now().Before(deadline) {
+			time.Sleep(50 * time.Millisecond)
+		}
+		if open := db.Stats().OpenConnections; open != 0 {
+			t.Fatalf("Expected the idle connection to be closed but %d are open", open)
+		}
+	}
+}
+
+func TestPostgreSQLConnectionMaxLifetime(t *testing.T) {
+	testData := []struct {
+		name     string
+		metadata map[string]string
+		recycled bool
+	}{
+		{name: "without connectionMaxLifetime", metadata: map[string]string{}, recycled: false},
+		{name: "with connectionMaxLifetime", metadata: map[string]string{"connectionMaxLifetime": "20ms"}, recycled: true},
+	}
+
+	for _, testData := range testData {
+		t.Run(testData.name, func(t *testing.T) {
+			connector := &postgreSQLCountingConnector{}
+			db := sql.OpenDB(connector)
+			metadata := map[string]string{"query": "SELECT count(*) FROM jobs", "targetQueryValue": "5"}
+			for key, value := range testData.metadata {
+				metadata[key] = value
+			}
+			scaler, err := newPostgreSQLScaler(&ScalerConfig{
+				TriggerMetadata: metadata,
+				AuthParams:      map[string]string{"connection": "host=localhost"},
+			}, newPostgreSQLConnectionPool(func(*postgreSQLMetadata) (*sql.DB, error) {
+				return db, nil
+			}, 0))
+			if err != nil {
+				t.Fatal("Could not create scaler:", err)
+			}
+			defer scaler.Close(context.Background())
+
+			if _, err := scaler.getActiveNumber(context.Background()); err != nil {
+				t.Fatal("Unexpected error querying:", err)
+			}
+			opened := atomic.LoadInt32(&connector.opened)
+			for round := 0; round < 3; round++ {
+				time.Sleep(50 * time.Millisecond)
+				if _, err := scaler.getActiveNumber(context.Background()); err != nil {
+					t.Fatal("Unexpected error querying:", err)
+				}
+			}
+
+			reopened := atomic.LoadInt32(&connector.opened) - opened
+			if testData.recycled && (reopened != 3 || db.Stats().MaxLifetimeClosed == 0) {
+				t.Errorf("Expected every query to use a new connection but %d were opened", reopened)
+			}
+			if !testData.recycled && reopened != 0 {
+				t.Errorf("Expected the connection to be reused but %d were opened", reopened)
+			}
+		})
+	}
+}
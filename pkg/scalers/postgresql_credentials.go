@@ -0,0 +1,71 @@
+package scalers
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// parsePostgreSQLCredentialsMetadata parses the credentialProvider supplying the password
+func parsePostgreSQLCredentialsMetadata(config *ScalerConfig, meta *postgreSQLMetadata) error {
+	meta.credentialProvider = postgreSQLCredentialProviderStatic
+	if val, ok := config.TriggerMetadata["credentialProvider"]; ok && val != "" {
+		if _, ok := postgreSQLCredentialProviders[val]; !ok {
+			return fmt.Errorf("unknown credentialProvider %s", val)
+		}
+		meta.credentialProvider = val
+	}
+	return nil
+}
+
+// postgreSQLCredentialProvider supplies the password used to connect, e.g. fetched from Vault
+// or a cloud secret store, so it can be rotated without changing the ScaledObject
+type postgreSQLCredentialProvider interface {
+	// getPassword returns the password and when it expires, the zero time if it doesn't.
+	// An empty password keeps the one of the connection
+	getPassword(ctx context.Context) (string, time.Time, error)
+}
+
+// postgreSQLCredentialProviderFactory creates a provider from the scaler configuration
+type postgreSQLCredentialProviderFactory func(config *ScalerConfig) (postgreSQLCredentialProvider, error)
+
+const postgreSQLCredentialProviderStatic = "static"
+
+// postgreSQLCredentialProviders are the providers which can be selected with credentialProvider
+var postgreSQLCredentialProviders = map[string]postgreSQLCredentialProviderFactory{
+	postgreSQLCredentialProviderStatic: func(*ScalerConfig) (postgreSQLCredentialProvider, error) {
+		return postgreSQLStaticCredentials{}, nil
+	},
+}
+
+// postgreSQLCredentialRefreshMargin renews credentials ahead of their expiry, so a query
+// doesn't race with it
+const postgreSQLCredentialRefreshMargin = 30 * time.Second
+
+// postgreSQLStaticCredentials keeps the password of the connection, which never expires
+type postgreSQLStaticCredentials struct{}
+
+func (postgreSQLStaticCredentials) getPassword(context.Context) (string, time.Time, error) {
+	return "", time.Time{}, nil
+}
+
+// resolvePostgreSQLCredentials returns a copy of the metadata whose connection uses the password
+// of the provider, and when that password expires
+func resolvePostgreSQLCredentials(ctx context.Context, provider postgreSQLCredentialProvider, meta *postgreSQLMetadata) (*postgreSQLMetadata, time.Time, error) {
+	password, expiresAt, err := provider.getPassword(ctx)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("error getting postgreSQL credentials: %s", err)
+	}
+	if password == "" {
+		return meta, expiresAt, nil
+	}
+
+	params, err := parsePostgreSQLConnectionString(meta.connection)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("error parsing connection for credentials: %s", err)
+	}
+	params["password"] = password
+	connectionMeta := *meta
+	connectionMeta.connection = formatPostgreSQLConnectionString(params)
+	return &connectionMeta, expiresAt, nil
+}
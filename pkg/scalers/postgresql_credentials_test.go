@@ -0,0 +1,123 @@
+package scalers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// postgreSQLRotatingCredentials hands out a new password on every call
+type postgreSQLRotatingCredentials struct {
+	mutex    sync.Mutex
+	calls    int
+	validFor time.Duration
+	err      error
+}
+
+var testPostgreSQLCredentialsMetadata = []parsePostgresMetadataTestData{
+	// static credentialProvider
+	{
+		metadata:    map[string]string{"query": "query", "targetQueryValue": "12", "credentialProvider": "static"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		resolvedEnv: map[string]string{},
+		raisesError: false,
+	},
+	// unknown credentialProvider
+	{
+		metadata:    map[string]string{"query": "query", "targetQueryValue": "12", "credentialProvider": "vault"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		resolvedEnv: map[string]string{},
+		raisesError: true,
+	},
+}
+
+func TestParsePostgreSQLCredentialsMetadata(t *testing.T) {
+	testParsePostgreSQLMetadata(t, testPostgreSQLCredentialsMetadata)
+}
+
+func (c *postgreSQLRotatingCredentials) getPassword(context.Context) (string, time.Time, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.err != nil {
+		return "", time.Time{}, c.err
+	}
+	c.calls++
+	return fmt.Sprintf("password-%d", c.calls), time.Now().Add(c.validFor), nil
+}
+
+func TestPostgreSQLCredentialProviderRotation(t *testing.T) {
+	credentials := &postgreSQLRotatingCredentials{validFor: time.Hour}
+	postgreSQLCredentialProviders["rotating"] = func(*ScalerConfig) (postgreSQLCredentialProvider, error) {
+		return credentials, nil
+	}
+	defer delete(postgreSQLCredentialProviders, "rotating")
+
+	var passwords []string
+	var mocks []sqlmock.Sqlmock
+	pool := newPostgreSQLConnectionPool(func(meta *postgreSQLMetadata) (*sql.DB, error) {
+		params, err := parsePostgreSQLConnectionString(meta.connection)
+		if err != nil {
+			return nil, err
+		}
+		passwords = append(passwords, params["password"])
+		db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+		if err != nil {
+			return nil, err
+		}
+		mock.MatchExpectationsInOrder(false)
+		mock.ExpectPing()
+		mock.ExpectClose()
+		mock.ExpectQuery("SELECT count").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(len(mocks) + 1))
+		mocks = append(mocks, mock)
+		return db, nil
+	}, 0)
+
+	scaler, err := newPostgreSQLScaler(&ScalerConfig{
+		TriggerMetadata: map[string]string{"query": "SELECT count(*) FROM jobs", "targetQueryValue": "5", "credentialProvider": "rotating"},
+		AuthParams:      map[string]string{"connection": "host=localhost user=postgres password=initial"},
+	}, pool)
+	if err != nil {
+		t.Fatal("Could not create scaler:", err)
+	}
+
+	if value, err := scaler.getActiveNumber(context.Background()); err != nil || value != 1 {
+		t.Fatalf("Expected 1 from the first connection but got %v, %v", value, err)
+	}
+
+	// the credentials are about to expire
+	scaler.credentialsExpireAt = time.Now().Add(time.Second)
+	if value, err := scaler.getActiveNumber(context.Background()); err != nil || value != 2 {
+		t.Fatalf("Expected 2 from the connection with new credentials but got %v, %v", value, err)
+	}
+
+	if len(passwords) != 2 || passwords[0] != "password-1" || passwords[1] != "password-2" {
+		t.Errorf("Expected connections with password-1 and password-2 but got %v", passwords)
+	}
+	if err := mocks[0].ExpectationsWereMet(); err != nil {
+		t.Errorf("Expected the connection with expired credentials to be closed: %s", err)
+	}
+
+	// failing to refresh fails the read instead of using expired credentials
+	credentials.err = errors.New("vault is sealed")
+	scaler.credentialsExpireAt = time.Now()
+	if _, err := scaler.getActiveNumber(context.Background()); err == nil {
+		t.Error("Expected error when the credentials can't be refreshed but got success")
+	}
+}
+
+func TestPostgreSQLStaticCredentials(t *testing.T) {
+	meta := &postgreSQLMetadata{connection: "host=localhost password=secret"}
+	connectionMeta, expiresAt, err := resolvePostgreSQLCredentials(context.Background(), postgreSQLStaticCredentials{}, meta)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if connectionMeta.connection != meta.connection || !expiresAt.IsZero() {
+		t.Errorf("Expected the connection to be kept without expiry but got %q expiring at %v", connectionMeta.connection, expiresAt)
+	}
+}
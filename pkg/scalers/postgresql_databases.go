@@ -0,0 +1,191 @@
+package scalers
+
+import (
+	"context"
+	"fmt"
+	"math"
+)
+
+const (
+	// postgreSQLDatabasesAggregationMax reports the largest value of the databases
+	postgreSQLDatabasesAggregationMax = "max"
+	// postgreSQLDatabasesAggregationSum reports the sum of the values of the databases
+	postgreSQLDatabasesAggregationSum = "sum"
+)
+
+const (
+	// postgreSQLOnDatabaseErrorFail fails the read if any database fails
+	postgreSQLOnDatabaseErrorFail = "fail"
+	// postgreSQLOnDatabaseErrorIgnore aggregates the databases which answered, failing only if none did
+	postgreSQLOnDatabaseErrorIgnore = "ignore"
+)
+
+// postgreSQLDatabasesMetadataKeys only apply to the scaler querying all connections, not to its databases
+var postgreSQLDatabasesMetadataKeys = []string{"databasesAggregation", "onDatabaseError", "databasesTimeout"}
+
+// parsePostgreSQLDatabasesMetadata parses the aggregation of the connections given as a JSON array
+func parsePostgreSQLDatabasesMetadata(config *ScalerConfig, meta *postgreSQLMetadata) error {
+	if len(meta.databaseConnections) == 0 {
+		for _, key := range postgreSQLDatabasesMetadataKeys {
+			if _, ok := config.TriggerMetadata[key]; ok {
+				return fmt.Errorf("%s can only be used with connections", key)
+			}
+		}
+		return nil
+	}
+
+	meta.databasesAggregation = postgreSQLDatabasesAggregationMax
+	if val, ok := config.TriggerMetadata["databasesAggregation"]; ok && val != "" {
+		switch val {
+		case postgreSQLDatabasesAggregationMax, postgreSQLDatabasesAggregationSum:
+			meta.databasesAggregation = val
+		default:
+			return fmt.Errorf("unknown databasesAggregation %s, must be one of %s, %s", val, postgreSQLDatabasesAggregationMax, postgreSQLDatabasesAggregationSum)
+		}
+	}
+
+	meta.onDatabaseError = postgreSQLOnDatabaseErrorFail
+	if val, ok := config.TriggerMetadata["onDatabaseError"]; ok && val != "" {
+		switch val {
+		case postgreSQLOnDatabaseErrorFail, postgreSQLOnDatabaseErrorIgnore:
+			meta.onDatabaseError = val
+		default:
+			return fmt.Errorf("unknown onDatabaseError %s, must be one of %s, %s", val, postgreSQLOnDatabaseErrorFail, postgreSQLOnDatabaseErrorIgnore)
+		}
+	}
+
+	if val, ok := config.TriggerMetadata["databasesTimeout"]; ok && val != "" {
+		databasesTimeout, err := parsePostgreSQLDuration("databasesTimeout", val)
+		if err != nil {
+			return err
+		}
+		if databasesTimeout <= 0 {
+			return fmt.Errorf("databasesTimeout must be positive, got %s", databasesTimeout)
+		}
+		meta.databasesTimeout = databasesTimeout
+	}
+
+	// a pushed value can't be aggregated with the other databases
+	if meta.notifyChannel != "" {
+		return fmt.Errorf("notifyChannel can't be used with connections")
+	}
+	return nil
+}
+
+// newPostgreSQLDatabaseScalers creates a scaler for each additional connection. They share the trigger
+// metadata and their signals are recorded with the metric name suffixed by the position of the connection,
+// counting from 1 like the errors of the databases
+func newPostgreSQLDatabaseScalers(config *ScalerConfig, meta *postgreSQLMetadata, connections *postgreSQLConnectionPool) ([]*postgreSQLScaler, error) {
+	var databases []*postgreSQLScaler
+	for i, connection := range meta.databaseConnections[1:] {
+		databaseConfig := *config
+		databaseConfig.AuthParams = map[string]string{}
+		for key, value := range config.AuthParams {
+			databaseConfig.AuthParams[key] = value
+		}
+		delete(databaseConfig.AuthParams, "connections")
+		databaseConfig.AuthParams["connection"] = connection
+		databaseConfig.TriggerMetadata = map[string]string{}
+		for key, value := range config.TriggerMetadata {
+			databaseConfig.TriggerMetadata[key] = value
+		}
+		for _, key := range postgreSQLDatabasesMetadataKeys {
+			delete(databaseConfig.TriggerMetadata, key)
+		}
+		// the aggregated value is shared, the databases are only queried through it
+		delete(databaseConfig.TriggerMetadata, "sharedPollingInterval")
+
+		metricName := fmt.Sprintf("%s-database%d", GenerateMetricNameWithIndex(meta.scalerIndex, meta.metricName), i+2)
+		database, err := newPostgreSQLScalerRecordedAs(&databaseConfig, connections, metricName)
+		if err != nil {
+			closePostgreSQLDatabaseScalers(databases)
+			return nil, fmt.Errorf("error creating scaler for connection %d: %w", i+2, err)
+		}
+		databases = append(databases, database)
+	}
+	return databases, nil
+}
+
+func closePostgreSQLDatabaseScalers(databases []*postgreSQLScaler) {
+	for _, database := range databases {
+		_ = database.Close(context.Background())
+	}
+}
+
+// postgreSQLDatabaseResult is the value or the error of the database with the index
+type postgreSQLDatabaseResult struct {
+	index int
+	value float64
+	err   error
+}
+
+// queryDatabases queries the database of the scaler and the additional databases concurrently
+// and aggregates their values. With databasesTimeout the databases which didn't answer by the
+// deadline count as failed, so a slow region doesn't hold up the others
+func (s *postgreSQLScaler) queryDatabases(ctx context.Context) (float64, error) {
+	if len(s.databases) == 0 {
+		return s.queryDatabase(ctx)
+	}
+	if s.metadata.databasesTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.metadata.databasesTimeout)
+		defer cancel()
+	}
+
+	databases := append([]*postgreSQLScaler{s}, s.databases...)
+	// buffered, so the queries still running after the deadline don't block
+	results := make(chan postgreSQLDatabaseResult, len(databases))
+	for i, database := range databases {
+		go func(i int, database *postgreSQLScaler) {
+			value, err := database.queryDatabase(ctx)
+			results <- postgreSQLDatabaseResult{index: i, value: value, err: err}
+		}(i, database)
+	}
+
+	values := make([]float64, len(databases))
+	errs := make([]error, len(databases))
+	answered := make([]bool, len(databases))
+	for pending := len(databases); pending > 0; pending-- {
+		select {
+		case result := <-results:
+			values[result.index], errs[result.index] = result.value, result.err
+			answered[result.index] = true
+		case <-ctx.Done():
+			for i := range databases {
+				if !answered[i] {
+					errs[i] = fmt.Errorf("no answer before the deadline: %w", ctx.Err())
+				}
+			}
+			return aggregatePostgreSQLDatabaseValues(values, errs, s.metadata.databasesAggregation, s.metadata.onDatabaseError)
+		}
+	}
+	return aggregatePostgreSQLDatabaseValues(values, errs, s.metadata.databasesAggregation, s.metadata.onDatabaseError)
+}
+
+// aggregatePostgreSQLDatabaseValues aggregates the values of the databases without an error. With onDatabaseError
+// fail the first error fails the read, with ignore only the failure of all databases does
+func aggregatePostgreSQLDatabaseValues(values []float64, errs []error, aggregation, onDatabaseError string) (float64, error) {
+	var result float64
+	answered := 0
+	for i, err := range errs {
+		if err != nil {
+			if onDatabaseError == postgreSQLOnDatabaseErrorFail {
+				return 0, fmt.Errorf("error querying database %d: %w", i+1, err)
+			}
+			continue
+		}
+		switch {
+		case answered == 0:
+			result = values[i]
+		case aggregation == postgreSQLDatabasesAggregationSum:
+			result += values[i]
+		default:
+			result = math.Max(result, values[i])
+		}
+		answered++
+	}
+	if answered == 0 {
+		return 0, fmt.Errorf("error querying all %d databases, first error: %w", len(errs), errs[0])
+	}
+	return result, nil
+}
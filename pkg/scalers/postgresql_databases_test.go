@@ -0,0 +1,311 @@
+package scalers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var testPostgreSQLDatabasesMetadata = []parsePostgresMetadataTestData{
+	// connections with databasesAggregation and onDatabaseError
+	{
+		metadata:    map[string]string{"query": "query", "targetQueryValue": "12", "databasesAggregation": "sum", "onDatabaseError": "ignore"},
+		authParams:  map[string]string{"connections": `["host=eu", "host=us"]`},
+		resolvedEnv: map[string]string{},
+		raisesError: false,
+	},
+	// empty connections
+	{
+		metadata:    map[string]string{"query": "query", "targetQueryValue": "12"},
+		authParams:  map[string]string{"connections": `[]`},
+		resolvedEnv: map[string]string{},
+		raisesError: true,
+	},
+	// unknown databasesAggregation
+	{
+		metadata:    map[string]string{"query": "query", "targetQueryValue": "12", "databasesAggregation": "avg"},
+		authParams:  map[string]string{"connections": `["host=eu", "host=us"]`},
+		resolvedEnv: map[string]string{},
+		raisesError: true,
+	},
+	// unknown onDatabaseError
+	{
+		metadata:    map[string]string{"query": "query", "targetQueryValue": "12", "onDatabaseError": "skip"},
+		authParams:  map[string]string{"connections": `["host=eu", "host=us"]`},
+		resolvedEnv: map[string]string{},
+		raisesError: true,
+	},
+	// databasesAggregation without connections
+	{
+		metadata:    map[string]string{"query": "query", "targetQueryValue": "12", "databasesAggregation": "sum"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		resolvedEnv: map[string]string{},
+		raisesError: true,
+	},
+	// connections with notifyChannel
+	{
+		metadata:    map[string]string{"query": "query", "targetQueryValue": "12", "notifyChannel": "jobs"},
+		authParams:  map[string]string{"connections": `["host=eu", "host=us"]`},
+		resolvedEnv: map[string]string{},
+		raisesError: true,
+	},
+	// databasesTimeout
+	{
+		metadata:   map[string]string{"query": "select 1", "targetQueryValue": "1", "databasesTimeout": "2s"},
+		authParams: map[string]string{"connections": `["host=eu", "host=us"]`},
+	},
+	// databasesTimeout not positive
+	{
+		metadata:    map[string]string{"query": "select 1", "targetQueryValue": "1", "databasesTimeout": "0s"},
+		authParams:  map[string]string{"connections": `["host=eu", "host=us"]`},
+		raisesError: true,
+	},
+	// databasesTimeout without connections
+	{
+		metadata:    map[string]string{"query": "select 1", "targetQueryValue": "1", "databasesTimeout": "2s"},
+		authParams:  map[string]string{"connection": "test_connection_string"},
+		raisesError: true,
+	},
+}
+
+func TestParsePostgreSQLDatabasesMetadata(t *testing.T) {
+	testParsePostgreSQLMetadata(t, testPostgreSQLDatabasesMetadata)
+}
+
+func TestAggregatePostgreSQLDatabaseValues(t *testing.T) {
+	refused := errors.New("connection refused")
+	testData := []struct {
+		name            string
+		values          []float64
+		errs            []error
+		aggregation     string
+		onDatabaseError string
+		expected        float64
+		raisesError     bool
+	}{
+		{name: "max", values: []float64{3, 9, 4}, errs: []error{nil, nil, nil}, aggregation: "max", onDatabaseError: "fail", expected: 9},
+		{name: "sum", values: []float64{3, 9, 4}, errs: []error{nil, nil, nil}, aggregation: "sum", onDatabaseError: "fail", expected: 16},
+		{name: "max of negative values", values: []float64{-3, -1}, errs: []error{nil, nil}, aggregation: "max", onDatabaseError: "fail", expected: -1},
+		{name: "failed database fails", values: []float64{3, 0, 4}, errs: []error{nil, refused, nil}, aggregation: "sum", onDatabaseError: "fail", raisesError: true},
+		{name: "failed database ignored", values: []float64{3, 0, 4}, errs: []error{nil, refused, nil}, aggregation: "sum", onDatabaseError: "ignore", expected: 7},
+		{name: "failed first database ignored", values: []float64{0, 5}, errs: []error{refused, nil}, aggregation: "max", onDatabaseError: "ignore", expected: 5},
+		{name: "all databases failed", values: []float64{0, 0}, errs: []error{refused, refused}, aggregation: "max", onDatabaseError: "ignore", raisesError: true},
+	}
+
+	for _, testData := range testData {
+		value, err := aggregatePostgreSQLDatabaseValues(testData.values, testData.errs, testData.aggregation, testData.onDatabaseError)
+		if err != nil && !testData.raisesError {
+			t.Errorf("%s: expected success but got error %s", testData.name, err)
+		}
+		if err == nil && testData.raisesError {
+			t.Errorf("%s: expected error but got success", testData.name)
+		}
+		if err == nil && value != testData.expected {
+			t.Errorf("%s: expected %v but got %v", testData.name, testData.expected, value)
+		}
+	}
+
+	// databases are counted from 1, like the metric names of the connections
+	_, err := aggregatePostgreSQLDatabaseValues([]float64{3, 0}, []error{nil, refused}, "max", "fail")
+	if err == nil || !strings.Contains(err.Error(), "database 2") {
+		t.Errorf("Expected the error of the second database but got %v", err)
+	}
+}
+
+// newPostgreSQLMockDatabasesScaler creates a scaler with connections, each connection gets its own sqlmock
+func newPostgreSQLMockDatabasesScaler(t *testing.T, metadata map[string]string, connections ...string) (*postgreSQLScaler, map[string]sqlmock.Sqlmock) {
+	t.Helper()
+	dbs := map[string]*sql.DB{}
+	mocks := map[string]sqlmock.Sqlmock{}
+	for _, connection := range connections {
+		db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+		if err != nil {
+			t.Fatal("Could not create sqlmock:", err)
+		}
+		mock.ExpectPing()
+		dbs[connection], mocks[connection] = db, mock
+	}
+	connectionsJSON, err := json.Marshal(connections)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scaler, err := newPostgreSQLScaler(&ScalerConfig{
+		TriggerMetadata: metadata,
+		AuthParams:      map[string]string{"connections": string(connectionsJSON)},
+	}, newPostgreSQLConnectionPool(func(meta *postgreSQLMetadata) (*sql.DB, error) {
+		return dbs[meta.connection], nil
+	}, 0))
+	if err != nil {
+		t.Fatal("Could not create scaler:", err)
+	}
+	return scaler, mocks
+}
+
+func TestPostgreSQLScalerDatabases(t *testing.T) {
+	testData := []struct {
+		metadata    map[string]string
+		usFails     bool
+		expected    float64
+		raisesError bool
+	}{
+		{metadata: map[string]string{}, expected: 9},
+		{metadata: map[string]string{"databasesAggregation": "sum"}, expected: 16},
+		{metadata: map[string]string{"databasesAggregation": "sum"}, usFails: true, raisesError: true},
+		{metadata: map[string]string{"databasesAggregation": "sum", "onDatabaseError": "ignore"}, usFails: true, expected: 7},
+	}
+
+	for _, testData := range testData {
+		metadata := map[string]string{"query": "SELECT count(*) FROM jobs", "targetQueryValue": "5"}
+		for key, value := range testData.metadata {
+			metadata[key] = value
+		}
+		scaler, mocks := newPostgreSQLMockDatabasesScaler(t, metadata, "host=eu", "host=us", "host=ap")
+		mocks["host=eu"].ExpectQuery("SELECT count").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+		if testData.usFails {
+			mocks["host=us"].ExpectQuery("SELECT count").WillReturnError(errors.New("connection refused"))
+		} else {
+			mocks["host=us"].ExpectQuery("SELECT count").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(9))
+		}
+		mocks["host=ap"].ExpectQuery("SELECT count").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(4))
+
+		value, err := scaler.getActiveNumber(context.Background())
+		if err != nil && !testData.raisesError {
+			t.Errorf("%v: expected success but got error %s", testData.metadata, err)
+		}
+		if err == nil && testData.raisesError {
+			t.Errorf("%v: expected error but got success", testData.metadata)
+		}
+		if err == nil && value != testData.expected {
+			t.Errorf("%v: expected %v but got %v", testData.metadata, testData.expected, value)
+		}
+
+		for _, mock := range mocks {
+			mock.ExpectClose()
+		}
+		if err := scaler.Close(context.Background()); err != nil {
+			t.Error("Unexpected error closing scaler:", err)
+		}
+		for connection, mock := range mocks {
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("%v: %s: %s", testData.metadata, connection, err)
+			}
+		}
+	}
+}
+
+func TestPostgreSQLScalerDatabasesTimeout(t *testing.T) {
+	testData := []struct {
+		name        string
+		metadata    map[string]string
+		delay       time.Duration
+		usDelay     time.Duration
+		expected    float64
+		raisesError bool
+	}{
+		// the databases are queried concurrently, so their delays don't add up
+		{name: "concurrent", metadata: map[string]string{"databasesAggregation": "sum"}, delay: 300 * time.Millisecond, usDelay: 300 * time.Millisecond, expected: 16},
+		{name: "slow database ignored", metadata: map[string]string{"databasesAggregation": "sum", "onDatabaseError": "ignore", "databasesTimeout": "200ms"}, usDelay: 5 * time.Second, expected: 7},
+		{name: "slow database fails", metadata: map[string]string{"databasesAggregation": "sum", "databasesTimeout": "200ms"}, usDelay: 5 * time.Second, raisesError: true},
+		{name: "all databases too slow", metadata: map[string]string{"onDatabaseError": "ignore", "databasesTimeout": "200ms"}, delay: 5 * time.Second, usDelay: 5 * time.Second, raisesError: true},
+		{name: "in time", metadata: map[string]string{"databasesAggregation": "sum", "databasesTimeout": "5s"}, usDelay: 10 * time.Millisecond, expected: 16},
+	}
+
+	for _, testData := range testData {
+		t.Run(testData.name, func(t *testing.T) {
+			metadata := map[string]string{"query": "SELECT count(*) FROM jobs", "targetQueryValue": "5"}
+			for key, value := range testData.metadata {
+				metadata[key] = value
+			}
+			scaler, mocks := newPostgreSQLMockDatabasesScaler(t, metadata, "host=eu", "host=us", "host=ap")
+			defer scaler.Close(context.Background())
+			mocks["host=eu"].ExpectQuery("SELECT count").WillDelayFor(testData.delay).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+			mocks["host=us"].ExpectQuery("SELECT count").WillDelayFor(testData.usDelay).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(9))
+			mocks["host=ap"].ExpectQuery("SELECT count").WillDelayFor(testData.delay).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(4))
+
+			start := time.Now()
+			value, err := scaler.getActiveNumber(context.Background())
+			elapsed := time.Since(start)
+			if err != nil && !testData.raisesError {
+				t.Fatal("Expected success but got error", err)
+			}
+			if err == nil && testData.raisesError {
+				t.Fatal("Expected error but got success")
+			}
+			if err == nil && value != testData.expected {
+				t.Errorf("Expected %v but got %v", testData.expected, value)
+			}
+			if elapsed > 800*time.Millisecond {
+				t.Errorf("Expected the databases to be queried concurrently within the deadline but took %s", elapsed)
+			}
+		})
+	}
+}
+
+func TestPostgreSQLScalerDatabasesMetrics(t *testing.T) {
+	dbs := map[string]*sql.DB{}
+	mocks := map[string]sqlmock.Sqlmock{}
+	for _, connection := range []string{"host=eu", "host=us"} {
+		db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+		if err != nil {
+			t.Fatal("Could not create sqlmock:", err)
+		}
+		mock.ExpectPing()
+		dbs[connection], mocks[connection] = db, mock
+	}
+	scaler, err := newPostgreSQLScaler(&ScalerConfig{
+		ScalableObjectName:      "databases-metrics-test",
+		ScalableObjectNamespace: "default",
+		TriggerMetadata:         map[string]string{"query": "SELECT count(*) FROM jobs", "targetQueryValue": "5"},
+		AuthParams:              map[string]string{"connections": `["host=eu", "host=us"]`},
+	}, newPostgreSQLConnectionPool(func(meta *postgreSQLMetadata) (*sql.DB, error) {
+		return dbs[meta.connection], nil
+	}, 0))
+	if err != nil {
+		t.Fatal("Could not create scaler:", err)
+	}
+	infoSeries := func() map[string]int {
+		t.Helper()
+		registry := prometheus.NewRegistry()
+		registry.MustRegister(postgreSQLScalerInfo)
+		families, err := registry.Gather()
+		if err != nil {
+			t.Fatal(err)
+		}
+		series := map[string]int{}
+		for _, family := range families {
+			for _, metric := range family.GetMetric() {
+				labels := map[string]string{}
+				for _, label := range metric.GetLabel() {
+					labels[label.GetName()] = label.GetValue()
+				}
+				if labels["scaledObject"] == "databases-metrics-test" {
+					series[labels["metric"]]++
+				}
+			}
+		}
+		return series
+	}
+
+	// each database is recorded once under its own name from the start
+	expected := map[string]int{"s0-postgresql": 1, "s0-postgresql-database2": 1}
+	if series := infoSeries(); !reflect.DeepEqual(series, expected) {
+		t.Errorf("Expected info series %v but got %v", expected, series)
+	}
+	for _, mock := range mocks {
+		mock.ExpectClose()
+	}
+	if err := scaler.Close(context.Background()); err != nil {
+		t.Error("Unexpected error closing scaler:", err)
+	}
+	if series := infoSeries(); len(series) != 0 {
+		t.Errorf("Expected no info series of the closed scaler but got %v", series)
+	}
+}
@@ -0,0 +1,88 @@
+package scalers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// postgreSQLDeadTuplesQuery reads the tuple statistics of a table, no row is returned if the table doesn't exist
+const postgreSQLDeadTuplesQuery = "SELECT n_live_tup, n_dead_tup FROM pg_stat_user_tables WHERE relid = to_regclass($1)"
+
+// defaultPostgreSQLDeadTupleWarningRatio warns when a fifth of the tuples are dead, which autovacuum
+// keeps well below with its default scale factor
+const defaultPostgreSQLDeadTupleWarningRatio = 0.2
+
+// postgreSQLDeadTupleCheckInterval is how often the statistics are read, they're only updated by the
+// statistics collector anyway
+const postgreSQLDeadTupleCheckInterval = 10 * time.Minute
+
+// parsePostgreSQLDeadTuplesMetadata parses the table whose share of dead tuples is checked and the warning ratio
+func parsePostgreSQLDeadTuplesMetadata(config *ScalerConfig, meta *postgreSQLMetadata) error {
+	val, ok := config.TriggerMetadata["deadTupleWarningTable"]
+	if !ok || val == "" {
+		if _, ok := config.TriggerMetadata["deadTupleWarningRatio"]; ok {
+			return fmt.Errorf("deadTupleWarningRatio requires deadTupleWarningTable")
+		}
+		return nil
+	}
+	meta.deadTupleWarningTable = val
+	meta.deadTupleWarningRatio = defaultPostgreSQLDeadTupleWarningRatio
+	if val, ok := config.TriggerMetadata["deadTupleWarningRatio"]; ok {
+		ratio, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			return fmt.Errorf("deadTupleWarningRatio parsing error %s", err.Error())
+		}
+		if ratio <= 0 || ratio >= 1 {
+			return fmt.Errorf("deadTupleWarningRatio must be between 0 and 1, got %v", ratio)
+		}
+		meta.deadTupleWarningRatio = ratio
+	}
+	return nil
+}
+
+// postgreSQLDeadTupleCheck remembers when the statistics of deadTupleWarningTable were read last
+type postgreSQLDeadTupleCheck struct {
+	lastCheck time.Time
+}
+
+// due reports whether the statistics should be read again and marks them read if so
+func (c *postgreSQLDeadTupleCheck) due(now time.Time) bool {
+	if !c.lastCheck.IsZero() && now.Sub(c.lastCheck) < postgreSQLDeadTupleCheckInterval {
+		return false
+	}
+	c.lastCheck = now
+	return true
+}
+
+// computePostgreSQLDeadTupleRatio returns the share of dead tuples, 0 for an empty table
+func computePostgreSQLDeadTupleRatio(live, dead int64) float64 {
+	if live+dead <= 0 {
+		return 0
+	}
+	return float64(dead) / float64(live+dead)
+}
+
+// checkDeadTuples warns when the share of dead tuples of deadTupleWarningTable exceeds deadTupleWarningRatio.
+// A bloated table slows down counting queries, so it's only a hint and never fails the read
+func (s *postgreSQLScaler) checkDeadTuples(ctx context.Context, connection postgreSQLQuerier) (ratio float64, high bool, err error) {
+	var live, dead int64
+	err = connection.QueryRowContext(ctx, postgreSQLDeadTuplesQuery, s.metadata.deadTupleWarningTable).Scan(&live, &dead)
+	if errors.Is(err, sql.ErrNoRows) {
+		s.logger.V(1).Info("no postgreSQL statistics for deadTupleWarningTable", "table", s.metadata.deadTupleWarningTable)
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	ratio = computePostgreSQLDeadTupleRatio(live, dead)
+	if ratio <= s.metadata.deadTupleWarningRatio {
+		return ratio, false, nil
+	}
+	s.logger.Info("postgreSQL table has a high share of dead tuples, the metric query may be slow and counts may be skewed until it's vacuumed",
+		"table", s.metadata.deadTupleWarningTable, "liveTuples", live, "deadTuples", dead, "ratio", ratio)
+	return ratio, true, nil
+}
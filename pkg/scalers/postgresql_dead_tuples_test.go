@@ -0,0 +1,130 @@
+package scalers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+var testPostgreSQLDeadTuplesMetadata = []parsePostgresMetadataTestData{
+	// deadTupleWarningTable with deadTupleWarningRatio
+	{
+		metadata:    map[string]string{"query": "query", "targetQueryValue": "12", "deadTupleWarningTable": "jobs", "deadTupleWarningRatio": "0.5"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		resolvedEnv: map[string]string{},
+		raisesError: false,
+	},
+	// deadTupleWarningRatio out of range
+	{
+		metadata:    map[string]string{"query": "query", "targetQueryValue": "12", "deadTupleWarningTable": "jobs", "deadTupleWarningRatio": "1.5"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		resolvedEnv: map[string]string{},
+		raisesError: true,
+	},
+	// deadTupleWarningRatio without deadTupleWarningTable
+	{
+		metadata:    map[string]string{"query": "query", "targetQueryValue": "12", "deadTupleWarningRatio": "0.5"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		resolvedEnv: map[string]string{},
+		raisesError: true,
+	},
+}
+
+func TestParsePostgreSQLDeadTuplesMetadata(t *testing.T) {
+	testParsePostgreSQLMetadata(t, testPostgreSQLDeadTuplesMetadata)
+}
+
+func TestComputePostgreSQLDeadTupleRatio(t *testing.T) {
+	testData := []struct {
+		live     int64
+		dead     int64
+		expected float64
+	}{
+		{live: 0, dead: 0, expected: 0},
+		{live: 100, dead: 0, expected: 0},
+		{live: 75, dead: 25, expected: 0.25},
+		{live: 0, dead: 10, expected: 1},
+	}
+
+	for _, testData := range testData {
+		if ratio := computePostgreSQLDeadTupleRatio(testData.live, testData.dead); ratio != testData.expected {
+			t.Errorf("Expected ratio %v for %d live and %d dead tuples but got %v", testData.expected, testData.live, testData.dead, ratio)
+		}
+	}
+}
+
+func TestPostgreSQLDeadTupleCheckDue(t *testing.T) {
+	var check postgreSQLDeadTupleCheck
+	now := time.Now()
+	if !check.due(now) {
+		t.Error("Expected the first check to be due")
+	}
+	if check.due(now.Add(postgreSQLDeadTupleCheckInterval / 2)) {
+		t.Error("Expected no check within the interval")
+	}
+	if !check.due(now.Add(postgreSQLDeadTupleCheckInterval)) {
+		t.Error("Expected a check after the interval")
+	}
+}
+
+func TestPostgreSQLCheckDeadTuples(t *testing.T) {
+	testData := []struct {
+		rows        *sqlmock.Rows
+		err         error
+		high        bool
+		raisesError bool
+	}{
+		{rows: sqlmock.NewRows([]string{"n_live_tup", "n_dead_tup"}).AddRow(900, 100)},
+		{rows: sqlmock.NewRows([]string{"n_live_tup", "n_dead_tup"}).AddRow(600, 400), high: true},
+		{rows: sqlmock.NewRows([]string{"n_live_tup", "n_dead_tup"})},
+		{err: errors.New("permission denied for relation pg_stat_user_tables"), raisesError: true},
+	}
+
+	for i, testData := range testData {
+		scaler, mock := newPostgreSQLMockScaler(t, &ScalerConfig{
+			TriggerMetadata: map[string]string{"query": "SELECT count(*) FROM jobs", "targetQueryValue": "5", "deadTupleWarningTable": "public.jobs", "deadTupleWarningRatio": "0.3"},
+			AuthParams:      map[string]string{"connection": "host=localhost"},
+		})
+		expectation := mock.ExpectQuery("SELECT n_live_tup, n_dead_tup FROM pg_stat_user_tables").WithArgs("public.jobs")
+		if testData.err != nil {
+			expectation.WillReturnError(testData.err)
+		} else {
+			expectation.WillReturnRows(testData.rows)
+		}
+
+		_, high, err := scaler.checkDeadTuples(context.Background(), scaler.connection.db)
+		if err != nil && !testData.raisesError {
+			t.Errorf("case %d: expected success but got error %s", i, err)
+		}
+		if err == nil && testData.raisesError {
+			t.Errorf("case %d: expected error but got success", i)
+		}
+		if high != testData.high {
+			t.Errorf("case %d: expected high %v but got %v", i, testData.high, high)
+		}
+	}
+}
+
+func TestPostgreSQLScalerDeadTupleWarning(t *testing.T) {
+	scaler, mock := newPostgreSQLMockScaler(t, &ScalerConfig{
+		TriggerMetadata: map[string]string{"query": "SELECT count(*) FROM jobs", "targetQueryValue": "5", "deadTupleWarningTable": "jobs"},
+		AuthParams:      map[string]string{"connection": "host=localhost"},
+	})
+
+	// a failing check doesn't fail the read
+	mock.ExpectQuery("SELECT n_live_tup").WillReturnError(errors.New("permission denied"))
+	mock.ExpectQuery("SELECT count").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(7))
+	// the statistics aren't read again within the interval
+	mock.ExpectQuery("SELECT count").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(8))
+	for _, expected := range []float64{7, 8} {
+		if value, err := scaler.getActiveNumber(context.Background()); err != nil || value != expected {
+			t.Errorf("Expected value %v but got %v (%v)", expected, value, err)
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
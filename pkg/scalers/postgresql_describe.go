@@ -0,0 +1,70 @@
+package scalers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// postgreSQLDescribedConnectionParams are the connection parameters shown by String, anything else such
+// as the password, options or TLS file paths is left out
+var postgreSQLDescribedConnectionParams = []string{"host", "port", "dbname", "user", "sslmode"}
+
+// String describes the effective configuration of the scaler for debugging. Credentials are never
+// included, of the connection only the parameters in postgreSQLDescribedConnectionParams are shown
+func (s *postgreSQLScaler) String() string {
+	return s.metadata.String()
+}
+
+func (m *postgreSQLMetadata) String() string {
+	fields := []string{
+		"metricName=" + m.metricName,
+		"metricMode=" + m.metricMode,
+		"dialect=" + m.dialect,
+		"query=" + strconv.Quote(m.query),
+		"targetQueryValue=" + strconv.FormatFloat(m.targetQueryValue, 'f', -1, 64),
+		"activationTargetQueryValue=" + strconv.FormatFloat(m.activationTargetQueryValue, 'f', -1, 64),
+	}
+	fields = append(fields, describePostgreSQLConnection(m.connection)...)
+	fields = append(fields,
+		"eagerConnect="+strconv.FormatBool(m.eagerConnect),
+		"estimateMode="+strconv.FormatBool(m.estimateMode),
+		"targetFromQuery="+strconv.FormatBool(m.targetFromQuery),
+		"requireEncryption="+strconv.FormatBool(m.requireEncryption),
+	)
+	if m.credentialProvider != "" {
+		fields = append(fields, "credentialProvider="+m.credentialProvider)
+	}
+	if m.slotName != "" {
+		fields = append(fields, "slotName="+m.slotName)
+	}
+	if len(m.queries) > 0 {
+		fields = append(fields, fmt.Sprintf("queries=%q", m.queries), fmt.Sprintf("queryWeights=%v", m.queryWeights))
+	}
+	if m.capacityQuery != "" {
+		fields = append(fields, "capacityQuery="+strconv.Quote(m.capacityQuery))
+	}
+	if m.maintenanceQuery != "" {
+		fields = append(fields, "maintenanceQuery="+strconv.Quote(m.maintenanceQuery))
+	}
+	if m.notifyChannel != "" {
+		fields = append(fields, "notifyChannel="+m.notifyChannel)
+	}
+	return fmt.Sprintf("postgreSQLScaler{%s}", strings.Join(fields, " "))
+}
+
+// describePostgreSQLConnection returns the parameters of the connection which are safe to show
+func describePostgreSQLConnection(connection string) []string {
+	params, err := parsePostgreSQLConnectionString(connection)
+	if err != nil {
+		// the error may quote the connection, password included
+		return []string{"connection=<invalid>"}
+	}
+	var fields []string
+	for _, param := range postgreSQLDescribedConnectionParams {
+		if val, ok := params[param]; ok {
+			fields = append(fields, param+"="+val)
+		}
+	}
+	return fields
+}
@@ -0,0 +1,62 @@
+package scalers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPostgreSQLDescribe(t *testing.T) {
+	const password = "s3cr3t-Passw0rd"
+	testData := []struct {
+		name       string
+		metadata   map[string]string
+		authParams map[string]string
+		expected   []string
+	}{
+		{
+			name:       "connection",
+			metadata:   map[string]string{"query": "SELECT count(*) FROM jobs", "targetQueryValue": "5", "activationTargetQueryValue": "1.5"},
+			authParams: map[string]string{"connection": "host=db.example.com port=5432 user=keda dbname=jobs sslmode=require password=" + password},
+			expected: []string{
+				"metricMode=absolute", "dialect=postgres", `query="SELECT count(*) FROM jobs"`, "targetQueryValue=5", "activationTargetQueryValue=1.5",
+				"host=db.example.com", "port=5432", "user=keda", "dbname=jobs", "sslmode=require", "eagerConnect=true", "estimateMode=false",
+			},
+		},
+		{
+			name:       "url connection",
+			metadata:   map[string]string{"metricMode": "connectionSaturation", "targetQueryValue": "0.8", "eagerConnect": "false"},
+			authParams: map[string]string{"connection": "postgresql://keda:" + password + "@db.example.com:5432/jobs"},
+			expected:   []string{"metricMode=connectionSaturation", "host=db.example.com", "user=keda", "eagerConnect=false"},
+		},
+		{
+			name:       "separate parameters",
+			metadata:   map[string]string{"query": "SELECT count(*) FROM jobs", "targetQueryValue": "5", "host": "db.example.com", "port": "5432", "userName": "keda", "dbName": "jobs", "sslmode": "disable"},
+			authParams: map[string]string{"password": password},
+			expected:   []string{"host=db.example.com", "dbname=jobs"},
+		},
+		{
+			name:       "statement timeout options",
+			metadata:   map[string]string{"metricMode": "replicationSlotLag", "slotName": "debezium", "targetQueryValue": "1048576", "statementTimeout": "5s"},
+			authParams: map[string]string{"connection": "host=db.example.com password=" + password},
+			expected:   []string{"metricMode=replicationSlotLag", "slotName=debezium"},
+		},
+	}
+
+	for _, testData := range testData {
+		meta, err := parsePostgreSQLMetadata(&ScalerConfig{TriggerMetadata: testData.metadata, AuthParams: testData.authParams})
+		if err != nil {
+			t.Fatalf("%s: could not parse metadata: %s", testData.name, err)
+		}
+		description := (&postgreSQLScaler{metadata: meta}).String()
+		for _, expected := range testData.expected {
+			if !strings.Contains(description, expected) {
+				t.Errorf("%s: expected %s in %s", testData.name, expected, description)
+			}
+		}
+		for _, leaked := range []string{password, "password", "statement_timeout"} {
+			if strings.Contains(description, leaked) {
+				t.Errorf("%s: expected no %s in %s", testData.name, leaked, description)
+			}
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package scalers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// categories of the errors onErrorByCategory defines the onError policy for. Connection errors and
+// timeouts usually heal by themselves, failed authentications and queries need a fix of the configuration
+const (
+	// postgreSQLErrorCategoryConnection are network errors, a server restarting and a saturated pool
+	postgreSQLErrorCategoryConnection = "connection"
+	// postgreSQLErrorCategoryTimeout are queries running into queryTimeout or canceled by the server
+	postgreSQLErrorCategoryTimeout = "timeout"
+	// postgreSQLErrorCategoryAuthentication are rejected credentials
+	postgreSQLErrorCategoryAuthentication = "authentication"
+	// postgreSQLErrorCategoryQuery is everything else, e.g. syntax errors, missing permissions and invalid values
+	postgreSQLErrorCategoryQuery = "query"
+)
+
+// parsePostgreSQLErrorPolicyMetadata parses what a failed read returns, overall and per category of the error,
+// and how old a last value may be
+func parsePostgreSQLErrorPolicyMetadata(config *ScalerConfig, meta *postgreSQLMetadata) error {
+	meta.onError = postgreSQLOnErrorFail
+	if val, ok := config.TriggerMetadata["onError"]; ok && val != "" {
+		switch val {
+		case postgreSQLOnErrorFail, postgreSQLOnErrorLastValue:
+			meta.onError = val
+		default:
+			return fmt.Errorf("unknown onError %s, must be one of %s, %s", val, postgreSQLOnErrorFail, postgreSQLOnErrorLastValue)
+		}
+	}
+
+	// e.g. connection=lastValue,timeout=lastValue tolerates outages while a broken query still fails
+	if val, ok := config.TriggerMetadata["onErrorByCategory"]; ok && val != "" {
+		onErrorByCategory, err := parsePostgreSQLOnErrorByCategory(val)
+		if err != nil {
+			return fmt.Errorf("onErrorByCategory parsing error %s", err.Error())
+		}
+		meta.onErrorByCategory = onErrorByCategory
+	}
+
+	if val, ok := config.TriggerMetadata["maxStaleness"]; ok && val != "" {
+		if !meta.usesLastValue() {
+			return fmt.Errorf("maxStaleness requires onError %s", postgreSQLOnErrorLastValue)
+		}
+		maxStaleness, err := parsePostgreSQLDuration("maxStaleness", val)
+		if err != nil {
+			return err
+		}
+		if maxStaleness <= 0 {
+			return fmt.Errorf("maxStaleness must be positive, got %s", maxStaleness)
+		}
+		meta.maxStaleness = maxStaleness
+	}
+	return nil
+}
+
+// getPostgreSQLErrorCategory returns the onErrorByCategory category of err
+func getPostgreSQLErrorCategory(err error) string {
+	var pqErr *pq.Error
+	if errors.Is(err, errPostgreSQLQueryTimeout) || errors.Is(err, context.DeadlineExceeded) ||
+		(errors.As(err, &pqErr) && pqErr.Code == "57014") {
+		return postgreSQLErrorCategoryTimeout
+	}
+	// newPostgreSQLError categorized err already, e.g. a failed refresh of the credentials
+	var categorized *postgreSQLError
+	if !errors.As(err, &categorized) {
+		categorized = &postgreSQLError{reason: getPostgreSQLErrorReason(err)}
+	}
+	switch categorized.reason {
+	case postgreSQLErrorReasonConnection:
+		return postgreSQLErrorCategoryConnection
+	case postgreSQLErrorReasonAuthentication:
+		return postgreSQLErrorCategoryAuthentication
+	default:
+		return postgreSQLErrorCategoryQuery
+	}
+}
+
+// parsePostgreSQLOnErrorByCategory parses category=policy pairs such as connection=lastValue,query=fail
+func parsePostgreSQLOnErrorByCategory(val string) (map[string]string, error) {
+	result := map[string]string{}
+	for _, pair := range strings.Split(val, ",") {
+		category, policy, found := strings.Cut(pair, "=")
+		if !found {
+			return nil, fmt.Errorf("%q must be in the form category=policy", strings.TrimSpace(pair))
+		}
+		category, policy = strings.TrimSpace(category), strings.TrimSpace(policy)
+		switch category {
+		case postgreSQLErrorCategoryConnection, postgreSQLErrorCategoryTimeout, postgreSQLErrorCategoryAuthentication, postgreSQLErrorCategoryQuery:
+		default:
+			return nil, fmt.Errorf("unknown category %s, must be one of %s, %s, %s, %s", category, postgreSQLErrorCategoryConnection,
+				postgreSQLErrorCategoryTimeout, postgreSQLErrorCategoryAuthentication, postgreSQLErrorCategoryQuery)
+		}
+		if policy != postgreSQLOnErrorFail && policy != postgreSQLOnErrorLastValue {
+			return nil, fmt.Errorf("unknown policy %s of category %s, must be one of %s, %s", policy, category, postgreSQLOnErrorFail, postgreSQLOnErrorLastValue)
+		}
+		if _, ok := result[category]; ok {
+			return nil, fmt.Errorf("duplicate category %s", category)
+		}
+		result[category] = policy
+	}
+	return result, nil
+}
+
+// onErrorFor returns the onError policy of err, the one of its category or onError for the other categories
+func (m *postgreSQLMetadata) onErrorFor(err error) string {
+	if policy, ok := m.onErrorByCategory[getPostgreSQLErrorCategory(err)]; ok {
+		return policy
+	}
+	return m.onError
+}
+
+// usesLastValue returns true if any error returns the last value
+func (m *postgreSQLMetadata) usesLastValue() bool {
+	if m.onError == postgreSQLOnErrorLastValue {
+		return true
+	}
+	for _, policy := range m.onErrorByCategory {
+		if policy == postgreSQLOnErrorLastValue {
+			return true
+		}
+	}
+	return false
+}
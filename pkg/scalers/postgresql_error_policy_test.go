@@ -0,0 +1,205 @@
+package scalers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+)
+
+var testPostgreSQLErrorPolicyMetadata = []parsePostgresMetadataTestData{
+	// unknown onError
+	{
+		metadata:    map[string]string{"query": "query", "targetQueryValue": "12", "connectionFromEnv": "POSTGRE_CONN_STR", "onError": "ignore"},
+		authParams:  map[string]string{},
+		resolvedEnv: testPostgresResolvedEnv,
+		raisesError: true,
+	},
+	// maxStaleness with onError lastValue
+	{
+		metadata:    map[string]string{"query": "query", "targetQueryValue": "12", "connectionFromEnv": "POSTGRE_CONN_STR", "onError": "lastValue", "maxStaleness": "10m"},
+		authParams:  map[string]string{},
+		resolvedEnv: testPostgresResolvedEnv,
+		raisesError: false,
+	},
+	// maxStaleness without onError lastValue
+	{
+		metadata:    map[string]string{"query": "query", "targetQueryValue": "12", "connectionFromEnv": "POSTGRE_CONN_STR", "maxStaleness": "10m"},
+		authParams:  map[string]string{},
+		resolvedEnv: testPostgresResolvedEnv,
+		raisesError: true,
+	},
+	// negative maxStaleness
+	{
+		metadata:    map[string]string{"query": "query", "targetQueryValue": "12", "connectionFromEnv": "POSTGRE_CONN_STR", "onError": "lastValue", "maxStaleness": "-1m"},
+		authParams:  map[string]string{},
+		resolvedEnv: testPostgresResolvedEnv,
+		raisesError: true,
+	},
+	// onErrorByCategory with maxStaleness
+	{
+		metadata:   map[string]string{"query": "test_query", "targetQueryValue": "5", "onErrorByCategory": "connection=lastValue,query=fail", "maxStaleness": "10m"},
+		authParams: map[string]string{"connection": "test_connection_string"},
+	},
+	// onErrorByCategory with an unknown category
+	{
+		metadata:    map[string]string{"query": "test_query", "targetQueryValue": "5", "onErrorByCategory": "network=lastValue"},
+		authParams:  map[string]string{"connection": "test_connection_string"},
+		raisesError: true,
+	},
+	// onErrorByCategory with an unknown policy
+	{
+		metadata:    map[string]string{"query": "test_query", "targetQueryValue": "5", "onErrorByCategory": "connection=ignore"},
+		authParams:  map[string]string{"connection": "test_connection_string"},
+		raisesError: true,
+	},
+	// onErrorByCategory with a duplicate category
+	{
+		metadata:    map[string]string{"query": "test_query", "targetQueryValue": "5", "onErrorByCategory": "timeout=lastValue,timeout=fail"},
+		authParams:  map[string]string{"connection": "test_connection_string"},
+		raisesError: true,
+	},
+	// maxStaleness with onErrorByCategory without lastValue
+	{
+		metadata:    map[string]string{"query": "test_query", "targetQueryValue": "5", "onErrorByCategory": "connection=fail", "maxStaleness": "10m"},
+		authParams:  map[string]string{"connection": "test_connection_string"},
+		raisesError: true,
+	},
+}
+
+func TestParsePostgreSQLErrorPolicyMetadata(t *testing.T) {
+	testParsePostgreSQLMetadata(t, testPostgreSQLErrorPolicyMetadata)
+}
+
+func TestPostgreSQLErrorCategories(t *testing.T) {
+	testData := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{name: "connection refused", err: &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, expected: postgreSQLErrorCategoryConnection},
+		{name: "server starting", err: &pq.Error{Code: "57P03"}, expected: postgreSQLErrorCategoryConnection},
+		{name: "saturated pool", err: errPostgreSQLConnectionAcquireTimeout, expected: postgreSQLErrorCategoryConnection},
+		{name: "query timeout", err: fmt.Errorf("%w after 10s", errPostgreSQLQueryTimeout), expected: postgreSQLErrorCategoryTimeout},
+		{name: "statement timeout", err: &pq.Error{Code: "57014"}, expected: postgreSQLErrorCategoryTimeout},
+		{name: "deadline", err: context.DeadlineExceeded, expected: postgreSQLErrorCategoryTimeout},
+		{name: "invalid password", err: &pq.Error{Code: "28P01"}, expected: postgreSQLErrorCategoryAuthentication},
+		{name: "expired token", err: &postgreSQLError{reason: postgreSQLErrorReasonAuthentication, err: errors.New("token expired")}, expected: postgreSQLErrorCategoryAuthentication},
+		{name: "syntax error", err: &pq.Error{Code: "42601"}, expected: postgreSQLErrorCategoryQuery},
+		{name: "permission denied", err: &pq.Error{Code: "42501"}, expected: postgreSQLErrorCategoryQuery},
+		{name: "non finite value", err: errPostgreSQLNonFiniteValue, expected: postgreSQLErrorCategoryQuery},
+	}
+
+	for _, testData := range testData {
+		if category := getPostgreSQLErrorCategory(testData.err); category != testData.expected {
+			t.Errorf("%s: expected category %s but got %s", testData.name, testData.expected, category)
+		}
+	}
+}
+
+func TestPostgreSQLOnErrorByCategory(t *testing.T) {
+	scaler, mock := newPostgreSQLMockScaler(t, &ScalerConfig{
+		TriggerMetadata: map[string]string{"query": "test_query", "targetQueryValue": "5", "onErrorByCategory": "connection=lastValue, timeout=lastValue"},
+		AuthParams:      map[string]string{"connection": "host=localhost"},
+	})
+	mock.ExpectQuery("test_query").WillReturnRows(sqlmock.NewRows([]string{"value"}).AddRow(7))
+	if _, err := scaler.getActiveNumber(context.Background()); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	testData := []struct {
+		name      string
+		err       error
+		lastValue bool
+	}{
+		{name: "connection", err: &pq.Error{Code: "08006"}, lastValue: true},
+		{name: "timeout", err: &pq.Error{Code: "57014"}, lastValue: true},
+		// onError fail applies to the categories without a policy
+		{name: "authentication", err: &pq.Error{Code: "28P01"}, lastValue: false},
+		{name: "query", err: &pq.Error{Code: "42P01"}, lastValue: false},
+	}
+	for _, testData := range testData {
+		mock.ExpectQuery("test_query").WillReturnError(testData.err)
+		value, err := scaler.getActiveNumber(context.Background())
+		switch {
+		case testData.lastValue && (err != nil || value != 7):
+			t.Errorf("%s: expected the last value 7 but got %v (%v)", testData.name, value, err)
+		case !testData.lastValue && err == nil:
+			t.Errorf("%s: expected error but got %v", testData.name, value)
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPostgreSQLOnErrorByCategoryCircuitBreaker(t *testing.T) {
+	scaler, mock := newPostgreSQLMockScaler(t, &ScalerConfig{
+		TriggerMetadata: map[string]string{"query": "test_query", "targetQueryValue": "5", "circuitBreakerThreshold": "2", "circuitBreakerCooldown": "1h",
+			"onErrorByCategory": "connection=lastValue,query=fail"},
+		AuthParams: map[string]string{"connection": "host=localhost"},
+	})
+	mock.ExpectQuery("test_query").WillReturnRows(sqlmock.NewRows([]string{"value"}).AddRow(4))
+	mock.ExpectQuery("test_query").WillReturnError(&pq.Error{Code: "08006"})
+	mock.ExpectQuery("test_query").WillReturnError(&pq.Error{Code: "08006"})
+	// the outage opens the circuit, the reads it rejects are connection errors too and keep the last value
+	for i := 0; i < 5; i++ {
+		if value, err := scaler.getActiveNumber(context.Background()); err != nil || value != 4 {
+			t.Errorf("read %d: expected the last value 4 but got %v (%v)", i, value, err)
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+
+	// without the fallback the rejected reads fail with the reason of the outage
+	scaler.metadata.onErrorByCategory = nil
+	_, err := scaler.GetMetrics(context.Background(), "s0-postgresql")
+	if err == nil {
+		t.Fatal("Expected error from the open circuit")
+	}
+	var reasonErr ConditionReasonError
+	if !errors.As(err, &reasonErr) || reasonErr.ConditionReason() != postgreSQLErrorReasonConnection {
+		t.Errorf("Expected reason %s for the open circuit but got %v", postgreSQLErrorReasonConnection, err)
+	}
+	if category := getPostgreSQLErrorCategory(err); category != postgreSQLErrorCategoryConnection {
+		t.Errorf("Expected category %s for the open circuit but got %s", postgreSQLErrorCategoryConnection, category)
+	}
+}
+
+func TestPostgreSQLScalerMaxStaleness(t *testing.T) {
+	testData := []struct {
+		age         time.Duration
+		raisesError bool
+	}{
+		{age: 0, raisesError: false},
+		{age: 4 * time.Minute, raisesError: false},
+		{age: 6 * time.Minute, raisesError: true},
+	}
+
+	for _, testData := range testData {
+		scaler, mock := newPostgreSQLMockScaler(t, &ScalerConfig{
+			TriggerMetadata: map[string]string{"query": "test_query", "targetQueryValue": "5", "onError": "lastValue", "maxStaleness": "5m"},
+			AuthParams:      map[string]string{"connection": "host=localhost"},
+		})
+		mock.ExpectQuery("test_query").WillReturnRows(sqlmock.NewRows([]string{"value"}).AddRow(4))
+		if _, err := scaler.getActiveNumber(context.Background()); err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+		scaler.lastValueAt = scaler.lastValueAt.Add(-testData.age)
+
+		mock.ExpectQuery("test_query").WillReturnError(errors.New("connection refused"))
+		value, err := scaler.getActiveNumber(context.Background())
+		switch {
+		case testData.raisesError && err == nil:
+			t.Errorf("Expected error for a last value %s old but got %v", testData.age, value)
+		case !testData.raisesError && (err != nil || value != 4):
+			t.Errorf("Expected last value 4 for a last value %s old but got %v (%v)", testData.age, value, err)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package scalers
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+
+	"github.com/lib/pq"
+)
+
+// reasons reported in the Ready condition of the ScaledObject for failing PostgreSQL triggers
+const (
+	postgreSQLErrorReasonAuthentication = "PostgreSQLAuthenticationFailed"
+	postgreSQLErrorReasonConnection     = "PostgreSQLConnectionFailed"
+	postgreSQLErrorReasonQuery          = "PostgreSQLQueryFailed"
+)
+
+// the budgets of acquiring a connection and running the queries expire with distinct errors, so a
+// saturated pool can be told from slow queries
+var (
+	errPostgreSQLConnectionAcquireTimeout = errors.New("timed out waiting for a postgreSQL connection")
+	errPostgreSQLQueryTimeout             = errors.New("postgreSQL query timed out")
+)
+
+// errPostgreSQLNonFiniteValue is returned for NaN and infinite values, e.g. of a division by zero
+// in the query, which mustn't reach the HPA
+var errPostgreSQLNonFiniteValue = errors.New("postgreSQL value is not a finite number")
+
+// checkPostgreSQLFiniteValue returns errPostgreSQLNonFiniteValue for NaN and infinite values
+func checkPostgreSQLFiniteValue(value float64) error {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return fmt.Errorf("%w: %v", errPostgreSQLNonFiniteValue, value)
+	}
+	return nil
+}
+
+// postgreSQLError is a PostgreSQL scaler error with the category of its cause
+type postgreSQLError struct {
+	reason string
+	err    error
+}
+
+func (e *postgreSQLError) Error() string {
+	return e.err.Error()
+}
+
+func (e *postgreSQLError) Unwrap() error {
+	return e.err
+}
+
+// ConditionReason implements ConditionReasonError
+func (e *postgreSQLError) ConditionReason() string {
+	return e.reason
+}
+
+// newPostgreSQLError categorizes err, keeping the reason of an error in its chain which is already categorized
+func newPostgreSQLError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var categorized *postgreSQLError
+	if errors.As(err, &categorized) {
+		return &postgreSQLError{reason: categorized.reason, err: err}
+	}
+	return &postgreSQLError{reason: getPostgreSQLErrorReason(err), err: err}
+}
+
+// postgreSQLSQLStateClassNone is the SQLSTATE class of errors which weren't reported by the server,
+// e.g. network errors and timeouts
+const postgreSQLSQLStateClassNone = "none"
+
+// getPostgreSQLSQLStateClass returns the SQLSTATE class of the server error in the chain of err, e.g. 42 for
+// syntax errors and undefined objects
+func getPostgreSQLSQLStateClass(err error) string {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && len(pqErr.Code) == 5 {
+		return string(pqErr.Code.Class())
+	}
+	return postgreSQLSQLStateClassNone
+}
+
+// getPostgreSQLErrorReason tells failed authentications, by SQLSTATE class 28, from failed connections, by
+// SQLSTATE class 08, the server shutting down or starting and network errors. Anything else is a failed query
+func getPostgreSQLErrorReason(err error) string {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch {
+		case pqErr.Code.Class() == "28":
+			return postgreSQLErrorReasonAuthentication
+		case pqErr.Code.Class() == "08", pqErr.Code == "57P01", pqErr.Code == "57P02", pqErr.Code == "57P03":
+			return postgreSQLErrorReasonConnection
+		default:
+			return postgreSQLErrorReasonQuery
+		}
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, errPostgreSQLConnectionAcquireTimeout) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, driver.ErrBadConn) {
+		return postgreSQLErrorReasonConnection
+	}
+	return postgreSQLErrorReasonQuery
+}
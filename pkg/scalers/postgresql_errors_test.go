@@ -0,0 +1,155 @@
+package scalers
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+)
+
+func TestPostgreSQLErrorReasons(t *testing.T) {
+	testData := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{name: "invalid password", err: &pq.Error{Code: "28P01"}, expected: postgreSQLErrorReasonAuthentication},
+		{name: "invalid authorization", err: &pq.Error{Code: "28000"}, expected: postgreSQLErrorReasonAuthentication},
+		{name: "connection failure", err: &pq.Error{Code: "08006"}, expected: postgreSQLErrorReasonConnection},
+		{name: "cannot connect now", err: &pq.Error{Code: "57P03"}, expected: postgreSQLErrorReasonConnection},
+		{name: "admin shutdown", err: &pq.Error{Code: "57P01"}, expected: postgreSQLErrorReasonConnection},
+		{name: "connection refused", err: &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, expected: postgreSQLErrorReasonConnection},
+		{name: "connection closed", err: io.ErrUnexpectedEOF, expected: postgreSQLErrorReasonConnection},
+		{name: "bad connection", err: driver.ErrBadConn, expected: postgreSQLErrorReasonConnection},
+		{name: "undefined table", err: &pq.Error{Code: "42P01"}, expected: postgreSQLErrorReasonQuery},
+		{name: "query canceled", err: &pq.Error{Code: "57014"}, expected: postgreSQLErrorReasonQuery},
+		{name: "non numeric value", err: errors.New("query returned a non numeric value"), expected: postgreSQLErrorReasonQuery},
+		{name: "wrapped", err: fmt.Errorf("could not query postgreSQL: %w", &pq.Error{Code: "28P01"}), expected: postgreSQLErrorReasonAuthentication},
+		{name: "already categorized", err: fmt.Errorf("error inspecting postgreSQL: %w", &postgreSQLError{reason: postgreSQLErrorReasonAuthentication, err: errors.New("token expired")}), expected: postgreSQLErrorReasonAuthentication},
+	}
+
+	for _, testData := range testData {
+		err := newPostgreSQLError(testData.err)
+		var reasonErr ConditionReasonError
+		if !errors.As(err, &reasonErr) {
+			t.Errorf("%s: expected a ConditionReasonError but got %T", testData.name, err)
+			continue
+		}
+		if reason := reasonErr.ConditionReason(); reason != testData.expected {
+			t.Errorf("%s: expected reason %s but got %s", testData.name, testData.expected, reason)
+		}
+		if err.Error() != testData.err.Error() {
+			t.Errorf("%s: expected message %q but got %q", testData.name, testData.err.Error(), err.Error())
+		}
+	}
+
+	if err := newPostgreSQLError(nil); err != nil {
+		t.Errorf("Expected no error but got %v", err)
+	}
+}
+
+func TestPostgreSQLIsActiveErrorReason(t *testing.T) {
+	scaler, mock := newPostgreSQLMockScaler(t, &ScalerConfig{
+		TriggerMetadata: map[string]string{"query": "SELECT count(*) FROM jobs", "targetQueryValue": "5"},
+		AuthParams:      map[string]string{"connection": "host=localhost"},
+	})
+	mock.ExpectQuery("SELECT count").WillReturnError(&pq.Error{Code: "42P01", Message: `relation "jobs" does not exist`})
+
+	_, err := scaler.IsActive(context.Background())
+	var reasonErr ConditionReasonError
+	if !errors.As(err, &reasonErr) || reasonErr.ConditionReason() != postgreSQLErrorReasonQuery {
+		t.Errorf("Expected an error with reason %s but got %v", postgreSQLErrorReasonQuery, err)
+	}
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) || pqErr.Code != "42P01" {
+		t.Errorf("Expected the driver error to stay in the chain but got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestGetPostgreSQLSQLStateClass(t *testing.T) {
+	testData := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{name: "undefined table", err: &pq.Error{Code: "42P01"}, expected: "42"},
+		{name: "invalid password", err: &pq.Error{Code: "28P01"}, expected: "28"},
+		{name: "connection failure", err: &pq.Error{Code: "08006"}, expected: "08"},
+		{name: "wrapped division by zero", err: fmt.Errorf("could not query postgreSQL: %w", &pq.Error{Code: "22012"}), expected: "22"},
+		{name: "invalid code", err: &pq.Error{Code: "42"}, expected: postgreSQLSQLStateClassNone},
+		{name: "network error", err: &net.OpError{Op: "read", Err: errors.New("connection reset by peer")}, expected: postgreSQLSQLStateClassNone},
+		{name: "query timeout", err: errPostgreSQLQueryTimeout, expected: postgreSQLSQLStateClassNone},
+	}
+
+	for _, testData := range testData {
+		if class := getPostgreSQLSQLStateClass(testData.err); class != testData.expected {
+			t.Errorf("%s: expected SQLSTATE class %s but got %s", testData.name, testData.expected, class)
+		}
+	}
+}
+
+func TestPostgreSQLNonFiniteValue(t *testing.T) {
+	testData := []struct {
+		name    string
+		value   string
+		onError string
+	}{
+		{name: "NaN", value: "NaN"},
+		{name: "positive infinity", value: "Infinity"},
+		{name: "negative infinity", value: "-Infinity"},
+		{name: "NaN with lastValue fallback but no value yet", value: "NaN", onError: postgreSQLOnErrorLastValue},
+	}
+
+	for _, testData := range testData {
+		t.Run(testData.name, func(t *testing.T) {
+			metadata := map[string]string{"query": "SELECT done::float / total FROM progress", "targetQueryValue": "5"}
+			if testData.onError != "" {
+				metadata["onError"] = testData.onError
+			}
+			scaler, mock := newPostgreSQLMockScaler(t, &ScalerConfig{
+				TriggerMetadata: metadata,
+				AuthParams:      map[string]string{"connection": "host=localhost"},
+			})
+			mock.ExpectQuery("SELECT done").WillReturnRows(sqlmock.NewRows([]string{"ratio"}).AddRow(testData.value))
+
+			_, err := scaler.GetMetrics(context.Background(), "s0-postgresql")
+			if !errors.Is(err, errPostgreSQLNonFiniteValue) {
+				t.Errorf("Expected %v but got %v", errPostgreSQLNonFiniteValue, err)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestPostgreSQLNonFiniteValueReturnsLastValue(t *testing.T) {
+	scaler, mock := newPostgreSQLMockScaler(t, &ScalerConfig{
+		TriggerMetadata: map[string]string{"query": "SELECT done::float / total FROM progress", "targetQueryValue": "5", "onError": postgreSQLOnErrorLastValue},
+		AuthParams:      map[string]string{"connection": "host=localhost"},
+	})
+	mock.ExpectQuery("SELECT done").WillReturnRows(sqlmock.NewRows([]string{"ratio"}).AddRow(0.5))
+	mock.ExpectQuery("SELECT done").WillReturnRows(sqlmock.NewRows([]string{"ratio"}).AddRow("NaN"))
+
+	for i := 0; i < 2; i++ {
+		value, err := scaler.getActiveNumber(context.Background())
+		if err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+		if value != 0.5 {
+			t.Errorf("Expected the last value 0.5 but got %v", value)
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
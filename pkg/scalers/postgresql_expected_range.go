@@ -0,0 +1,93 @@
+package scalers
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// bounds of the expected value range a value fell outside of, the label of postgreSQLUnexpectedValues
+const (
+	postgreSQLExpectedRangeBelow = "below"
+	postgreSQLExpectedRangeAbove = "above"
+)
+
+// parsePostgreSQLExpectedRangeMetadata parses the expectedRange the values are checked against
+func parsePostgreSQLExpectedRangeMetadata(config *ScalerConfig, meta *postgreSQLMetadata) error {
+	expectedRange, err := parsePostgreSQLExpectedRange(config)
+	if err != nil {
+		return err
+	}
+	meta.expectedRange = expectedRange
+	return nil
+}
+
+// postgreSQLExpectedRange is the range of plausible values. A value outside of it usually means a broken query,
+// e.g. after a schema change, rather than a change of the load
+type postgreSQLExpectedRange struct {
+	min, max *float64
+	// fail fails the read instead of only reporting the unexpected value
+	fail bool
+}
+
+func parsePostgreSQLExpectedRange(config *ScalerConfig) (*postgreSQLExpectedRange, error) {
+	expected := &postgreSQLExpectedRange{}
+	for _, bound := range []struct {
+		key   string
+		value **float64
+	}{{"expectedMin", &expected.min}, {"expectedMax", &expected.max}} {
+		if val, ok := config.TriggerMetadata[bound.key]; ok && val != "" {
+			value, err := strconv.ParseFloat(val, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%s parsing error %s", bound.key, err.Error())
+			}
+			*bound.value = &value
+		}
+	}
+	if expected.min != nil && expected.max != nil && *expected.min > *expected.max {
+		return nil, fmt.Errorf("expectedMin %v must not be greater than expectedMax %v", *expected.min, *expected.max)
+	}
+	if val, ok := config.TriggerMetadata["failOnUnexpectedValue"]; ok {
+		fail, err := strconv.ParseBool(val)
+		if err != nil {
+			return nil, fmt.Errorf("failOnUnexpectedValue parsing error %s", err.Error())
+		}
+		if fail && expected.min == nil && expected.max == nil {
+			return nil, fmt.Errorf("failOnUnexpectedValue requires expectedMin or expectedMax")
+		}
+		expected.fail = fail
+	}
+	if expected.min == nil && expected.max == nil {
+		return nil, nil
+	}
+	return expected, nil
+}
+
+// check returns the bound value is outside of, or an empty string if it's in the range
+func (r *postgreSQLExpectedRange) check(value float64) string {
+	switch {
+	case r.min != nil && value < *r.min:
+		return postgreSQLExpectedRangeBelow
+	case r.max != nil && value > *r.max:
+		return postgreSQLExpectedRangeAbove
+	default:
+		return ""
+	}
+}
+
+// checkExpectedValue reports a value outside of the expected range, which is only an error with failOnUnexpectedValue
+func (s *postgreSQLScaler) checkExpectedValue(value float64) error {
+	expected := s.metadata.expectedRange
+	if expected == nil {
+		return nil
+	}
+	bound := expected.check(value)
+	if bound == "" {
+		return nil
+	}
+	s.recorder.recordUnexpectedValue(bound)
+	s.logger.Info("postgreSQL value is outside the expected range, the query may be broken", "value", value, "bound", bound)
+	if expected.fail {
+		return fmt.Errorf("postgreSQL value %v is %s the expected range", value, bound)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package scalers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestParsePostgreSQLExpectedRange(t *testing.T) {
+	testData := []struct {
+		metadata    map[string]string
+		isNil       bool
+		raisesError bool
+	}{
+		{metadata: map[string]string{}, isNil: true},
+		{metadata: map[string]string{"expectedMin": "1"}},
+		{metadata: map[string]string{"expectedMax": "1000"}},
+		{metadata: map[string]string{"expectedMin": "-5", "expectedMax": "5", "failOnUnexpectedValue": "true"}},
+		{metadata: map[string]string{"expectedMin": "5", "expectedMax": "5"}},
+		{metadata: map[string]string{"expectedMin": "10", "expectedMax": "5"}, raisesError: true},
+		{metadata: map[string]string{"expectedMin": "none"}, raisesError: true},
+		{metadata: map[string]string{"expectedMax": "1", "failOnUnexpectedValue": "maybe"}, raisesError: true},
+		{metadata: map[string]string{"failOnUnexpectedValue": "true"}, raisesError: true},
+	}
+
+	for _, testData := range testData {
+		expected, err := parsePostgreSQLExpectedRange(&ScalerConfig{TriggerMetadata: testData.metadata})
+		if err != nil && !testData.raisesError {
+			t.Errorf("%v: expected success but got error %s", testData.metadata, err)
+		}
+		if err == nil && testData.raisesError {
+			t.Errorf("%v: expected error but got success", testData.metadata)
+		}
+		if err == nil && (expected == nil) != testData.isNil {
+			t.Errorf("%v: expected nil range %v but got %v", testData.metadata, testData.isNil, expected)
+		}
+	}
+}
+
+func TestPostgreSQLExpectedRange(t *testing.T) {
+	testData := []struct {
+		name     string
+		metadata map[string]string
+		value    float64
+		bound    string
+		fails    bool
+	}{
+		{name: "in range", metadata: map[string]string{"expectedMin": "1", "expectedMax": "1000"}, value: 40},
+		{name: "at the bounds", metadata: map[string]string{"expectedMin": "40", "expectedMax": "40"}, value: 40},
+		{name: "below", metadata: map[string]string{"expectedMin": "1", "expectedMax": "1000"}, value: 0, bound: postgreSQLExpectedRangeBelow},
+		{name: "above", metadata: map[string]string{"expectedMax": "1000"}, value: 1e9, bound: postgreSQLExpectedRangeAbove},
+		{name: "above with failOnUnexpectedValue", metadata: map[string]string{"expectedMax": "1000", "failOnUnexpectedValue": "true"}, value: 1e9, bound: postgreSQLExpectedRangeAbove, fails: true},
+	}
+
+	for _, testData := range testData {
+		t.Run(testData.name, func(t *testing.T) {
+			metadata := map[string]string{"query": "SELECT count(*) FROM jobs", "targetQueryValue": "5"}
+			for key, value := range testData.metadata {
+				metadata[key] = value
+			}
+			scaler, mock := newPostgreSQLMockScaler(t, &ScalerConfig{
+				ScalableObjectName:      "expected-range-" + testData.name,
+				ScalableObjectNamespace: "default",
+				TriggerMetadata:         metadata,
+				AuthParams:              map[string]string{"connection": "host=localhost"},
+			})
+			mock.ExpectQuery("SELECT count").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(testData.value))
+
+			value, err := scaler.getActiveNumber(context.Background())
+			if testData.fails && err == nil {
+				t.Error("Expected the unexpected value to fail the read")
+			}
+			if !testData.fails && (err != nil || value != testData.value) {
+				t.Errorf("Expected the value %v to be reported but got %v, %v", testData.value, value, err)
+			}
+			for _, bound := range []string{postgreSQLExpectedRangeBelow, postgreSQLExpectedRangeAbove} {
+				expected := 0.0
+				if bound == testData.bound {
+					expected = 1
+				}
+				if count := testutil.ToFloat64(postgreSQLUnexpectedValues.With(scaler.recorder.labelsWith("bound", bound))); count != expected {
+					t.Errorf("Expected %v unexpected values %s the range but got %v", expected, bound, count)
+				}
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,216 @@
+package scalers
+
+import (
+	"fmt"
+	"strconv"
+	"unicode"
+)
+
+// parsePostgreSQLExpressionMetadata parses the valueExpression computing the value from the columns of the query
+func parsePostgreSQLExpressionMetadata(config *ScalerConfig, meta *postgreSQLMetadata) error {
+	if val, ok := config.TriggerMetadata["valueExpression"]; ok && val != "" {
+		if (meta.metricMode != postgreSQLMetricModeAbsolute && meta.metricMode != postgreSQLMetricModeRate) || meta.estimateMode {
+			return fmt.Errorf("valueExpression can only be used with metricMode %s or %s without estimateMode", postgreSQLMetricModeAbsolute, postgreSQLMetricModeRate)
+		}
+		valueExpression, err := parsePostgreSQLExpression(val)
+		if err != nil {
+			return fmt.Errorf("valueExpression parsing error %s", err.Error())
+		}
+		meta.valueExpression = valueExpression
+	}
+	return nil
+}
+
+// postgreSQLExpression is a parsed valueExpression. The grammar is deliberately small, only numbers,
+// column names, + - * /, unary minus and parentheses are supported:
+//
+//	expression = term { ("+" | "-") term }
+//	term       = factor { ("*" | "/") factor }
+//	factor     = number | column | "-" factor | "(" expression ")"
+type postgreSQLExpression interface {
+	evaluate(columns map[string]float64) (float64, error)
+}
+
+type postgreSQLNumberExpression float64
+
+func (e postgreSQLNumberExpression) evaluate(map[string]float64) (float64, error) {
+	return float64(e), nil
+}
+
+type postgreSQLColumnExpression string
+
+func (e postgreSQLColumnExpression) evaluate(columns map[string]float64) (float64, error) {
+	value, ok := columns[string(e)]
+	if !ok {
+		return 0, fmt.Errorf("valueExpression references unknown column %q", string(e))
+	}
+	return value, nil
+}
+
+type postgreSQLNegateExpression struct {
+	operand postgreSQLExpression
+}
+
+func (e postgreSQLNegateExpression) evaluate(columns map[string]float64) (float64, error) {
+	value, err := e.operand.evaluate(columns)
+	return -value, err
+}
+
+type postgreSQLBinaryExpression struct {
+	operator    rune
+	left, right postgreSQLExpression
+}
+
+func (e postgreSQLBinaryExpression) evaluate(columns map[string]float64) (float64, error) {
+	left, err := e.left.evaluate(columns)
+	if err != nil {
+		return 0, err
+	}
+	right, err := e.right.evaluate(columns)
+	if err != nil {
+		return 0, err
+	}
+	switch e.operator {
+	case '+':
+		return left + right, nil
+	case '-':
+		return left - right, nil
+	case '*':
+		return left * right, nil
+	default:
+		if right == 0 {
+			return 0, fmt.Errorf("valueExpression divides by zero")
+		}
+		return left / right, nil
+	}
+}
+
+// parsePostgreSQLExpression parses a valueExpression such as "pending * 2 + processing"
+func parsePostgreSQLExpression(expression string) (postgreSQLExpression, error) {
+	p := &postgreSQLExpressionParser{input: []rune(expression)}
+	result, err := p.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpaces()
+	if p.pos < len(p.input) {
+		return nil, fmt.Errorf("unexpected %q at position %d", p.input[p.pos], p.pos)
+	}
+	return result, nil
+}
+
+// postgreSQLExpressionMaxDepth bounds the nesting of parentheses and unary minus
+const postgreSQLExpressionMaxDepth = 32
+
+type postgreSQLExpressionParser struct {
+	input []rune
+	pos   int
+	depth int
+}
+
+func (p *postgreSQLExpressionParser) skipSpaces() {
+	for p.pos < len(p.input) && unicode.IsSpace(p.input[p.pos]) {
+		p.pos++
+	}
+}
+
+// consume skips spaces and returns the next rune if it's one of operators
+func (p *postgreSQLExpressionParser) consume(operators ...rune) (rune, bool) {
+	p.skipSpaces()
+	if p.pos >= len(p.input) {
+		return 0, false
+	}
+	for _, operator := range operators {
+		if p.input[p.pos] == operator {
+			p.pos++
+			return operator, true
+		}
+	}
+	return 0, false
+}
+
+func (p *postgreSQLExpressionParser) parseExpression() (postgreSQLExpression, error) {
+	left, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		operator, ok := p.consume('+', '-')
+		if !ok {
+			return left, nil
+		}
+		right, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		left = postgreSQLBinaryExpression{operator: operator, left: left, right: right}
+	}
+}
+
+func (p *postgreSQLExpressionParser) parseTerm() (postgreSQLExpression, error) {
+	left, err := p.parseFactor()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		operator, ok := p.consume('*', '/')
+		if !ok {
+			return left, nil
+		}
+		right, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		left = postgreSQLBinaryExpression{operator: operator, left: left, right: right}
+	}
+}
+
+func (p *postgreSQLExpressionParser) parseFactor() (postgreSQLExpression, error) {
+	p.depth++
+	defer func() { p.depth-- }()
+	if p.depth > postgreSQLExpressionMaxDepth {
+		return nil, fmt.Errorf("expression is nested too deeply")
+	}
+
+	if _, ok := p.consume('-'); ok {
+		operand, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		return postgreSQLNegateExpression{operand: operand}, nil
+	}
+	if _, ok := p.consume('('); ok {
+		inner, err := p.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := p.consume(')'); !ok {
+			return nil, fmt.Errorf("missing \")\" at position %d", p.pos)
+		}
+		return inner, nil
+	}
+
+	p.skipSpaces()
+	if p.pos >= len(p.input) {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	start := p.pos
+	switch r := p.input[p.pos]; {
+	case unicode.IsDigit(r) || r == '.':
+		for p.pos < len(p.input) && (unicode.IsDigit(p.input[p.pos]) || p.input[p.pos] == '.') {
+			p.pos++
+		}
+		number, err := strconv.ParseFloat(string(p.input[start:p.pos]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q at position %d", string(p.input[start:p.pos]), start)
+		}
+		return postgreSQLNumberExpression(number), nil
+	case r == '_' || (r < unicode.MaxASCII && unicode.IsLetter(r)):
+		for p.pos < len(p.input) && (p.input[p.pos] == '_' || (p.input[p.pos] < unicode.MaxASCII && (unicode.IsLetter(p.input[p.pos]) || unicode.IsDigit(p.input[p.pos])))) {
+			p.pos++
+		}
+		return postgreSQLColumnExpression(p.input[start:p.pos]), nil
+	default:
+		return nil, fmt.Errorf("unexpected %q at position %d", r, start)
+	}
+}
@@ -0,0 +1,141 @@
+package scalers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+type postgreSQLExpressionTestData struct {
+	expression  string
+	columns     map[string]float64
+	expected    float64
+	raisesError bool
+}
+
+var testPostgreSQLExpressions = []postgreSQLExpressionTestData{
+	{expression: "pending", columns: map[string]float64{"pending": 5}, expected: 5},
+	{expression: "pending * 2 + processing", columns: map[string]float64{"pending": 5, "processing": 3}, expected: 13},
+	{expression: "pending + processing * 2", columns: map[string]float64{"pending": 5, "processing": 3}, expected: 11},
+	{expression: "(pending + processing) * 2", columns: map[string]float64{"pending": 5, "processing": 3}, expected: 16},
+	{expression: "pending - processing - 1", columns: map[string]float64{"pending": 5, "processing": 3}, expected: 1},
+	{expression: "pending / workers / 2", columns: map[string]float64{"pending": 12, "workers": 3}, expected: 2},
+	{expression: "-pending + 10", columns: map[string]float64{"pending": 4}, expected: 6},
+	{expression: "- -pending", columns: map[string]float64{"pending": 4}, expected: 4},
+	{expression: "0.5*total_rows", columns: map[string]float64{"total_rows": 9}, expected: 4.5},
+	{expression: "42", columns: map[string]float64{}, expected: 42},
+	// evaluation errors
+	{expression: "pending + unknown", columns: map[string]float64{"pending": 5}, raisesError: true},
+	{expression: "pending / workers", columns: map[string]float64{"pending": 5, "workers": 0}, raisesError: true},
+	{expression: "Pending", columns: map[string]float64{"pending": 5}, raisesError: true},
+}
+
+var testPostgreSQLInvalidExpressions = []string{
+	"",
+	"pending +",
+	"pending * * 2",
+	"(pending + 1",
+	"pending + 1)",
+	"pending processing",
+	"pending ^ 2",
+	"pending % 2",
+	"1.2.3",
+	"os.Exit(1)",
+	"count(*)",
+	"pending; DROP TABLE jobs",
+	"\"pending\"",
+	"((((((((((((((((((((((((((((((((((pending))))))))))))))))))))))))))))))))))",
+}
+
+var testPostgreSQLExpressionMetadata = []parsePostgresMetadataTestData{
+	// valid valueExpression
+	{
+		metadata:    map[string]string{"query": "query", "targetQueryValue": "12", "valueExpression": "pending * 2 + processing"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		resolvedEnv: map[string]string{},
+		raisesError: false,
+	},
+	// invalid valueExpression
+	{
+		metadata:    map[string]string{"query": "query", "targetQueryValue": "12", "valueExpression": "pending +"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		resolvedEnv: map[string]string{},
+		raisesError: true,
+	},
+	// metricMode age with valueExpression
+	{
+		metadata:    map[string]string{"metricMode": "age", "query": "query", "targetQueryValue": "60", "valueExpression": "oldest"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		resolvedEnv: map[string]string{},
+		raisesError: true,
+	},
+	// targetFromQuery with valueExpression
+	{
+		metadata:    map[string]string{"query": "query", "targetQueryValue": "10", "targetFromQuery": "true", "valueExpression": "a + b"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		resolvedEnv: map[string]string{},
+		raisesError: true,
+	},
+}
+
+func TestParsePostgreSQLExpressionMetadata(t *testing.T) {
+	testParsePostgreSQLMetadata(t, testPostgreSQLExpressionMetadata)
+}
+
+func TestPostgreSQLExpressionEvaluate(t *testing.T) {
+	for _, testData := range testPostgreSQLExpressions {
+		expression, err := parsePostgreSQLExpression(testData.expression)
+		if err != nil {
+			t.Errorf("Expected success parsing %q but got error %s", testData.expression, err)
+			continue
+		}
+		value, err := expression.evaluate(testData.columns)
+		if err != nil && !testData.raisesError {
+			t.Errorf("Expected success evaluating %q but got error %s", testData.expression, err)
+		}
+		if err == nil && testData.raisesError {
+			t.Errorf("Expected error evaluating %q but got success", testData.expression)
+		}
+		if err == nil && value != testData.expected {
+			t.Errorf("Expected %v evaluating %q but got %v", testData.expected, testData.expression, value)
+		}
+	}
+}
+
+func TestPostgreSQLExpressionParseErrors(t *testing.T) {
+	for _, expression := range testPostgreSQLInvalidExpressions {
+		if _, err := parsePostgreSQLExpression(expression); err == nil {
+			t.Errorf("Expected error parsing %q but got success", expression)
+		}
+	}
+}
+
+func TestPostgreSQLScalerValueExpression(t *testing.T) {
+	scaler, mock := newPostgreSQLMockScaler(t, &ScalerConfig{
+		TriggerMetadata: map[string]string{
+			"query":            "SELECT count(*) FILTER (WHERE state = 'pending') AS pending, count(*) FILTER (WHERE state = 'processing') AS processing FROM jobs",
+			"targetQueryValue": "10",
+			"valueExpression":  "pending * 2 + processing",
+		},
+		AuthParams: map[string]string{"connection": "host=localhost"},
+	})
+
+	mock.ExpectQuery("SELECT count").WillReturnRows(sqlmock.NewRows([]string{"pending", "processing"}).AddRow(4, nil))
+	value, err := scaler.getActiveNumber(context.Background())
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if value != 8 {
+		t.Errorf("Expected 8 but got %v", value)
+	}
+
+	mock.ExpectQuery("SELECT count").WillReturnRows(sqlmock.NewRows([]string{"pending", "done"}).AddRow(4, 1))
+	if _, err := scaler.getActiveNumber(context.Background()); err == nil {
+		t.Error("Expected error for a column missing from the query but got success")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,55 @@
+package scalers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// postgreSQLIdleInTransactionQuery counts the sessions of the current database which are idle inside
+// a transaction, including aborted ones, for at least $1 seconds. The session running it is active
+const postgreSQLIdleInTransactionQuery = `SELECT count(*) FROM pg_stat_activity ` +
+	`WHERE datname = current_database() AND state IN ('idle in transaction', 'idle in transaction (aborted)') ` +
+	`AND state_change <= now() - make_interval(secs => $1)`
+
+// parsePostgreSQLIdleInTransactionMetadata parses how long sessions have to be idle in transaction to be counted
+// by metricMode idleInTransaction
+func parsePostgreSQLIdleInTransactionMetadata(config *ScalerConfig, meta *postgreSQLMetadata) error {
+	if meta.metricMode != postgreSQLMetricModeIdleInTransaction {
+		if _, ok := config.TriggerMetadata["minIdleDuration"]; ok {
+			return fmt.Errorf("minIdleDuration can only be used with metricMode %s", postgreSQLMetricModeIdleInTransaction)
+		}
+		return nil
+	}
+	if meta.dialect == postgreSQLDialectCockroach {
+		return fmt.Errorf("metricMode %s can't be used with dialect %s", meta.metricMode, meta.dialect)
+	}
+	// by default every session idle in transaction counts, however briefly
+	var minIdle time.Duration
+	if val, ok := config.TriggerMetadata["minIdleDuration"]; ok && val != "" {
+		var err error
+		if minIdle, err = parsePostgreSQLDuration("minIdleDuration", val); err != nil {
+			return err
+		}
+		if minIdle < 0 {
+			return fmt.Errorf("minIdleDuration must not be negative, got %s", minIdle)
+		}
+	}
+	meta.query = postgreSQLIdleInTransactionQuery
+	meta.queryArgs = []interface{}{minIdle.Seconds()}
+	return nil
+}
+
+// queryIdleInTransaction returns the number of sessions idle in transaction
+func (s *postgreSQLScaler) queryIdleInTransaction(ctx context.Context, connection postgreSQLQuerier) (float64, error) {
+	var count sql.NullString
+	if err := connection.QueryRowContext(ctx, s.metadata.query, s.metadata.queryArgs...).Scan(&count); err != nil {
+		return 0, err
+	}
+	value, err := parsePostgreSQLResultValue(count, 0)
+	if err != nil {
+		return 0, fmt.Errorf("error parsing idle in transaction count: %w", err)
+	}
+	return value, nil
+}
@@ -0,0 +1,91 @@
+package scalers
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+var testPostgreSQLIdleInTransactionMetadata = []parsePostgresMetadataTestData{
+	// metricMode idleInTransaction
+	{
+		metadata:   map[string]string{"metricMode": "idleInTransaction", "minIdleDuration": "30s", "targetQueryValue": "1"},
+		authParams: map[string]string{"connection": "test_connection_string"},
+	},
+	// metricMode idleInTransaction with its own query
+	{
+		metadata:    map[string]string{"metricMode": "idleInTransaction", "query": "select 1", "targetQueryValue": "1"},
+		authParams:  map[string]string{"connection": "test_connection_string"},
+		raisesError: true,
+	},
+	// metricMode idleInTransaction with dialect cockroach
+	{
+		metadata:    map[string]string{"metricMode": "idleInTransaction", "dialect": "cockroach", "targetQueryValue": "1"},
+		authParams:  map[string]string{"connection": "test_connection_string"},
+		raisesError: true,
+	},
+	// negative minIdleDuration
+	{
+		metadata:    map[string]string{"metricMode": "idleInTransaction", "minIdleDuration": "-1m", "targetQueryValue": "1"},
+		authParams:  map[string]string{"connection": "test_connection_string"},
+		raisesError: true,
+	},
+	// minIdleDuration without metricMode idleInTransaction
+	{
+		metadata:    map[string]string{"query": "select 1", "minIdleDuration": "30s", "targetQueryValue": "1"},
+		authParams:  map[string]string{"connection": "test_connection_string"},
+		raisesError: true,
+	},
+}
+
+func TestParsePostgreSQLIdleInTransactionMetadata(t *testing.T) {
+	testParsePostgreSQLMetadata(t, testPostgreSQLIdleInTransactionMetadata)
+}
+
+func TestPostgreSQLIdleInTransactionQuery(t *testing.T) {
+	testData := []struct {
+		name        string
+		metadata    map[string]string
+		minIdle     float64
+		count       interface{}
+		expected    int64
+		raisesError bool
+	}{
+		{name: "count", count: 3, expected: 3},
+		{name: "count as text", count: "12", expected: 12},
+		{name: "minIdleDuration", metadata: map[string]string{"minIdleDuration": "5m"}, minIdle: 300, count: 1, expected: 1},
+		{name: "unparseable count", count: "many", raisesError: true},
+	}
+
+	// only the sessions of the database idle in a transaction, aborted or not, are counted
+	stateFilter := regexp.QuoteMeta("datname = current_database() AND state IN ('idle in transaction', 'idle in transaction (aborted)')")
+	for _, testData := range testData {
+		t.Run(testData.name, func(t *testing.T) {
+			metadata := map[string]string{"metricMode": "idleInTransaction", "targetQueryValue": "1"}
+			for key, value := range testData.metadata {
+				metadata[key] = value
+			}
+			scaler, mock := newPostgreSQLMockScaler(t, &ScalerConfig{
+				TriggerMetadata: metadata,
+				AuthParams:      map[string]string{"connection": "host=localhost"},
+			})
+			mock.ExpectQuery(stateFilter).WithArgs(testData.minIdle).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(testData.count))
+
+			metrics, err := scaler.GetMetrics(context.Background(), "s0-postgresql")
+			if err != nil && !testData.raisesError {
+				t.Fatal("Expected success but got error", err)
+			}
+			if err == nil && testData.raisesError {
+				t.Fatal("Expected error but got success")
+			}
+			if err == nil && metrics[0].Value.Value() != testData.expected {
+				t.Errorf("Expected metric value %d but got %d", testData.expected, metrics[0].Value.Value())
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
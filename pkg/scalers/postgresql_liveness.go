@@ -0,0 +1,59 @@
+package scalers
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// defaultPostgreSQLProducerStallWindow is how long the producerLivenessQuery may return the same timestamp
+const defaultPostgreSQLProducerStallWindow = 10 * time.Minute
+
+// parsePostgreSQLLivenessMetadata parses the producerLivenessQuery, which returns the latest write timestamp of
+// the producers, e.g. SELECT max(created_at), and the window it has to advance within
+func parsePostgreSQLLivenessMetadata(config *ScalerConfig, meta *postgreSQLMetadata) error {
+	val, ok := config.TriggerMetadata["producerLivenessQuery"]
+	if !ok || val == "" {
+		return nil
+	}
+	meta.producerLivenessQuery = val
+	meta.producerStallWindow = defaultPostgreSQLProducerStallWindow
+	if val, ok := config.TriggerMetadata["producerStallWindow"]; ok {
+		producerStallWindow, err := parsePostgreSQLDuration("producerStallWindow", val)
+		if err != nil {
+			return err
+		}
+		if producerStallWindow <= 0 {
+			return fmt.Errorf("producerStallWindow must be positive, got %s", producerStallWindow)
+		}
+		meta.producerStallWindow = producerStallWindow
+	}
+	if val, ok := config.TriggerMetadata["failOnProducerStall"]; ok {
+		failOnProducerStall, err := strconv.ParseBool(val)
+		if err != nil {
+			return fmt.Errorf("failOnProducerStall parsing error %s", err.Error())
+		}
+		meta.failOnProducerStall = failOnProducerStall
+	}
+	return nil
+}
+
+// postgreSQLLivenessTracker detects producers which stopped writing from consecutive readings of the
+// latest write timestamp. It only compares the readings with each other, so the clocks of the database
+// and KEDA don't need to agree
+type postgreSQLLivenessTracker struct {
+	window       time.Duration
+	latest       time.Time
+	lastAdvanced time.Time
+	hasLatest    bool
+}
+
+// stalled stores the reading and reports whether the latest write timestamp didn't advance within the
+// window. The zero time stands for no writes at all, e.g. when max() runs over an empty table
+func (l *postgreSQLLivenessTracker) stalled(latest, now time.Time) bool {
+	if !l.hasLatest || latest.After(l.latest) {
+		l.latest, l.lastAdvanced, l.hasLatest = latest, now, true
+		return false
+	}
+	return now.Sub(l.lastAdvanced) > l.window
+}
@@ -0,0 +1,126 @@
+package scalers
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+var testPostgreSQLLivenessMetadata = []parsePostgresMetadataTestData{
+	// producerLivenessQuery
+	{
+		metadata:    map[string]string{"query": "query", "targetQueryValue": "10", "producerLivenessQuery": "SELECT max(created_at) FROM jobs", "producerStallWindow": "5m", "failOnProducerStall": "true"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		resolvedEnv: map[string]string{},
+		raisesError: false,
+	},
+	// invalid producerStallWindow
+	{
+		metadata:    map[string]string{"query": "query", "targetQueryValue": "10", "producerLivenessQuery": "SELECT max(created_at) FROM jobs", "producerStallWindow": "0"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		resolvedEnv: map[string]string{},
+		raisesError: true,
+	},
+	// invalid failOnProducerStall
+	{
+		metadata:    map[string]string{"query": "query", "targetQueryValue": "10", "producerLivenessQuery": "SELECT max(created_at) FROM jobs", "failOnProducerStall": "maybe"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		resolvedEnv: map[string]string{},
+		raisesError: true,
+	},
+}
+
+func TestParsePostgreSQLLivenessMetadata(t *testing.T) {
+	testParsePostgreSQLMetadata(t, testPostgreSQLLivenessMetadata)
+}
+
+func TestPostgreSQLLivenessTracker(t *testing.T) {
+	start := time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC)
+	written := start.Add(-time.Minute)
+
+	testData := []struct {
+		name     string
+		latest   time.Time
+		elapsed  time.Duration
+		expected bool
+	}{
+		{name: "first reading", latest: written, elapsed: 0, expected: false},
+		{name: "unchanged within window", latest: written, elapsed: 4 * time.Minute, expected: false},
+		{name: "advanced", latest: written.Add(5 * time.Minute), elapsed: 5 * time.Minute, expected: false},
+		{name: "unchanged at window", latest: written.Add(5 * time.Minute), elapsed: 10 * time.Minute, expected: false},
+		{name: "stalled", latest: written.Add(5 * time.Minute), elapsed: 11 * time.Minute, expected: true},
+		{name: "went back", latest: written, elapsed: 12 * time.Minute, expected: true},
+		{name: "recovered", latest: written.Add(12 * time.Minute), elapsed: 13 * time.Minute, expected: false},
+	}
+
+	tracker := &postgreSQLLivenessTracker{window: 5 * time.Minute}
+	for _, testData := range testData {
+		if stalled := tracker.stalled(testData.latest, start.Add(testData.elapsed)); stalled != testData.expected {
+			t.Errorf("%s: expected stalled %v but got %v", testData.name, testData.expected, stalled)
+		}
+	}
+}
+
+func TestPostgreSQLLivenessTrackerWithoutWrites(t *testing.T) {
+	start := time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC)
+	tracker := &postgreSQLLivenessTracker{window: time.Minute}
+	if tracker.stalled(time.Time{}, start) {
+		t.Error("Expected the first reading not to be stalled")
+	}
+	if !tracker.stalled(time.Time{}, start.Add(2*time.Minute)) {
+		t.Error("Expected no writes beyond the window to be stalled")
+	}
+	if tracker.stalled(start.Add(90*time.Second), start.Add(2*time.Minute)) {
+		t.Error("Expected the first write not to be stalled")
+	}
+}
+
+func TestPostgreSQLProducerLiveness(t *testing.T) {
+	for _, failOnProducerStall := range []string{"false", "true"} {
+		scaler, mock := newPostgreSQLMockScaler(t, &ScalerConfig{
+			TriggerMetadata: map[string]string{
+				"query":                 "SELECT count(*) FROM jobs",
+				"targetQueryValue":      "5",
+				"producerLivenessQuery": "SELECT max(created_at) FROM jobs",
+				"producerStallWindow":   "1m",
+				"failOnProducerStall":   failOnProducerStall,
+			},
+			AuthParams: map[string]string{"connection": "host=localhost"},
+		})
+		written := time.Now().Add(-time.Hour)
+		// the scaler saw the timestamp advance long ago
+		scaler.liveness.stalled(written, time.Now().Add(-2*time.Minute))
+
+		mock.ExpectQuery("SELECT max").WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(written))
+		if failOnProducerStall == "false" {
+			mock.ExpectQuery("SELECT count").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(7))
+		}
+		value, err := scaler.getActiveNumber(context.Background())
+		if failOnProducerStall == "true" {
+			if err == nil || !strings.Contains(err.Error(), "didn't advance") {
+				t.Errorf("Expected a stalled producer error but got %v", err)
+			}
+		} else if err != nil || value != 7 {
+			t.Errorf("Expected the value 7 despite stalled producers but got %v, %v", value, err)
+		}
+		if stalled := testutil.ToFloat64(postgreSQLProducerStalled.With(scaler.recorder.labels)); stalled != 1 {
+			t.Errorf("Expected the producers to be reported as stalled but got %v", stalled)
+		}
+
+		mock.ExpectQuery("SELECT max").WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(written.Add(time.Minute)))
+		mock.ExpectQuery("SELECT count").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(8))
+		if value, err := scaler.getActiveNumber(context.Background()); err != nil || value != 8 {
+			t.Errorf("Expected the value 8 after the timestamp advanced but got %v, %v", value, err)
+		}
+		if stalled := testutil.ToFloat64(postgreSQLProducerStalled.With(scaler.recorder.labels)); stalled != 0 {
+			t.Errorf("Expected the producers to be reported as live but got %v", stalled)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	}
+}
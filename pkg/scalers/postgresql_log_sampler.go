@@ -0,0 +1,63 @@
+package scalers
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// parsePostgreSQLLogSamplerMetadata parses the errorLogInterval an error with the same message is logged in at most
+func parsePostgreSQLLogSamplerMetadata(config *ScalerConfig, meta *postgreSQLMetadata) error {
+	if val, ok := config.TriggerMetadata["errorLogInterval"]; ok {
+		errorLogInterval, err := parsePostgreSQLDuration("errorLogInterval", val)
+		if err != nil {
+			return err
+		}
+		if errorLogInterval < 0 {
+			return fmt.Errorf("errorLogInterval must not be negative, got %s", errorLogInterval)
+		}
+		meta.errorLogInterval = errorLogInterval
+	}
+	return nil
+}
+
+// postgreSQLLogSampler limits repeated errors with the same signature to one log line per interval
+type postgreSQLLogSampler struct {
+	interval time.Duration
+	mutex    sync.Mutex
+	entries  map[string]*postgreSQLLogSamplerEntry
+}
+
+type postgreSQLLogSamplerEntry struct {
+	loggedAt   time.Time
+	suppressed int
+}
+
+func newPostgreSQLLogSampler(interval time.Duration) *postgreSQLLogSampler {
+	return &postgreSQLLogSampler{interval: interval, entries: map[string]*postgreSQLLogSamplerEntry{}}
+}
+
+// allow reports whether an error with the signature should be logged now, together with the number of
+// occurrences suppressed since it was last logged. Signatures logged more than an interval ago are
+// forgotten, so the map doesn't grow with one-off errors
+func (l *postgreSQLLogSampler) allow(signature string, now time.Time) (bool, int) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	entry, ok := l.entries[signature]
+	if ok && now.Sub(entry.loggedAt) < l.interval {
+		entry.suppressed++
+		return false, 0
+	}
+	suppressed := 0
+	if ok {
+		suppressed = entry.suppressed
+	}
+	for key, other := range l.entries {
+		if now.Sub(other.loggedAt) >= l.interval {
+			delete(l.entries, key)
+		}
+	}
+	l.entries[signature] = &postgreSQLLogSamplerEntry{loggedAt: now}
+	return true, suppressed
+}
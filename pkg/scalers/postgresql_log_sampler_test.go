@@ -0,0 +1,60 @@
+package scalers
+
+import (
+	"testing"
+	"time"
+)
+
+var testPostgreSQLLogSamplerMetadata = []parsePostgresMetadataTestData{
+	// errorLogInterval
+	{
+		metadata:    map[string]string{"query": "query", "targetQueryValue": "5", "errorLogInterval": "1m"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		resolvedEnv: map[string]string{},
+		raisesError: false,
+	},
+	// negative errorLogInterval
+	{
+		metadata:    map[string]string{"query": "query", "targetQueryValue": "5", "errorLogInterval": "-1m"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		resolvedEnv: map[string]string{},
+		raisesError: true,
+	},
+}
+
+func TestParsePostgreSQLLogSamplerMetadata(t *testing.T) {
+	testParsePostgreSQLMetadata(t, testPostgreSQLLogSamplerMetadata)
+}
+
+func TestPostgreSQLLogSampler(t *testing.T) {
+	sampler := newPostgreSQLLogSampler(time.Minute)
+	start := time.Now()
+
+	testData := []struct {
+		name       string
+		signature  string
+		after      time.Duration
+		allowed    bool
+		suppressed int
+	}{
+		{name: "first occurrence", signature: "connection refused", after: 0, allowed: true},
+		{name: "repeated", signature: "connection refused", after: 10 * time.Second, allowed: false},
+		{name: "repeated again", signature: "connection refused", after: 20 * time.Second, allowed: false},
+		{name: "other signature", signature: "relation does not exist", after: 30 * time.Second, allowed: true},
+		{name: "after the interval", signature: "connection refused", after: time.Minute, allowed: true, suppressed: 2},
+		{name: "other signature within its interval", signature: "relation does not exist", after: 80 * time.Second, allowed: false},
+		{name: "no suppression since last log", signature: "connection refused", after: 3 * time.Minute, allowed: true},
+	}
+
+	for _, testData := range testData {
+		allowed, suppressed := sampler.allow(testData.signature, start.Add(testData.after))
+		if allowed != testData.allowed || suppressed != testData.suppressed {
+			t.Errorf("%s: expected allowed %v with %d suppressed but got %v with %d",
+				testData.name, testData.allowed, testData.suppressed, allowed, suppressed)
+		}
+	}
+	// the signature which wasn't logged for an interval was forgotten
+	if _, ok := sampler.entries["relation does not exist"]; ok {
+		t.Error("Expected the stale signature to be removed")
+	}
+}
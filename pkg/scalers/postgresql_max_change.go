@@ -0,0 +1,32 @@
+package scalers
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// parsePostgreSQLMaxChangeMetadata parses maxChangePerRead, the largest increase of the value over the previous reading
+func parsePostgreSQLMaxChangeMetadata(config *ScalerConfig, meta *postgreSQLMetadata) error {
+	if val, ok := config.TriggerMetadata["maxChangePerRead"]; ok && val != "" {
+		maxChangePerRead, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			return fmt.Errorf("maxChangePerRead parsing error %s", err.Error())
+		}
+		if maxChangePerRead <= 0 || math.IsNaN(maxChangePerRead) || math.IsInf(maxChangePerRead, 0) {
+			return fmt.Errorf("maxChangePerRead must be positive, got %v", maxChangePerRead)
+		}
+		meta.maxChangePerRead = maxChangePerRead
+	}
+	return nil
+}
+
+// capPostgreSQLValueIncrease limits the increase of value over the previous reading to maxChange.
+// The capped value is the baseline of the next reading, so a lasting load is reached within a few
+// reads while a single outlier only moves the metric by maxChange. Decreases aren't limited
+func capPostgreSQLValueIncrease(previous, value, maxChange float64) float64 {
+	if value > previous+maxChange {
+		return previous + maxChange
+	}
+	return value
+}
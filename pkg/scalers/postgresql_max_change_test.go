@@ -0,0 +1,76 @@
+package scalers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+type postgreSQLMaxChangeTestData struct {
+	name     string
+	previous float64
+	value    float64
+	expected float64
+}
+
+var testPostgreSQLMaxChanges = []postgreSQLMaxChangeTestData{
+	{name: "increase within the limit", previous: 100, value: 140, expected: 140},
+	{name: "increase by the limit", previous: 100, value: 150, expected: 150},
+	{name: "increase above the limit", previous: 100, value: 10000, expected: 150},
+	{name: "decrease", previous: 100, value: 0, expected: 0},
+	{name: "unchanged", previous: 100, value: 100, expected: 100},
+}
+
+var testPostgreSQLMaxChangeMetadata = []parsePostgresMetadataTestData{
+	// maxChangePerRead
+	{
+		metadata:   map[string]string{"query": "test_query", "targetQueryValue": "5", "maxChangePerRead": "20.5"},
+		authParams: map[string]string{"connection": "test_connection_string"},
+	},
+	// negative maxChangePerRead
+	{
+		metadata:    map[string]string{"query": "test_query", "targetQueryValue": "5", "maxChangePerRead": "-1"},
+		authParams:  map[string]string{"connection": "test_connection_string"},
+		raisesError: true,
+	},
+	// invalid maxChangePerRead
+	{
+		metadata:    map[string]string{"query": "test_query", "targetQueryValue": "5", "maxChangePerRead": "NaN"},
+		authParams:  map[string]string{"connection": "test_connection_string"},
+		raisesError: true,
+	},
+}
+
+func TestParsePostgreSQLMaxChangeMetadata(t *testing.T) {
+	testParsePostgreSQLMetadata(t, testPostgreSQLMaxChangeMetadata)
+}
+
+func TestCapPostgreSQLValueIncrease(t *testing.T) {
+	for _, testData := range testPostgreSQLMaxChanges {
+		if value := capPostgreSQLValueIncrease(testData.previous, testData.value, 50); value != testData.expected {
+			t.Errorf("%s: expected %v but got %v", testData.name, testData.expected, value)
+		}
+	}
+}
+
+func TestPostgreSQLScalerMaxChangePerRead(t *testing.T) {
+	scaler, mock := newPostgreSQLMockScaler(t, &ScalerConfig{
+		TriggerMetadata: map[string]string{"query": "test_query", "targetQueryValue": "100", "maxChangePerRead": "500"},
+		AuthParams:      map[string]string{"connection": "host=localhost"},
+	})
+	// the first reading isn't capped, the spike is reached step by step and the drop right away
+	for _, testData := range []struct{ read, expected float64 }{{200, 200}, {5000, 700}, {5000, 1200}, {1300, 1300}, {100, 100}} {
+		mock.ExpectQuery("test_query").WillReturnRows(sqlmock.NewRows([]string{"value"}).AddRow(testData.read))
+		value, err := scaler.getActiveNumber(context.Background())
+		if err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+		if value != testData.expected {
+			t.Errorf("Expected %v for a read of %v but got %v", testData.expected, testData.read, value)
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,337 @@
+package scalers
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	defaultPostgreSQLMetricsNamespace = "keda"
+	defaultPostgreSQLMetricsSubsystem = "postgresql_scaler"
+)
+
+// postgreSQLMetricsNamespace and postgreSQLMetricsSubsystem prefix the names of the Prometheus metrics.
+// KEDA_POSTGRESQL_METRICS_NAMESPACE and KEDA_POSTGRESQL_METRICS_SUBSYSTEM of the operator override them,
+// e.g. to fit existing dashboards or to avoid collisions with other metrics
+var postgreSQLMetricsNamespace, postgreSQLMetricsSubsystem = getPostgreSQLMetricsPrefix(os.Getenv)
+
+// postgreSQLMetricsPrefixPattern is what Prometheus accepts as part of a metric name
+var postgreSQLMetricsPrefixPattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// getPostgreSQLMetricsPrefix returns the namespace and subsystem of the metrics from the environment.
+// The metrics are registered when the operator starts, so a name Prometheus wouldn't accept falls back to
+// the default instead of failing the registration
+func getPostgreSQLMetricsPrefix(getenv func(string) string) (string, string) {
+	namespace, subsystem := defaultPostgreSQLMetricsNamespace, defaultPostgreSQLMetricsSubsystem
+	if val := getenv("KEDA_POSTGRESQL_METRICS_NAMESPACE"); postgreSQLMetricsPrefixPattern.MatchString(val) {
+		namespace = val
+	}
+	if val := getenv("KEDA_POSTGRESQL_METRICS_SUBSYSTEM"); postgreSQLMetricsPrefixPattern.MatchString(val) {
+		subsystem = val
+	}
+	return namespace, subsystem
+}
+
+// postgreSQLValueDistributionMaxAge is the window of the query value summary, long enough to cover
+// the daily peaks operators tune targetQueryValue for while still following trend changes
+const postgreSQLValueDistributionMaxAge = time.Hour
+
+var (
+	postgreSQLMetricLabels   = []string{"namespace", "scaledObject", "metric"}
+	postgreSQLQueryDurations = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: postgreSQLMetricsNamespace,
+			Subsystem: postgreSQLMetricsSubsystem,
+			Name:      "query_duration_seconds",
+			Help:      "Duration of the PostgreSQL scaler queries",
+			Buckets:   prometheus.DefBuckets,
+		},
+		postgreSQLMetricLabels,
+	)
+	postgreSQLQueryValues = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: postgreSQLMetricsNamespace,
+			Subsystem: postgreSQLMetricsSubsystem,
+			Name:      "query_value",
+			Help:      "Value returned by the last successful PostgreSQL scaler query",
+		},
+		postgreSQLMetricLabels,
+	)
+	postgreSQLQueryValueDistribution = prometheus.NewSummaryVec(
+		prometheus.SummaryOpts{
+			Namespace:  postgreSQLMetricsNamespace,
+			Subsystem:  postgreSQLMetricsSubsystem,
+			Name:       "query_value_distribution",
+			Help:       "Distribution of the values returned by the PostgreSQL scaler queries with recordValueDistribution, to help tuning targetQueryValue",
+			Objectives: map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.95: 0.005, 0.99: 0.001},
+			MaxAge:     postgreSQLValueDistributionMaxAge,
+		},
+		postgreSQLMetricLabels,
+	)
+	postgreSQLTargetValues = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: postgreSQLMetricsNamespace,
+			Subsystem: postgreSQLMetricsSubsystem,
+			Name:      "target_value",
+			Help:      "Target the PostgreSQL scaler query values are compared with, exported with recordTargetValue",
+		},
+		postgreSQLMetricLabels,
+	)
+	postgreSQLConnectionDurations = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: postgreSQLMetricsNamespace,
+			Subsystem: postgreSQLMetricsSubsystem,
+			Name:      "connection_duration_seconds",
+			Help:      "Duration of acquiring a connection for the PostgreSQL scaler queries, including establishing it",
+			Buckets:   prometheus.DefBuckets,
+		},
+		postgreSQLMetricLabels,
+	)
+	postgreSQLProducerStalled = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: postgreSQLMetricsNamespace,
+			Subsystem: postgreSQLMetricsSubsystem,
+			Name:      "producer_stalled",
+			Help:      "1 if the latest write timestamp of the PostgreSQL scaler producerLivenessQuery stopped advancing",
+		},
+		postgreSQLMetricLabels,
+	)
+	postgreSQLScalerReady = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: postgreSQLMetricsNamespace,
+			Subsystem: postgreSQLMetricsSubsystem,
+			Name:      "ready",
+			Help:      "1 once the PostgreSQL scaler read a value successfully, 0 until then",
+		},
+		postgreSQLMetricLabels,
+	)
+	// postgreSQLScalerInfo describes the configuration of the scaler, it tells nothing about the connection or query
+	postgreSQLScalerInfo = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: postgreSQLMetricsNamespace,
+			Subsystem: postgreSQLMetricsSubsystem,
+			Name:      "info",
+			Help:      "Always 1 for every PostgreSQL scaler, labeled with its metricMode, metric type and targetQueryValue",
+		},
+		append(append([]string{}, postgreSQLMetricLabels...), "metricMode", "metricType", "targetQueryValue"),
+	)
+	postgreSQLUnexpectedValues = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: postgreSQLMetricsNamespace,
+			Subsystem: postgreSQLMetricsSubsystem,
+			Name:      "unexpected_values_total",
+			Help:      "Number of PostgreSQL scaler values below expectedMin or above expectedMax by the bound they crossed",
+		},
+		append(append([]string{}, postgreSQLMetricLabels...), "bound"),
+	)
+	postgreSQLThresholdCrossings = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: postgreSQLMetricsNamespace,
+			Subsystem: postgreSQLMetricsSubsystem,
+			Name:      "threshold_crossings_total",
+			Help:      "Number of times the PostgreSQL scaler metric rose above targetQueryValue, direction breach, or fell back to it, direction clear",
+		},
+		append(append([]string{}, postgreSQLMetricLabels...), "direction"),
+	)
+	postgreSQLQueryErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: postgreSQLMetricsNamespace,
+			Subsystem: postgreSQLMetricsSubsystem,
+			Name:      "query_errors_total",
+			Help:      "Number of failed PostgreSQL scaler queries by the SQLSTATE class of the error, none if the server didn't report one",
+		},
+		append(append([]string{}, postgreSQLMetricLabels...), "sqlstateClass"),
+	)
+)
+
+// parsePostgreSQLMetricsMetadata parses which of the optional Prometheus metrics are recorded
+func parsePostgreSQLMetricsMetadata(config *ScalerConfig, meta *postgreSQLMetadata) error {
+	if val, ok := config.TriggerMetadata["recordValueDistribution"]; ok {
+		recordValueDistribution, err := strconv.ParseBool(val)
+		if err != nil {
+			return fmt.Errorf("recordValueDistribution parsing error %s", err.Error())
+		}
+		meta.recordValueDistribution = recordValueDistribution
+	}
+
+	if val, ok := config.TriggerMetadata["recordTargetValue"]; ok {
+		recordTargetValue, err := strconv.ParseBool(val)
+		if err != nil {
+			return fmt.Errorf("recordTargetValue parsing error %s", err.Error())
+		}
+		meta.recordTargetValue = recordTargetValue
+	}
+	return nil
+}
+
+// postgreSQLLabeledMetrics are all metrics labeled with the scaler, whose series are deleted when it's closed
+var postgreSQLLabeledMetrics = []interface {
+	prometheus.Collector
+	DeletePartialMatch(labels prometheus.Labels) int
+}{
+	postgreSQLQueryDurations,
+	postgreSQLQueryValues,
+	postgreSQLQueryValueDistribution,
+	postgreSQLTargetValues,
+	postgreSQLQueryErrors,
+	postgreSQLConnectionDurations,
+	postgreSQLProducerStalled,
+	postgreSQLScalerReady,
+	postgreSQLUnexpectedValues,
+	postgreSQLScalerInfo,
+	postgreSQLThresholdCrossings,
+}
+
+func init() {
+	for _, collector := range postgreSQLLabeledMetrics {
+		metrics.Registry.MustRegister(collector)
+	}
+}
+
+// postgreSQLRecorderOwners counts the open scalers recording with the same labels. A scaler is replaced by
+// creating the new one before closing the old one, so the series are only deleted with the last of them
+var (
+	postgreSQLRecorderOwners      = map[postgreSQLRecorderKey]int{}
+	postgreSQLRecorderOwnersMutex sync.Mutex
+)
+
+// postgreSQLRecorderKey are the labels the series of a scaler are recorded with
+type postgreSQLRecorderKey struct {
+	namespace    string
+	scaledObject string
+	metric       string
+}
+
+// postgreSQLQueryRecorder records the query signals of a scaler with its labels
+type postgreSQLQueryRecorder struct {
+	key    postgreSQLRecorderKey
+	labels prometheus.Labels
+	// released is set once the scaler gave up its share of the series
+	released bool
+	// recordDistribution adds the values to postgreSQLQueryValueDistribution
+	recordDistribution bool
+	// recordTarget exports the target to postgreSQLTargetValues
+	recordTarget bool
+}
+
+func newPostgreSQLQueryRecorder(config *ScalerConfig, metricName string) *postgreSQLQueryRecorder {
+	key := postgreSQLRecorderKey{namespace: config.ScalableObjectNamespace, scaledObject: config.ScalableObjectName, metric: metricName}
+	postgreSQLRecorderOwnersMutex.Lock()
+	postgreSQLRecorderOwners[key]++
+	postgreSQLRecorderOwnersMutex.Unlock()
+	return &postgreSQLQueryRecorder{
+		key: key,
+		labels: prometheus.Labels{
+			"namespace":    key.namespace,
+			"scaledObject": key.scaledObject,
+			"metric":       key.metric,
+		},
+	}
+}
+
+// recordQuery records the duration of a query and either its value or its failure
+func (r *postgreSQLQueryRecorder) recordQuery(duration time.Duration, value float64, err error) {
+	postgreSQLQueryDurations.With(r.labels).Observe(duration.Seconds())
+	if err != nil {
+		class := getPostgreSQLSQLStateClass(err)
+		postgreSQLQueryErrors.With(r.errorLabels(class)).Inc()
+		return
+	}
+	postgreSQLQueryValues.With(r.labels).Set(value)
+	if r.recordDistribution {
+		postgreSQLQueryValueDistribution.With(r.labels).Observe(value)
+	}
+}
+
+// errorLabels returns the labels of the error counter for the SQLSTATE class
+func (r *postgreSQLQueryRecorder) errorLabels(class string) prometheus.Labels {
+	return r.labelsWith("sqlstateClass", class)
+}
+
+// labelsWith returns the labels of the scaler with the additional label name
+func (r *postgreSQLQueryRecorder) labelsWith(label, labelValue string) prometheus.Labels {
+	labels := prometheus.Labels{label: labelValue}
+	for name, value := range r.labels {
+		labels[name] = value
+	}
+	return labels
+}
+
+// recordTargetValue records the target in the unit of the query value, e.g. the target computed from
+// the capacity or read with targetFromQuery
+func (r *postgreSQLQueryRecorder) recordTargetValue(target float64) {
+	if r.recordTarget {
+		postgreSQLTargetValues.With(r.labels).Set(target)
+	}
+}
+
+// recordConnection records the duration of acquiring a connection. Reusing an idle connection is almost free,
+// so the slow observations show the cost of the dial, TLS handshake and authentication
+func (r *postgreSQLQueryRecorder) recordConnection(duration time.Duration) {
+	postgreSQLConnectionDurations.With(r.labels).Observe(duration.Seconds())
+}
+
+// recordProducerStalled records a change of the producer liveness
+func (r *postgreSQLQueryRecorder) recordProducerStalled(stalled bool) {
+	if stalled {
+		postgreSQLProducerStalled.With(r.labels).Set(1)
+		return
+	}
+	postgreSQLProducerStalled.With(r.labels).Set(0)
+}
+
+// recordUnexpectedValue counts a value outside of the expected range below or above
+func (r *postgreSQLQueryRecorder) recordUnexpectedValue(bound string) {
+	postgreSQLUnexpectedValues.With(r.labelsWith("bound", bound)).Inc()
+}
+
+// recordThresholdCrossing counts a crossing of targetQueryValue in direction
+func (r *postgreSQLQueryRecorder) recordThresholdCrossing(direction string) {
+	postgreSQLThresholdCrossings.With(r.labelsWith("direction", direction)).Inc()
+}
+
+// recordReady records whether the scaler read a value yet. It's a gauge only, the scalers are recreated
+// with the ScaledObject so a counter of ready scalers wouldn't go down again
+func (r *postgreSQLQueryRecorder) recordReady(ready bool) {
+	if ready {
+		postgreSQLScalerReady.With(r.labels).Set(1)
+		return
+	}
+	postgreSQLScalerReady.With(r.labels).Set(0)
+}
+
+// recordInfo exports the configuration of the scaler to postgreSQLScalerInfo
+func (r *postgreSQLQueryRecorder) recordInfo(metricMode, metricType string, target float64) {
+	labels := r.labelsWith("metricMode", metricMode)
+	labels["metricType"] = metricType
+	labels["targetQueryValue"] = strconv.FormatFloat(target, 'f', -1, 64)
+	postgreSQLScalerInfo.With(labels).Set(1)
+}
+
+// release gives up the scaler's share of the series. The last scaler recording with the labels removes all
+// of them, so a closed scaler drops out of the exported metrics instead of being reported with its last values,
+// while a scaler replaced by one with the same labels keeps the series the new one already records
+func (r *postgreSQLQueryRecorder) release() {
+	if r.released {
+		return
+	}
+	r.released = true
+
+	postgreSQLRecorderOwnersMutex.Lock()
+	defer postgreSQLRecorderOwnersMutex.Unlock()
+	postgreSQLRecorderOwners[r.key]--
+	if postgreSQLRecorderOwners[r.key] > 0 {
+		return
+	}
+	delete(postgreSQLRecorderOwners, r.key)
+	for _, vec := range postgreSQLLabeledMetrics {
+		vec.DeletePartialMatch(r.labels)
+	}
+}
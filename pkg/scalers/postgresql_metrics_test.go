@@ -0,0 +1,445 @@
+package scalers
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+)
+
+var testPostgreSQLMetricsMetadata = []parsePostgresMetadataTestData{
+	// invalid recordValueDistribution
+	{
+		metadata:    map[string]string{"query": "query", "targetQueryValue": "5", "recordValueDistribution": "sometimes"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		resolvedEnv: map[string]string{},
+		raisesError: true,
+	},
+}
+
+func TestParsePostgreSQLMetricsMetadata(t *testing.T) {
+	testParsePostgreSQLMetadata(t, testPostgreSQLMetricsMetadata)
+}
+
+func TestPostgreSQLQueryMetrics(t *testing.T) {
+	scaler, mock := newPostgreSQLMockScaler(t, &ScalerConfig{
+		ScalableObjectName:      "metrics-test",
+		ScalableObjectNamespace: "default",
+		TriggerMetadata:         map[string]string{"query": "SELECT count(*) FROM jobs", "targetQueryValue": "5"},
+		AuthParams:              map[string]string{"connection": "host=localhost"},
+	})
+	labels := scaler.recorder.labels
+
+	mock.ExpectQuery("SELECT count").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(7))
+	mock.ExpectQuery("SELECT count").WillReturnError(errors.New("connection reset by peer"))
+	if _, err := scaler.getActiveNumber(context.Background()); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if _, err := scaler.getActiveNumber(context.Background()); err == nil {
+		t.Fatal("Expected error but got success")
+	}
+
+	if value := testutil.ToFloat64(postgreSQLQueryValues.With(labels)); value != 7 {
+		t.Errorf("Expected query value 7 but got %v", value)
+	}
+	if failures := testutil.ToFloat64(postgreSQLQueryErrors.With(scaler.recorder.errorLabels(postgreSQLSQLStateClassNone))); failures != 1 {
+		t.Errorf("Expected 1 query error but got %v", failures)
+	}
+	if count := testutil.CollectAndCount(postgreSQLQueryDurations, "keda_postgresql_scaler_query_duration_seconds"); count == 0 {
+		t.Error("Expected query durations to be recorded")
+	}
+}
+
+func TestPostgreSQLConnectionMetrics(t *testing.T) {
+	scaler, mock := newPostgreSQLMockScaler(t, &ScalerConfig{
+		ScalableObjectName:      "connection-metrics-test",
+		ScalableObjectNamespace: "default",
+		TriggerMetadata:         map[string]string{"query": "SELECT count(*) FROM jobs", "targetQueryValue": "5"},
+		AuthParams:              map[string]string{"connection": "host=localhost"},
+	})
+
+	mock.ExpectQuery("SELECT count").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(7))
+	mock.ExpectQuery("SELECT count").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(8))
+	for i := 0; i < 2; i++ {
+		if _, err := scaler.getActiveNumber(context.Background()); err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+	}
+
+	var histogram dto.Metric
+	if err := postgreSQLConnectionDurations.With(scaler.recorder.labels).(prometheus.Histogram).Write(&histogram); err != nil {
+		t.Fatal(err)
+	}
+	if count := histogram.GetHistogram().GetSampleCount(); count != 2 {
+		t.Errorf("Expected 2 connection durations but got %d", count)
+	}
+}
+
+func TestPostgreSQLQueryValueDistribution(t *testing.T) {
+	testData := []struct {
+		name   string
+		record string
+		count  uint64
+	}{
+		{name: "distribution-test", record: "true", count: 3},
+		{name: "no-distribution-test", record: "false", count: 0},
+	}
+
+	for _, testData := range testData {
+		scaler, mock := newPostgreSQLMockScaler(t, &ScalerConfig{
+			ScalableObjectName:      testData.name,
+			ScalableObjectNamespace: "default",
+			TriggerMetadata:         map[string]string{"query": "SELECT count(*) FROM jobs", "targetQueryValue": "5", "recordValueDistribution": testData.record},
+			AuthParams:              map[string]string{"connection": "host=localhost"},
+		})
+		for _, value := range []int{4, 10, 16} {
+			mock.ExpectQuery("SELECT count").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(value))
+			if _, err := scaler.getActiveNumber(context.Background()); err != nil {
+				t.Fatal("Unexpected error:", err)
+			}
+		}
+
+		var summary dto.Metric
+		if err := postgreSQLQueryValueDistribution.With(scaler.recorder.labels).(prometheus.Summary).Write(&summary); err != nil {
+			t.Fatal(err)
+		}
+		if count := summary.GetSummary().GetSampleCount(); count != testData.count {
+			t.Errorf("%s: expected %d recorded values but got %d", testData.name, testData.count, count)
+		}
+		if testData.count == 0 {
+			continue
+		}
+		if sum := summary.GetSummary().GetSampleSum(); sum != 30 {
+			t.Errorf("%s: expected a sum of 30 but got %v", testData.name, sum)
+		}
+		for _, quantile := range summary.GetSummary().GetQuantile() {
+			if quantile.GetQuantile() == 0.5 && quantile.GetValue() != 10 {
+				t.Errorf("%s: expected the median 10 but got %v", testData.name, quantile.GetValue())
+			}
+		}
+	}
+}
+
+func TestPostgreSQLScalerReady(t *testing.T) {
+	scaler, mock := newPostgreSQLMockScaler(t, &ScalerConfig{
+		ScalableObjectName:      "ready-test",
+		ScalableObjectNamespace: "default",
+		TriggerMetadata:         map[string]string{"query": "SELECT count(*) FROM jobs", "targetQueryValue": "5"},
+		AuthParams:              map[string]string{"connection": "host=localhost"},
+	})
+	labels := scaler.recorder.labels
+
+	testData := []struct {
+		err   error
+		ready bool
+	}{
+		{err: errors.New("connection refused"), ready: false},
+		{ready: true},
+		{err: errors.New("connection reset by peer"), ready: true},
+		{ready: true},
+	}
+
+	if scaler.isReady() || testutil.ToFloat64(postgreSQLScalerReady.With(labels)) != 0 {
+		t.Fatal("Expected a new scaler not to be ready")
+	}
+	for i, testData := range testData {
+		if testData.err != nil {
+			mock.ExpectQuery("SELECT count").WillReturnError(testData.err)
+		} else {
+			mock.ExpectQuery("SELECT count").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(7))
+		}
+		_, _ = scaler.getActiveNumber(context.Background())
+
+		if ready := scaler.isReady(); ready != testData.ready {
+			t.Errorf("read %d: expected ready %v but got %v", i, testData.ready, ready)
+		}
+		expected := 0.0
+		if testData.ready {
+			expected = 1
+		}
+		if value := testutil.ToFloat64(postgreSQLScalerReady.With(labels)); value != expected {
+			t.Errorf("read %d: expected ready gauge %v but got %v", i, expected, value)
+		}
+	}
+}
+
+func TestPostgreSQLQueryErrorsSQLStateClass(t *testing.T) {
+	scaler, mock := newPostgreSQLMockScaler(t, &ScalerConfig{
+		ScalableObjectName:      "sqlstate-metrics-test",
+		ScalableObjectNamespace: "default",
+		TriggerMetadata:         map[string]string{"query": "SELECT count(*) FROM jobs", "targetQueryValue": "5"},
+		AuthParams:              map[string]string{"connection": "host=localhost"},
+	})
+
+	testData := []struct {
+		err   error
+		class string
+	}{
+		{err: &pq.Error{Code: "42P01", Message: "relation \"jobs\" does not exist"}, class: "42"},
+		{err: &pq.Error{Code: "42601", Message: "syntax error"}, class: "42"},
+		{err: &pq.Error{Code: "28P01", Message: "password authentication failed"}, class: "28"},
+		{err: &pq.Error{Code: "08006", Message: "connection failure"}, class: "08"},
+		{err: errors.New("connection reset by peer"), class: postgreSQLSQLStateClassNone},
+	}
+	for _, testData := range testData {
+		mock.ExpectQuery("SELECT count").WillReturnError(testData.err)
+		if _, err := scaler.getActiveNumber(context.Background()); err == nil {
+			t.Fatal("Expected error but got success")
+		}
+	}
+
+	expected := map[string]float64{"42": 2, "28": 1, "08": 1, postgreSQLSQLStateClassNone: 1}
+	for class, count := range expected {
+		if failures := testutil.ToFloat64(postgreSQLQueryErrors.With(scaler.recorder.errorLabels(class))); failures != count {
+			t.Errorf("Expected %v query errors of SQLSTATE class %s but got %v", count, class, failures)
+		}
+	}
+}
+
+func TestPostgreSQLTargetValueMetric(t *testing.T) {
+	testData := []struct {
+		name     string
+		metadata map[string]string
+		rows     *sqlmock.Rows
+		capacity *sqlmock.Rows
+		before   float64
+		after    float64
+	}{
+		{
+			name:     "target-test",
+			metadata: map[string]string{"query": "SELECT count(*) FROM jobs", "targetQueryValue": "5", "recordTargetValue": "true"},
+			rows:     sqlmock.NewRows([]string{"count"}).AddRow(7),
+			before:   5,
+			after:    5,
+		},
+		{
+			name:     "live-target-test",
+			metadata: map[string]string{"query": "SELECT count(*), 20 FROM jobs", "targetQueryValue": "5", "targetFromQuery": "true", "recordTargetValue": "true"},
+			rows:     sqlmock.NewRows([]string{"count", "target"}).AddRow(7, 20),
+			before:   5,
+			after:    20,
+		},
+		{
+			name:     "capacity-target-test",
+			metadata: map[string]string{"query": "SELECT count(*) FROM jobs", "targetQueryValue": "80%", "capacityQuery": "SELECT workers FROM capacity", "recordTargetValue": "true"},
+			rows:     sqlmock.NewRows([]string{"count"}).AddRow(7),
+			capacity: sqlmock.NewRows([]string{"workers"}).AddRow(10),
+			before:   0,
+			after:    8,
+		},
+		{
+			name:     "no-target-test",
+			metadata: map[string]string{"query": "SELECT count(*) FROM jobs", "targetQueryValue": "5"},
+			rows:     sqlmock.NewRows([]string{"count"}).AddRow(7),
+			before:   0,
+			after:    0,
+		},
+	}
+
+	for _, testData := range testData {
+		scaler, mock := newPostgreSQLMockScaler(t, &ScalerConfig{
+			ScalableObjectName:      testData.name,
+			ScalableObjectNamespace: "default",
+			TriggerMetadata:         testData.metadata,
+			AuthParams:              map[string]string{"connection": "host=localhost"},
+		})
+		labels := scaler.recorder.labels
+		if value := testutil.ToFloat64(postgreSQLTargetValues.With(labels)); value != testData.before {
+			t.Errorf("%s: expected target %v after creation but got %v", testData.name, testData.before, value)
+		}
+
+		mock.ExpectQuery("SELECT count").WillReturnRows(testData.rows)
+		if testData.capacity != nil {
+			mock.ExpectQuery("SELECT workers").WillReturnRows(testData.capacity)
+		}
+		if _, err := scaler.GetMetrics(context.Background(), "s0-postgresql"); err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+		if value := testutil.ToFloat64(postgreSQLTargetValues.With(labels)); value != testData.after {
+			t.Errorf("%s: expected target %v but got %v", testData.name, testData.after, value)
+		}
+	}
+}
+
+func TestPostgreSQLScalerInfoMetric(t *testing.T) {
+	scaler, mock := newPostgreSQLMockScaler(t, &ScalerConfig{
+		ScalableObjectName:      "info-test",
+		ScalableObjectNamespace: "default",
+		TriggerMetadata:         map[string]string{"metricMode": "age", "query": "SELECT min(created_at) FROM jobs", "targetQueryValue": "300"},
+		AuthParams:              map[string]string{"connection": "host=db.internal user=keda password=secret"},
+		MetricType:              "Value",
+	})
+
+	labels := prometheus.Labels{
+		"namespace":        "default",
+		"scaledObject":     "info-test",
+		"metric":           "s0-postgresql",
+		"metricMode":       "age",
+		"metricType":       "Value",
+		"targetQueryValue": "300",
+	}
+	if value := testutil.ToFloat64(postgreSQLScalerInfo.With(labels)); value != 1 {
+		t.Errorf("Expected info gauge 1 but got %v", value)
+	}
+	// neither the connection nor the query are exported
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(postgreSQLScalerInfo)
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if strings.Contains(label.GetValue(), "secret") || strings.Contains(label.GetValue(), "db.internal") || strings.Contains(label.GetValue(), "jobs") {
+					t.Errorf("Expected no sensitive data in labels but got %s=%s", label.GetName(), label.GetValue())
+				}
+			}
+		}
+	}
+
+	mock.ExpectClose()
+	if err := scaler.Close(context.Background()); err != nil {
+		t.Fatal("Unexpected error closing scaler:", err)
+	}
+	if postgreSQLScalerInfo.Delete(labels) {
+		t.Error("Expected the info series to be removed when the scaler is closed")
+	}
+}
+
+func TestPostgreSQLScalerMetricsDeletedOnClose(t *testing.T) {
+	config := &ScalerConfig{
+		ScalableObjectName:      "close-metrics-test",
+		ScalableObjectNamespace: "default",
+		TriggerMetadata:         map[string]string{"query": "SELECT count(*) FROM jobs", "targetQueryValue": "5"},
+		AuthParams:              map[string]string{"connection": "host=localhost"},
+	}
+	scaler, mock := newPostgreSQLMockScaler(t, config)
+	series := func() int {
+		t.Helper()
+		registry := prometheus.NewRegistry()
+		for _, collector := range postgreSQLLabeledMetrics {
+			registry.MustRegister(collector)
+		}
+		families, err := registry.Gather()
+		if err != nil {
+			t.Fatal(err)
+		}
+		count := 0
+		for _, family := range families {
+			for _, metric := range family.GetMetric() {
+				for _, label := range metric.GetLabel() {
+					if label.GetName() == "scaledObject" && label.GetValue() == "close-metrics-test" {
+						count++
+					}
+				}
+			}
+		}
+		return count
+	}
+
+	mock.ExpectQuery("SELECT count").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(7))
+	if _, err := scaler.getActiveNumber(context.Background()); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	mock.ExpectQuery("SELECT count").WillReturnError(errors.New("connection refused"))
+	_, _ = scaler.getActiveNumber(context.Background())
+	if count := series(); count == 0 {
+		t.Fatal("Expected series of the scaler before closing it")
+	}
+
+	// the scalers cache creates the replacement before closing the failed scaler, which keeps the series
+	replacement, replacementMock := newPostgreSQLMockScaler(t, config)
+	mock.ExpectClose()
+	if err := scaler.Close(context.Background()); err != nil {
+		t.Fatal("Unexpected error closing scaler:", err)
+	}
+	if count := series(); count == 0 {
+		t.Error("Expected the series of the replacement scaler to be kept")
+	}
+
+	replacementMock.ExpectClose()
+	if err := replacement.Close(context.Background()); err != nil {
+		t.Fatal("Unexpected error closing scaler:", err)
+	}
+	if count := series(); count != 0 {
+		t.Errorf("Expected no series of the closed scalers but got %d", count)
+	}
+}
+
+type postgreSQLMetricsPrefixTestData struct {
+	name     string
+	env      map[string]string
+	expected string
+}
+
+var testPostgreSQLMetricsPrefixes = []postgreSQLMetricsPrefixTestData{
+	{name: "default", env: map[string]string{}, expected: "keda_postgresql_scaler_query_value"},
+	{name: "subsystem", env: map[string]string{"KEDA_POSTGRESQL_METRICS_SUBSYSTEM": "pg"}, expected: "keda_pg_query_value"},
+	{
+		name:     "namespace and subsystem",
+		env:      map[string]string{"KEDA_POSTGRESQL_METRICS_NAMESPACE": "platform", "KEDA_POSTGRESQL_METRICS_SUBSYSTEM": "autoscaler_pg"},
+		expected: "platform_autoscaler_pg_query_value",
+	},
+	{
+		name:     "invalid subsystem",
+		env:      map[string]string{"KEDA_POSTGRESQL_METRICS_SUBSYSTEM": "postgresql-scaler"},
+		expected: "keda_postgresql_scaler_query_value",
+	},
+}
+
+func TestPostgreSQLMetricsPrefix(t *testing.T) {
+	for _, testData := range testPostgreSQLMetricsPrefixes {
+		namespace, subsystem := getPostgreSQLMetricsPrefix(func(key string) string { return testData.env[key] })
+		if name := prometheus.BuildFQName(namespace, subsystem, "query_value"); name != testData.expected {
+			t.Errorf("%s: expected metric name %s but got %s", testData.name, testData.expected, name)
+		}
+	}
+
+	// the tests run without the environment variables, so the metrics have the default names
+	if desc := postgreSQLQueryValues.WithLabelValues("default", "name", "metric").Desc().String(); !strings.Contains(desc, `"keda_postgresql_scaler_query_value"`) {
+		t.Errorf("Expected the default metric name but got %s", desc)
+	}
+	postgreSQLQueryValues.DeleteLabelValues("default", "name", "metric")
+}
+
+func TestPostgreSQLThresholdCrossingsMetric(t *testing.T) {
+	scaler, mock := newPostgreSQLMockScaler(t, &ScalerConfig{
+		ScalableObjectName:      "threshold-crossings-test",
+		ScalableObjectNamespace: "default",
+		TriggerMetadata:         map[string]string{"query": "SELECT count(*) FROM jobs", "targetQueryValue": "10"},
+		AuthParams:              map[string]string{"connection": "host=localhost"},
+	})
+	breaches := postgreSQLThresholdCrossings.With(scaler.recorder.labelsWith("direction", postgreSQLThresholdBreach))
+	clears := postgreSQLThresholdCrossings.With(scaler.recorder.labelsWith("direction", postgreSQLThresholdClear))
+
+	testData := []struct {
+		value    int
+		breaches float64
+		clears   float64
+	}{
+		{value: 4},
+		{value: 25, breaches: 1},
+		{value: 30, breaches: 1},
+		{value: 8, breaches: 1, clears: 1},
+		{value: 11, breaches: 2, clears: 1},
+	}
+	for _, testData := range testData {
+		mock.ExpectQuery("SELECT count").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(testData.value))
+		if _, err := scaler.GetMetrics(context.Background(), "s0-postgresql"); err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+		if value := testutil.ToFloat64(breaches); value != testData.breaches {
+			t.Errorf("value %d: expected %v breaches but got %v", testData.value, testData.breaches, value)
+		}
+		if value := testutil.ToFloat64(clears); value != testData.clears {
+			t.Errorf("value %d: expected %v clears but got %v", testData.value, testData.clears, value)
+		}
+	}
+}
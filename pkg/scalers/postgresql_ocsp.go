@@ -0,0 +1,105 @@
+package scalers
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+// postgreSQLRevocationCheckOCSP requires an OCSP response with the status good for the server certificate
+const postgreSQLRevocationCheckOCSP = "ocsp"
+
+// postgreSQLOCSPRequestTimeout bounds the request to the OCSP responder, it's part of every TLS handshake
+const postgreSQLOCSPRequestTimeout = 10 * time.Second
+
+// maxPostgreSQLOCSPResponseSize limits the response read from the OCSP responder
+const maxPostgreSQLOCSPResponseSize = 1 << 20
+
+// parsePostgreSQLOCSPMetadata parses the sslRevocationCheck of the server certificate
+func parsePostgreSQLOCSPMetadata(config *ScalerConfig, meta *postgreSQLMetadata) error {
+	val, ok := config.TriggerMetadata["sslRevocationCheck"]
+	if !ok || val == "" {
+		return nil
+	}
+	if val != postgreSQLRevocationCheckOCSP {
+		return fmt.Errorf("unknown sslRevocationCheck %s, must be %s", val, postgreSQLRevocationCheckOCSP)
+	}
+	params, err := parsePostgreSQLConnectionString(meta.connection)
+	if err != nil {
+		return fmt.Errorf("error parsing connection for sslRevocationCheck: %s", err)
+	}
+	// without verification there is no chain to check the revocation of
+	if params["sslmode"] != "verify-full" {
+		return fmt.Errorf("sslRevocationCheck requires sslmode verify-full, got %q", params["sslmode"])
+	}
+	meta.sslRevocationCheck = val
+	return nil
+}
+
+// postgreSQLOCSPVerifier checks the revocation status of the server certificate after the chain was verified.
+// The response stapled by the server is used if there is one, otherwise the responder of the certificate is
+// asked. It fails closed: a status which can't be determined fails the handshake like a revoked certificate
+type postgreSQLOCSPVerifier struct {
+	client *http.Client
+}
+
+func newPostgreSQLOCSPVerifier() *postgreSQLOCSPVerifier {
+	return &postgreSQLOCSPVerifier{client: &http.Client{Timeout: postgreSQLOCSPRequestTimeout}}
+}
+
+// verifyConnection implements tls.Config.VerifyConnection
+func (v *postgreSQLOCSPVerifier) verifyConnection(state tls.ConnectionState) error {
+	if len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) < 2 {
+		return fmt.Errorf("OCSP check requires a verified certificate chain")
+	}
+	leaf, issuer := state.VerifiedChains[0][0], state.VerifiedChains[0][1]
+
+	raw := state.OCSPResponse
+	if len(raw) == 0 {
+		var err error
+		if raw, err = v.queryResponder(leaf, issuer); err != nil {
+			return err
+		}
+	}
+	response, err := ocsp.ParseResponseForCert(raw, leaf, issuer)
+	if err != nil {
+		return fmt.Errorf("error parsing OCSP response: %s", err)
+	}
+	if !response.NextUpdate.IsZero() && time.Now().After(response.NextUpdate) {
+		return fmt.Errorf("OCSP response for certificate %s expired at %s", leaf.SerialNumber, response.NextUpdate)
+	}
+	switch response.Status {
+	case ocsp.Good:
+		return nil
+	case ocsp.Revoked:
+		return fmt.Errorf("server certificate %s was revoked at %s", leaf.SerialNumber, response.RevokedAt)
+	default:
+		return fmt.Errorf("OCSP status of server certificate %s is unknown", leaf.SerialNumber)
+	}
+}
+
+// queryResponder asks the first OCSP responder of the certificate for its status
+func (v *postgreSQLOCSPVerifier) queryResponder(leaf, issuer *x509.Certificate) ([]byte, error) {
+	if len(leaf.OCSPServer) == 0 {
+		return nil, fmt.Errorf("server certificate %s has no stapled OCSP response and no OCSP responder", leaf.SerialNumber)
+	}
+	request, err := ocsp.CreateRequest(leaf, issuer, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating OCSP request: %s", err)
+	}
+	response, err := v.client.Post(leaf.OCSPServer[0], "application/ocsp-request", bytes.NewReader(request))
+	if err != nil {
+		return nil, fmt.Errorf("error querying OCSP responder: %s", err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("OCSP responder %s returned status %d", leaf.OCSPServer[0], response.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(response.Body, maxPostgreSQLOCSPResponseSize))
+}
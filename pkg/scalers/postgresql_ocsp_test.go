@@ -0,0 +1,248 @@
+package scalers
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+type postgreSQLTestOCSPAuthority struct {
+	caCert *x509.Certificate
+	caKey  crypto.Signer
+	caPath string
+}
+
+var testPostgreSQLOCSPMetadata = []parsePostgresMetadataTestData{
+	// sslRevocationCheck ocsp with verify-full
+	{
+		metadata:    map[string]string{"query": "test_query", "targetQueryValue": "5", "sslRevocationCheck": "ocsp"},
+		authParams:  map[string]string{"connection": "host=db.example.com sslmode=verify-full"},
+		resolvedEnv: map[string]string{},
+		raisesError: false,
+	},
+	// sslRevocationCheck without verify-full
+	{
+		metadata:    map[string]string{"query": "test_query", "targetQueryValue": "5", "sslRevocationCheck": "ocsp"},
+		authParams:  map[string]string{"connection": "host=db.example.com sslmode=require"},
+		resolvedEnv: map[string]string{},
+		raisesError: true,
+	},
+	// unknown sslRevocationCheck
+	{
+		metadata:    map[string]string{"query": "test_query", "targetQueryValue": "5", "sslRevocationCheck": "crl"},
+		authParams:  map[string]string{"connection": "host=db.example.com sslmode=verify-full"},
+		resolvedEnv: map[string]string{},
+		raisesError: true,
+	},
+}
+
+func TestParsePostgreSQLOCSPMetadata(t *testing.T) {
+	testParsePostgreSQLMetadata(t, testPostgreSQLOCSPMetadata)
+}
+
+func newPostgreSQLTestOCSPAuthority(t *testing.T) *postgreSQLTestOCSPAuthority {
+	t.Helper()
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caCert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caPath := filepath.Join(t.TempDir(), "ca.crt")
+	if err := os.WriteFile(caPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return &postgreSQLTestOCSPAuthority{caCert: caCert, caKey: caKey, caPath: caPath}
+}
+
+// issue creates a server certificate for dnsName which names responderURL as its OCSP responder
+func (a *postgreSQLTestOCSPAuthority) issue(t *testing.T, dnsName, responderURL string) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: dnsName},
+		DNSNames:     []string{dnsName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	if responderURL != "" {
+		template.OCSPServer = []string{responderURL}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, a.caCert, &key.PublicKey, a.caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
+
+// response signs an OCSP response with the given status for the certificate
+func (a *postgreSQLTestOCSPAuthority) response(t *testing.T, leaf *x509.Certificate, status int) []byte {
+	t.Helper()
+	template := ocsp.Response{
+		Status:       status,
+		SerialNumber: leaf.SerialNumber,
+		ThisUpdate:   time.Now().Add(-time.Minute),
+		NextUpdate:   time.Now().Add(time.Hour),
+	}
+	if status == ocsp.Revoked {
+		template.RevokedAt = time.Now().Add(-time.Minute)
+	}
+	raw, err := ocsp.CreateResponse(a.caCert, a.caCert, template, a.caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+// startResponder serves OCSP responses with the status returned by status, a negative status fails the request
+func (a *postgreSQLTestOCSPAuthority) startResponder(t *testing.T, status func() int) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		request, err := ocsp.ParseRequest(body)
+		if err != nil || status() < 0 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		raw, err := ocsp.CreateResponse(a.caCert, a.caCert, ocsp.Response{
+			Status:       status(),
+			SerialNumber: request.SerialNumber,
+			ThisUpdate:   time.Now().Add(-time.Minute),
+			NextUpdate:   time.Now().Add(time.Hour),
+			RevokedAt:    time.Now().Add(-time.Minute),
+		}, a.caKey)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/ocsp-response")
+		_, _ = w.Write(raw)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestPostgreSQLOCSPVerifier(t *testing.T) {
+	authority := newPostgreSQLTestOCSPAuthority(t)
+	responderStatus := ocsp.Good
+	responder := authority.startResponder(t, func() int { return responderStatus })
+	withResponder := authority.issue(t, "db.example.com", responder.URL).Leaf
+	withoutResponder := authority.issue(t, "db.example.com", "").Leaf
+
+	testData := []struct {
+		name            string
+		leaf            *x509.Certificate
+		staple          int
+		responderStatus int
+		raisesError     bool
+	}{
+		{name: "stapled good", leaf: withoutResponder, staple: ocsp.Good, raisesError: false},
+		{name: "stapled revoked", leaf: withResponder, staple: ocsp.Revoked, responderStatus: ocsp.Good, raisesError: true},
+		{name: "stapled unknown", leaf: withoutResponder, staple: ocsp.Unknown, raisesError: true},
+		{name: "responder good", leaf: withResponder, staple: -1, responderStatus: ocsp.Good, raisesError: false},
+		{name: "responder revoked", leaf: withResponder, staple: -1, responderStatus: ocsp.Revoked, raisesError: true},
+		{name: "responder unavailable", leaf: withResponder, staple: -1, responderStatus: -1, raisesError: true},
+		{name: "no staple and no responder", leaf: withoutResponder, staple: -1, raisesError: true},
+	}
+
+	verifier := newPostgreSQLOCSPVerifier()
+	for _, testData := range testData {
+		responderStatus = testData.responderStatus
+		state := tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{testData.leaf, authority.caCert}}}
+		if testData.staple >= 0 {
+			state.OCSPResponse = authority.response(t, testData.leaf, testData.staple)
+		}
+		err := verifier.verifyConnection(state)
+		if err != nil && !testData.raisesError {
+			t.Errorf("%s: expected success but got error %s", testData.name, err)
+		}
+		if err == nil && testData.raisesError {
+			t.Errorf("%s: expected error but got success", testData.name)
+		}
+	}
+}
+
+func TestPostgreSQLOCSPVerifierWithoutChain(t *testing.T) {
+	if err := newPostgreSQLOCSPVerifier().verifyConnection(tls.ConnectionState{}); err == nil {
+		t.Error("Expected error without a verified chain but got success")
+	}
+}
+
+func TestPostgreSQLTLSDialerOCSPStaple(t *testing.T) {
+	authority := newPostgreSQLTestOCSPAuthority(t)
+
+	testData := []struct {
+		status      int
+		raisesError bool
+	}{
+		{status: ocsp.Good, raisesError: false},
+		{status: ocsp.Revoked, raisesError: true},
+	}
+
+	for _, testData := range testData {
+		cert := authority.issue(t, "db.example.com", "")
+		cert.OCSPStaple = authority.response(t, cert.Leaf, testData.status)
+		address := startPostgreSQLTestTLSServer(t, cert, nil)
+
+		config, err := newPostgreSQLTLSConfig(map[string]string{"sslrootcert": authority.caPath}, "db.example.com", "")
+		if err != nil {
+			t.Fatal("Could not create TLS config:", err)
+		}
+		config.VerifyConnection = newPostgreSQLOCSPVerifier().verifyConnection
+		dialer := &postgreSQLTLSDialer{config: config}
+		conn, err := dialer.DialTimeout("tcp", address, 5*time.Second)
+		if err != nil && !testData.raisesError {
+			t.Errorf("Expected success dialing with OCSP status %d but got error %s", testData.status, err)
+		}
+		if err == nil {
+			conn.Close()
+			if testData.raisesError {
+				t.Errorf("Expected error dialing with OCSP status %d but got success", testData.status)
+			}
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package scalers
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// parsePostgreSQLParametersMetadata binds the workload parameters to the named parameters of the query
+// with bindWorkloadParameters
+func parsePostgreSQLParametersMetadata(config *ScalerConfig, meta *postgreSQLMetadata) error {
+	val, ok := config.TriggerMetadata["bindWorkloadParameters"]
+	if !ok {
+		return nil
+	}
+	bindWorkloadParameters, err := strconv.ParseBool(val)
+	if err != nil {
+		return fmt.Errorf("bindWorkloadParameters parsing error %s", err.Error())
+	}
+	if !bindWorkloadParameters {
+		return nil
+	}
+	if !isPostgreSQLQueryMetricMode(meta.metricMode) {
+		return fmt.Errorf("bindWorkloadParameters can't be used with metricMode %s", meta.metricMode)
+	}
+	if meta.queryFile != "" {
+		// the reloaded query wouldn't be bound
+		return fmt.Errorf("bindWorkloadParameters can't be used with queryFile")
+	}
+	meta.query, meta.queryArgs, err = bindPostgreSQLNamedParameters(meta.query, getPostgreSQLWorkloadParameters(config))
+	if err != nil {
+		return fmt.Errorf("bindWorkloadParameters error %s", err.Error())
+	}
+	return nil
+}
+
+// getPostgreSQLWorkloadParameters returns the values which can be referenced as $<name> in the query
+// with bindWorkloadParameters
+func getPostgreSQLWorkloadParameters(config *ScalerConfig) map[string]string {
+	return map[string]string{
+		"namespace":          config.ScalableObjectNamespace,
+		"scalableObjectName": config.ScalableObjectName,
+		"scalableObjectType": config.ScalableObjectType,
+		"triggerName":        config.TriggerName,
+	}
+}
+
+// bindPostgreSQLNamedParameters rewrites the $<name> placeholders of the query into positional
+// parameters and returns the matching arguments, so the values are never interpolated into the SQL.
+// String literals, quoted identifiers, dollar quoted strings and comments are left untouched
+func bindPostgreSQLNamedParameters(query string, parameters map[string]string) (string, []interface{}, error) {
+	runes := []rune(query)
+	var result strings.Builder
+	var args []interface{}
+	positions := map[string]int{}
+
+	isIdentifierStart := func(r rune) bool { return r == '_' || unicode.IsLetter(r) }
+	isIdentifierPart := func(r rune) bool { return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) }
+	// copyUntil copies the runes from i up to and including the end marker, returning the next position
+	copyUntil := func(i int, end string, what string) (int, error) {
+		marker := []rune(end)
+		for j := i; j+len(marker) <= len(runes); j++ {
+			if string(runes[j:j+len(marker)]) == end {
+				result.WriteString(string(runes[i : j+len(marker)]))
+				return j + len(marker), nil
+			}
+		}
+		return 0, fmt.Errorf("unterminated %s in query", what)
+	}
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		var err error
+		switch {
+		case r == '\'':
+			escaped := i > 0 && (runes[i-1] == 'E' || runes[i-1] == 'e') && (i == 1 || !isIdentifierPart(runes[i-2]))
+			j := i + 1
+			for ; j < len(runes); j++ {
+				if escaped && runes[j] == '\\' {
+					j++
+					continue
+				}
+				if runes[j] == '\'' {
+					if j+1 < len(runes) && runes[j+1] == '\'' {
+						j++
+						continue
+					}
+					break
+				}
+			}
+			if j >= len(runes) {
+				return "", nil, fmt.Errorf("unterminated string literal in query")
+			}
+			result.WriteString(string(runes[i : j+1]))
+			i = j + 1
+		case r == '"':
+			result.WriteRune(r)
+			i, err = copyUntil(i+1, `"`, "quoted identifier")
+		case r == '-' && i+1 < len(runes) && runes[i+1] == '-':
+			if !strings.ContainsRune(string(runes[i:]), '\n') {
+				result.WriteString(string(runes[i:]))
+				i = len(runes)
+			} else {
+				i, err = copyUntil(i, "\n", "comment")
+			}
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			result.WriteString("/*")
+			i, err = copyUntil(i+2, "*/", "comment")
+		case r == '$' && (i == 0 || !isIdentifierPart(runes[i-1])):
+			j := i + 1
+			for j < len(runes) && isIdentifierPart(runes[j]) {
+				j++
+			}
+			name := string(runes[i+1 : j])
+			switch {
+			case j < len(runes) && runes[j] == '$' && (name == "" || isIdentifierStart(runes[i+1])):
+				// dollar quoted string like $$...$$ or $body$...$body$
+				tag := string(runes[i : j+1])
+				result.WriteString(tag)
+				i, err = copyUntil(j+1, tag, "dollar quoted string")
+			case name == "":
+				result.WriteRune(r)
+				i++
+			case !isIdentifierStart(runes[i+1]):
+				return "", nil, fmt.Errorf("positional parameter $%s can't be used with named parameters", name)
+			default:
+				value, ok := parameters[name]
+				if !ok {
+					return "", nil, fmt.Errorf("unknown parameter $%s, must be one of %s", name, strings.Join(getSortedPostgreSQLParameterNames(parameters), ", "))
+				}
+				position, ok := positions[name]
+				if !ok {
+					args = append(args, value)
+					position = len(args)
+					positions[name] = position
+				}
+				result.WriteString(fmt.Sprintf("$%d", position))
+				i = j
+			}
+		default:
+			result.WriteRune(r)
+			i++
+		}
+		if err != nil {
+			return "", nil, err
+		}
+	}
+	return result.String(), args, nil
+}
+
+func getSortedPostgreSQLParameterNames(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
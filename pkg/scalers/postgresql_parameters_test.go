@@ -0,0 +1,133 @@
+package scalers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+type bindPostgreSQLNamedParametersTestData struct {
+	query       string
+	expected    string
+	args        []interface{}
+	raisesError bool
+}
+
+var testPostgreSQLWorkloadParameters = map[string]string{"namespace": "tenant-a", "scalableObjectName": "worker", "scalableObjectType": "ScaledObject", "triggerName": "jobs"}
+
+var testBindPostgreSQLNamedParameters = []bindPostgreSQLNamedParametersTestData{
+	{query: "SELECT count(*) FROM jobs", expected: "SELECT count(*) FROM jobs"},
+	{
+		query:    "SELECT count(*) FROM jobs WHERE tenant = $namespace AND worker = $scalableObjectName",
+		expected: "SELECT count(*) FROM jobs WHERE tenant = $1 AND worker = $2",
+		args:     []interface{}{"tenant-a", "worker"},
+	},
+	{
+		query:    "SELECT count(*) FROM jobs WHERE tenant = $namespace OR owner = $namespace",
+		expected: "SELECT count(*) FROM jobs WHERE tenant = $1 OR owner = $1",
+		args:     []interface{}{"tenant-a"},
+	},
+	{
+		query:    "SELECT count(*) FROM jobs WHERE kind=$scalableObjectType::text AND trigger = $triggerName",
+		expected: "SELECT count(*) FROM jobs WHERE kind=$1::text AND trigger = $2",
+		args:     []interface{}{"ScaledObject", "jobs"},
+	},
+	// placeholders in literals, identifiers and comments are kept
+	{
+		query:    "SELECT count(*) FROM \"$namespace\" WHERE note = 'costs $namespace' AND tenant = $namespace -- $triggerName\n",
+		expected: "SELECT count(*) FROM \"$namespace\" WHERE note = 'costs $namespace' AND tenant = $1 -- $triggerName\n",
+		args:     []interface{}{"tenant-a"},
+	},
+	{
+		query:    "SELECT count(*) FROM jobs WHERE note = 'it''s $namespace' /* $triggerName */ AND tenant = $namespace",
+		expected: "SELECT count(*) FROM jobs WHERE note = 'it''s $namespace' /* $triggerName */ AND tenant = $1",
+		args:     []interface{}{"tenant-a"},
+	},
+	{
+		query:    "SELECT count(*) FROM jobs WHERE note = E'\\' $namespace' AND tenant = $namespace",
+		expected: "SELECT count(*) FROM jobs WHERE note = E'\\' $namespace' AND tenant = $1",
+		args:     []interface{}{"tenant-a"},
+	},
+	{
+		query:    "SELECT count(*) FROM jobs WHERE note = $$ $namespace $$ AND body = $tag$ $triggerName $tag$ AND tenant = $namespace",
+		expected: "SELECT count(*) FROM jobs WHERE note = $$ $namespace $$ AND body = $tag$ $triggerName $tag$ AND tenant = $1",
+		args:     []interface{}{"tenant-a"},
+	},
+	// errors
+	{query: "SELECT count(*) FROM jobs WHERE tenant = $tenant", raisesError: true},
+	{query: "SELECT count(*) FROM jobs WHERE tenant = $1", raisesError: true},
+	{query: "SELECT count(*) FROM jobs WHERE note = 'unterminated", raisesError: true},
+	{query: "SELECT count(*) FROM jobs WHERE note = $$ unterminated", raisesError: true},
+	{query: "SELECT count(*) FROM jobs /* unterminated", raisesError: true},
+}
+
+var testPostgreSQLParametersMetadata = []parsePostgresMetadataTestData{
+	// bindWorkloadParameters with an unknown parameter
+	{
+		metadata:    map[string]string{"query": "SELECT count(*) FROM jobs WHERE tenant = $tenant", "targetQueryValue": "12", "bindWorkloadParameters": "true"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		resolvedEnv: map[string]string{},
+		raisesError: true,
+	},
+	// bindWorkloadParameters with connectionSaturation
+	{
+		metadata:    map[string]string{"metricMode": "connectionSaturation", "targetQueryValue": "0.8", "bindWorkloadParameters": "true"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		resolvedEnv: map[string]string{},
+		raisesError: true,
+	},
+}
+
+func TestParsePostgreSQLParametersMetadata(t *testing.T) {
+	testParsePostgreSQLMetadata(t, testPostgreSQLParametersMetadata)
+}
+
+func TestBindPostgreSQLNamedParameters(t *testing.T) {
+	for _, testData := range testBindPostgreSQLNamedParameters {
+		query, args, err := bindPostgreSQLNamedParameters(testData.query, testPostgreSQLWorkloadParameters)
+		if err != nil && !testData.raisesError {
+			t.Errorf("Expected success binding %q but got error %s", testData.query, err)
+		}
+		if err == nil && testData.raisesError {
+			t.Errorf("Expected error binding %q but got success", testData.query)
+		}
+		if err != nil {
+			continue
+		}
+		if query != testData.expected {
+			t.Errorf("Expected query %q binding %q but got %q", testData.expected, testData.query, query)
+		}
+		if !reflect.DeepEqual(args, testData.args) {
+			t.Errorf("Expected args %v binding %q but got %v", testData.args, testData.query, args)
+		}
+	}
+}
+
+func TestPostgreSQLScalerBindWorkloadParameters(t *testing.T) {
+	scaler, mock := newPostgreSQLMockScaler(t, &ScalerConfig{
+		ScalableObjectName:      "worker",
+		ScalableObjectNamespace: "tenant-a",
+		TriggerMetadata: map[string]string{
+			"query":                  "SELECT count(*) FROM jobs WHERE tenant = $namespace AND worker = $scalableObjectName",
+			"targetQueryValue":       "5",
+			"bindWorkloadParameters": "true",
+		},
+		AuthParams: map[string]string{"connection": "host=localhost"},
+	})
+
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM jobs WHERE tenant = \\$1 AND worker = \\$2").
+		WithArgs("tenant-a", "worker").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	value, err := scaler.getActiveNumber(context.Background())
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if value != 3 {
+		t.Errorf("Expected 3 but got %v", value)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
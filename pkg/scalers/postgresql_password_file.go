@@ -0,0 +1,101 @@
+package scalers
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// parsePostgreSQLPasswordFileMetadata replaces the password of the connection with the one of passwordFromFile,
+// which is watched for rotations
+func parsePostgreSQLPasswordFileMetadata(config *ScalerConfig, meta *postgreSQLMetadata) error {
+	path, ok := config.TriggerMetadata["passwordFromFile"]
+	if !ok || path == "" {
+		return nil
+	}
+	if meta.credentialProvider != postgreSQLCredentialProviderStatic {
+		return fmt.Errorf("passwordFromFile can't be used with credentialProvider %s", meta.credentialProvider)
+	}
+	params, err := parsePostgreSQLConnectionString(meta.connection)
+	if err != nil {
+		return fmt.Errorf("error parsing connection for passwordFromFile: %s", err)
+	}
+	password, modTime, err := readPostgreSQLPasswordFile(path)
+	if err != nil {
+		return fmt.Errorf("passwordFromFile error %s", err.Error())
+	}
+	params["password"] = password
+	meta.connection = formatPostgreSQLConnectionString(params)
+	meta.passwordFile, meta.passwordFileModTime = path, modTime
+	return nil
+}
+
+// readPostgreSQLPasswordFile returns the password in path and the modification time it was read at.
+// Only the trailing line break is removed, other whitespace may be part of the password
+func readPostgreSQLPasswordFile(path string) (string, time.Time, error) {
+	// os.Stat follows symlinks, so updates of mounted volumes are detected too
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	password := strings.TrimRight(string(content), "\r\n")
+	if password == "" {
+		return "", time.Time{}, fmt.Errorf("passwordFromFile %s is empty", path)
+	}
+	return password, info.ModTime(), nil
+}
+
+// refreshConnectionOnPasswordFileChange switches to a connection using the password of the passwordFromFile
+// when the file changed, e.g. after Vault agent rotated it. Like the TLS files it's checked before every query,
+// a file which can't be read in the middle of the rotation is checked again next time
+func (s *postgreSQLScaler) refreshConnectionOnPasswordFileChange(ctx context.Context) error {
+	if s.metadata.passwordFile == "" {
+		return nil
+	}
+	s.reconnectMutex.Lock()
+	defer s.reconnectMutex.Unlock()
+	s.mutex.Lock()
+	passwordFileModTime, connectionMetadata := s.passwordFileModTime, s.connectionMetadata
+	s.mutex.Unlock()
+
+	info, err := os.Stat(s.metadata.passwordFile)
+	if err != nil || info.ModTime().Equal(passwordFileModTime) {
+		return nil
+	}
+	password, modTime, err := readPostgreSQLPasswordFile(s.metadata.passwordFile)
+	if err != nil {
+		s.logger.V(1).Info("could not read postgreSQL passwordFromFile, keeping the previous password", "error", err.Error())
+		return nil
+	}
+	params, err := parsePostgreSQLConnectionString(connectionMetadata.connection)
+	if err != nil {
+		return fmt.Errorf("error parsing connection for passwordFromFile: %s", err)
+	}
+	if params["password"] == password {
+		// touched without a new password, e.g. when the volume was resynced
+		s.mutex.Lock()
+		s.passwordFileModTime = modTime
+		s.mutex.Unlock()
+		return nil
+	}
+
+	params["password"] = password
+	connectionMeta := *connectionMetadata
+	connectionMeta.connection = formatPostgreSQLConnectionString(params)
+	conn, err := s.connections.acquire(ctx, &connectionMeta, s.logger)
+	if err != nil {
+		return err
+	}
+	s.swapConnection(conn, func() {
+		s.connectionMetadata = &connectionMeta
+		s.passwordFileModTime = modTime
+	})
+	s.logger.V(1).Info("postgreSQL passwordFromFile changed, reconnecting")
+	return nil
+}
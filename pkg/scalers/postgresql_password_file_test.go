@@ -0,0 +1,196 @@
+package scalers
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// writePostgreSQLPasswordFile writes password to path with the given modification time, as the mod time
+// resolution of the file system may not tell writes within a test apart
+func writePostgreSQLPasswordFile(t *testing.T, path, password string, modTime time.Time) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(password), 0o600); err != nil {
+		t.Fatal("Could not write passwordFromFile:", err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal("Could not set passwordFromFile mod time:", err)
+	}
+}
+
+func TestParsePostgreSQLMetadataPasswordFromFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "password")
+	writePostgreSQLPasswordFile(t, path, "s3cret pass\n", time.Now())
+	empty := filepath.Join(dir, "empty")
+	writePostgreSQLPasswordFile(t, empty, "\n", time.Now())
+
+	testData := []struct {
+		name        string
+		metadata    map[string]string
+		raisesError bool
+	}{
+		{name: "passwordFromFile", metadata: map[string]string{"passwordFromFile": path}},
+		{name: "missing file", metadata: map[string]string{"passwordFromFile": filepath.Join(dir, "missing")}, raisesError: true},
+		{name: "empty file", metadata: map[string]string{"passwordFromFile": empty}, raisesError: true},
+	}
+
+	for _, testData := range testData {
+		t.Run(testData.name, func(t *testing.T) {
+			metadata := map[string]string{"query": "SELECT 1", "targetQueryValue": "5"}
+			for key, value := range testData.metadata {
+				metadata[key] = value
+			}
+			meta, err := parsePostgreSQLMetadata(&ScalerConfig{TriggerMetadata: metadata, AuthParams: map[string]string{"connection": "host=localhost password=initial"}})
+			if err != nil && !testData.raisesError {
+				t.Fatal("Expected success but got error", err)
+			}
+			if err == nil && testData.raisesError {
+				t.Fatal("Expected error but got success")
+			}
+			if err != nil {
+				return
+			}
+			params, err := parsePostgreSQLConnectionString(meta.connection)
+			if err != nil {
+				t.Fatal("Could not parse connection:", err)
+			}
+			if params["password"] != "s3cret pass" {
+				t.Errorf("Expected the password of the file but got %q", params["password"])
+			}
+		})
+	}
+}
+
+func TestPostgreSQLPasswordFromFileRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "password")
+	modTime := time.Now().Add(-time.Hour)
+	writePostgreSQLPasswordFile(t, path, "first", modTime)
+
+	dbs := map[string]*sql.DB{}
+	mocks := map[string]sqlmock.Sqlmock{}
+	for _, password := range []string{"first", "second"} {
+		db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+		if err != nil {
+			t.Fatal("Could not create sqlmock:", err)
+		}
+		mock.ExpectPing()
+		dbs[password], mocks[password] = db, mock
+	}
+	var opened []string
+	pool := newPostgreSQLConnectionPool(func(meta *postgreSQLMetadata) (*sql.DB, error) {
+		params, err := parsePostgreSQLConnectionString(meta.connection)
+		if err != nil {
+			return nil, err
+		}
+		opened = append(opened, params["password"])
+		return dbs[params["password"]], nil
+	}, 0)
+	scaler, err := newPostgreSQLScaler(&ScalerConfig{
+		TriggerMetadata: map[string]string{"query": "SELECT count(*) FROM jobs", "targetQueryValue": "5", "passwordFromFile": path},
+		AuthParams:      map[string]string{"connection": "host=localhost"},
+	}, pool)
+	if err != nil {
+		t.Fatal("Could not create scaler:", err)
+	}
+	defer scaler.Close(context.Background())
+
+	read := func(password string, value int) {
+		t.Helper()
+		mock := mocks[password]
+		mock.ExpectQuery("SELECT count").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(value))
+		got, err := scaler.getActiveNumber(context.Background())
+		if err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+		if got != float64(value) {
+			t.Errorf("Expected %d but got %v", value, got)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	}
+
+	read("first", 1)
+	// touched without a new password
+	modTime = modTime.Add(time.Minute)
+	writePostgreSQLPasswordFile(t, path, "first\n", modTime)
+	read("first", 2)
+	// the rotated password is used for the next query
+	modTime = modTime.Add(time.Minute)
+	writePostgreSQLPasswordFile(t, path, "second", modTime)
+	read("second", 3)
+	// a file removed in the middle of the rotation keeps the connection
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	read("second", 4)
+
+	if len(opened) != 2 {
+		t.Errorf("Expected a connection per password but got %v", opened)
+	}
+}
+
+func TestPostgreSQLPasswordFromFileReconnectWithoutLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "password")
+	modTime := time.Now().Add(-time.Hour)
+	writePostgreSQLPasswordFile(t, path, "first", modTime)
+
+	db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	if err != nil {
+		t.Fatal("Could not create sqlmock:", err)
+	}
+	mock.ExpectPing()
+	mock.ExpectPing()
+	connecting, connect := make(chan struct{}), make(chan struct{})
+	pool := newPostgreSQLConnectionPool(func(meta *postgreSQLMetadata) (*sql.DB, error) {
+		params, err := parsePostgreSQLConnectionString(meta.connection)
+		if err != nil {
+			return nil, err
+		}
+		if params["password"] == "second" {
+			close(connecting)
+			<-connect
+		}
+		return db, nil
+	}, 0)
+	scaler, err := newPostgreSQLScaler(&ScalerConfig{
+		TriggerMetadata: map[string]string{"query": "SELECT count(*) FROM jobs", "targetQueryValue": "5", "passwordFromFile": path},
+		AuthParams:      map[string]string{"connection": "host=localhost"},
+	}, pool)
+	if err != nil {
+		t.Fatal("Could not create scaler:", err)
+	}
+	defer scaler.Close(context.Background())
+
+	writePostgreSQLPasswordFile(t, path, "second", modTime.Add(time.Minute))
+	refreshed := make(chan error)
+	go func() {
+		refreshed <- scaler.refreshConnectionOnPasswordFileChange(context.Background())
+	}()
+	<-connecting
+
+	// the scaler lock is free while connecting
+	locked := make(chan struct{})
+	go func() {
+		scaler.isReady()
+		close(locked)
+	}()
+	select {
+	case <-locked:
+	case <-time.After(time.Second):
+		t.Error("Expected the scaler lock to be free while reconnecting")
+	}
+	close(connect)
+	if err := <-refreshed; err != nil {
+		t.Fatal("Unexpected error reconnecting:", err)
+	}
+	if params, _ := parsePostgreSQLConnectionString(scaler.connectionMetadata.connection); params["password"] != "second" {
+		t.Errorf("Expected the connection of the rotated password but got %s", params["password"])
+	}
+}
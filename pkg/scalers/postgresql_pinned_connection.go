@@ -0,0 +1,78 @@
+package scalers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+)
+
+// parsePostgreSQLPinnedConnectionMetadata parses pinConnection, which runs every read in the same session
+func parsePostgreSQLPinnedConnectionMetadata(config *ScalerConfig, meta *postgreSQLMetadata) error {
+	if val, ok := config.TriggerMetadata["pinConnection"]; ok {
+		pinConnection, err := strconv.ParseBool(val)
+		if err != nil {
+			return fmt.Errorf("pinConnection parsing error %s", err.Error())
+		}
+		meta.pinConnection = pinConnection
+	}
+	return nil
+}
+
+// postgreSQLPinnedConnection is the connection pinConnection holds for the lifetime of the scaler, so
+// session state such as temporary tables and prepared statements lasts from one read to the next
+type postgreSQLPinnedConnection struct {
+	// mutex is held from acquire to release, the reads mustn't interleave in the session
+	mutex sync.Mutex
+	conn  *sql.Conn
+	// db is the handle conn was acquired from, a new handle, e.g. after the TLS files were rotated, gets a new session
+	db *sql.DB
+}
+
+// acquire returns the pinned connection of db, establishing it if there is none yet
+func (p *postgreSQLPinnedConnection) acquire(ctx context.Context, db *sql.DB) (*sql.Conn, error) {
+	p.mutex.Lock()
+	if p.conn != nil && p.db != db {
+		p.conn.Close()
+		p.conn = nil
+	}
+	if p.conn == nil {
+		conn, err := db.Conn(ctx)
+		if err != nil {
+			p.mutex.Unlock()
+			return nil, err
+		}
+		p.conn, p.db = conn, db
+	}
+	return p.conn, nil
+}
+
+// release hands the connection back after a read which failed with err. A broken connection is closed,
+// so the next read reconnects, which starts a new session without the state of the previous one
+func (p *postgreSQLPinnedConnection) release(err error) {
+	defer p.mutex.Unlock()
+	if err != nil && (errors.Is(err, sql.ErrConnDone) || getPostgreSQLErrorCategory(err) == postgreSQLErrorCategoryConnection) {
+		p.conn.Close()
+		p.conn = nil
+	}
+}
+
+// discard drops the connection after a panic of the driver. database/sql keeps it locked, so it isn't closed
+func (p *postgreSQLPinnedConnection) discard() {
+	p.conn = nil
+	p.mutex.Unlock()
+}
+
+// close closes the pinned connection when the scaler is closed
+func (p *postgreSQLPinnedConnection) close() error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.conn == nil {
+		return nil
+	}
+	err := p.conn.Close()
+	p.conn = nil
+	return err
+}
@@ -0,0 +1,136 @@
+package scalers
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+)
+
+var testPostgreSQLPinnedConnectionMetadata = []parsePostgresMetadataTestData{
+	// invalid pinConnection
+	{
+		metadata:    map[string]string{"query": "test_query", "targetQueryValue": "5", "pinConnection": "session"},
+		authParams:  map[string]string{"connection": "test_connection_string"},
+		raisesError: true,
+	},
+}
+
+func TestParsePostgreSQLPinnedConnectionMetadata(t *testing.T) {
+	testParsePostgreSQLMetadata(t, testPostgreSQLPinnedConnectionMetadata)
+}
+
+func TestPostgreSQLPinnedConnection(t *testing.T) {
+	scaler, mock := newPostgreSQLMockScaler(t, &ScalerConfig{
+		TriggerMetadata: map[string]string{"query": "SELECT count\\(\\*\\) FROM pending_jobs", "targetQueryValue": "5", "pinConnection": "true"},
+		AuthParams:      map[string]string{"connection": "host=localhost"},
+	})
+	read := func() {
+		t.Helper()
+		mock.ExpectQuery("pending_jobs").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+		if _, err := scaler.getActiveNumber(context.Background()); err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+	}
+
+	read()
+	pinned := scaler.pinnedConnection.conn
+	if pinned == nil {
+		t.Fatal("Expected a pinned connection after the first read")
+	}
+	// the session, with its temporary tables and prepared statements, is the same for the next reads
+	read()
+	read()
+	if scaler.pinnedConnection.conn != pinned {
+		t.Error("Expected the reads to share the pinned connection")
+	}
+
+	// a failed query keeps the session, a broken connection is replaced
+	mock.ExpectQuery("pending_jobs").WillReturnError(&pq.Error{Code: "42601"})
+	if _, err := scaler.getActiveNumber(context.Background()); err == nil {
+		t.Error("Expected error for a failed query but got success")
+	}
+	if scaler.pinnedConnection.conn != pinned {
+		t.Error("Expected a failed query to keep the pinned connection")
+	}
+	mock.ExpectQuery("pending_jobs").WillReturnError(driver.ErrBadConn)
+	if _, err := scaler.getActiveNumber(context.Background()); err == nil {
+		t.Error("Expected error for a broken connection but got success")
+	}
+	if scaler.pinnedConnection.conn != nil {
+		t.Error("Expected a broken connection to be unpinned")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPostgreSQLPinnedConnectionReconnect(t *testing.T) {
+	pinned := &postgreSQLPinnedConnection{}
+	acquire := func(db *sql.DB) *sql.Conn {
+		t.Helper()
+		conn, err := pinned.acquire(context.Background(), db)
+		if err != nil {
+			t.Fatal("Unexpected error acquiring the pinned connection:", err)
+		}
+		return conn
+	}
+	first, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer first.Close()
+	second, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer second.Close()
+
+	conn := acquire(first)
+	pinned.release(nil)
+	if acquire(first) != conn {
+		t.Error("Expected the reads of a database to share the pinned connection")
+	}
+	pinned.release(nil)
+
+	// a new database handle, e.g. after the TLS files were rotated, gets a new session
+	if acquire(second) == conn {
+		t.Error("Expected a new pinned connection for the new database")
+	}
+	// a broken connection is replaced by the next read
+	pinned.release(driver.ErrBadConn)
+	if pinned.conn != nil {
+		t.Error("Expected the broken connection to be unpinned")
+	}
+	if err := pinned.close(); err != nil {
+		t.Error("Unexpected error closing:", err)
+	}
+}
+
+func TestPostgreSQLPinnedConnectionActivationQuery(t *testing.T) {
+	scaler, mock := newPostgreSQLMockScaler(t, &ScalerConfig{
+		TriggerMetadata: map[string]string{"query": "SELECT count\\(\\*\\) FROM pending_jobs", "targetQueryValue": "5", "pinConnection": "true",
+			"activationQuery": "SELECT enabled FROM feature_flags"},
+		AuthParams: map[string]string{"connection": "host=localhost"},
+	})
+	mock.ExpectQuery("pending_jobs").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	if _, err := scaler.getActiveNumber(context.Background()); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	pinned := scaler.pinnedConnection.conn
+
+	// the activationQuery shares the session of the query
+	mock.ExpectQuery("feature_flags").WillReturnRows(sqlmock.NewRows([]string{"enabled"}).AddRow(true))
+	if _, err := scaler.IsActive(context.Background()); err != nil {
+		t.Fatal("Unexpected error checking activation:", err)
+	}
+	if scaler.pinnedConnection.conn != pinned {
+		t.Error("Expected the activationQuery to run on the pinned connection")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,48 @@
+package scalers
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const maxPostgreSQLMetricPrecision = 3
+
+// parsePostgreSQLPrecisionMetadata parses the metricPrecision the value is rounded to. Without it the value is
+// truncated like by every other scaler
+func parsePostgreSQLPrecisionMetadata(config *ScalerConfig, meta *postgreSQLMetadata) error {
+	if val, ok := config.TriggerMetadata["metricPrecision"]; ok && val != "" {
+		if meta.valueType == postgreSQLValueTypeInteger {
+			return fmt.Errorf("metricPrecision can't be used with valueType %s", postgreSQLValueTypeInteger)
+		}
+		precision, err := parsePostgreSQLMetricPrecision(val)
+		if err != nil {
+			return err
+		}
+		meta.metricPrecision = precision
+		meta.hasMetricPrecision = true
+	}
+	return nil
+}
+
+func parsePostgreSQLMetricPrecision(val string) (int, error) {
+	precision, err := strconv.Atoi(val)
+	if err != nil {
+		return 0, fmt.Errorf("metricPrecision parsing error %s", err.Error())
+	}
+	if precision < 0 || precision > maxPostgreSQLMetricPrecision {
+		return 0, fmt.Errorf("metricPrecision must be between 0 and %d, got %d", maxPostgreSQLMetricPrecision, precision)
+	}
+	return precision, nil
+}
+
+// postgreSQLMilliQuantity rounds value to precision decimal places. Unlike GenerateMetricInMili it rounds
+// the milli value instead of truncating it, so a result such as 2.9999999999999996 of a division is 3, not 2999m.
+// It's only used when metricPrecision is set, otherwise the value is truncated like by every other scaler
+func postgreSQLMilliQuantity(value float64, precision int) resource.Quantity {
+	step := math.Pow10(maxPostgreSQLMetricPrecision - precision)
+	milli := int64(math.Round(value*1000/step) * step)
+	return *resource.NewMilliQuantity(milli, resource.DecimalSI)
+}
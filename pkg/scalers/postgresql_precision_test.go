@@ -0,0 +1,99 @@
+package scalers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+type postgreSQLMilliQuantityTestData struct {
+	value     float64
+	precision int
+	expected  string
+}
+
+var testPostgreSQLMilliQuantity = []postgreSQLMilliQuantityTestData{
+	{value: 3, precision: 3, expected: "3"},
+	// float results just below the value, which truncating turns into 2999m and 289m
+	{value: 0.1 + 0.2, precision: 3, expected: "300m"},
+	{value: 2.9999999999999996, precision: 3, expected: "3"},
+	{value: 0.29, precision: 3, expected: "290m"},
+	{value: 1.5, precision: 3, expected: "1500m"},
+	{value: 2.0 / 3, precision: 3, expected: "667m"},
+	{value: 2.0 / 3, precision: 2, expected: "670m"},
+	{value: 2.0 / 3, precision: 1, expected: "700m"},
+	{value: 2.0 / 3, precision: 0, expected: "1"},
+	{value: 1234.5678, precision: 0, expected: "1235"},
+	{value: 0, precision: 3, expected: "0"},
+	{value: -1.25, precision: 1, expected: "-1300m"},
+}
+
+var testPostgreSQLPrecisionMetadata = []parsePostgresMetadataTestData{
+	// metricPrecision
+	{
+		metadata:   map[string]string{"query": "select 1", "targetQueryValue": "1", "metricPrecision": "1"},
+		authParams: map[string]string{"connection": "test_connection_string"},
+	},
+	// metricPrecision beyond milli
+	{
+		metadata:    map[string]string{"query": "select 1", "targetQueryValue": "1", "metricPrecision": "4"},
+		authParams:  map[string]string{"connection": "test_connection_string"},
+		raisesError: true,
+	},
+	// negative metricPrecision
+	{
+		metadata:    map[string]string{"query": "select 1", "targetQueryValue": "1", "metricPrecision": "-1"},
+		authParams:  map[string]string{"connection": "test_connection_string"},
+		raisesError: true,
+	},
+}
+
+func TestParsePostgreSQLPrecisionMetadata(t *testing.T) {
+	testParsePostgreSQLMetadata(t, testPostgreSQLPrecisionMetadata)
+}
+
+func TestPostgreSQLMilliQuantity(t *testing.T) {
+	for _, testData := range testPostgreSQLMilliQuantity {
+		quantity := postgreSQLMilliQuantity(testData.value, testData.precision)
+		if quantity.String() != testData.expected {
+			t.Errorf("Expected %s for %v with precision %d but got %s", testData.expected, testData.value, testData.precision, quantity.String())
+		}
+	}
+}
+
+func TestPostgreSQLMetricPrecision(t *testing.T) {
+	scaler, mock := newPostgreSQLMockScaler(t, &ScalerConfig{
+		TriggerMetadata: map[string]string{"query": "SELECT avg(duration) FROM jobs", "targetQueryValue": "5", "metricPrecision": "1"},
+		AuthParams:      map[string]string{"connection": "host=localhost"},
+	})
+	mock.ExpectQuery("SELECT avg").WillReturnRows(sqlmock.NewRows([]string{"avg"}).AddRow("2.96"))
+	metrics, err := scaler.GetMetrics(context.Background(), "s0-postgresql")
+	if err != nil {
+		t.Fatal("Unexpected error getting metrics:", err)
+	}
+	if metrics[0].Value.String() != "3" {
+		t.Errorf("Expected metric value 3 but got %s", metrics[0].Value.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPostgreSQLMetricPrecisionUnsetTruncates(t *testing.T) {
+	scaler, mock := newPostgreSQLMockScaler(t, &ScalerConfig{
+		TriggerMetadata: map[string]string{"query": "SELECT avg(duration) FROM jobs", "targetQueryValue": "5"},
+		AuthParams:      map[string]string{"connection": "host=localhost"},
+	})
+	mock.ExpectQuery("SELECT avg").WillReturnRows(sqlmock.NewRows([]string{"avg"}).AddRow("1.2346"))
+	metrics, err := scaler.GetMetrics(context.Background(), "s0-postgresql")
+	if err != nil {
+		t.Fatal("Unexpected error getting metrics:", err)
+	}
+	if metrics[0].Value.String() != "1234m" {
+		t.Errorf("Expected metric value 1234m but got %s", metrics[0].Value.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
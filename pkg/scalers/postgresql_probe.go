@@ -0,0 +1,93 @@
+package scalers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// stages of ProbePostgreSQL, reported by PostgreSQLProbeError
+const (
+	// PostgreSQLProbeStageMetadata is the parsing of the trigger metadata
+	PostgreSQLProbeStageMetadata = "metadata"
+	// PostgreSQLProbeStageConnect is establishing the connection, including resolving the credentials
+	PostgreSQLProbeStageConnect = "connect"
+	// PostgreSQLProbeStageQuery is running the query and computing the value
+	PostgreSQLProbeStageQuery = "query"
+)
+
+// postgreSQLProbeIgnoredMetadataKeys are options which would make the probe wait, share or hide the result
+// of its query. onError and onErrorByCategory would return a fallback instead of the error which is looked for
+var postgreSQLProbeIgnoredMetadataKeys = []string{"firstQueryJitter", "sharedPollingInterval", "notifyChannel", "onError", "onErrorByCategory", "maxStaleness", "treatErrorAsZeroSqlStates"}
+
+// PostgreSQLProbeResult is the outcome of a successful ProbePostgreSQL
+type PostgreSQLProbeResult struct {
+	// Value is the metric value the trigger would report
+	Value float64
+	// ConnectDuration is the time it took to resolve the credentials, connect and ping the database
+	ConnectDuration time.Duration
+	// QueryDuration is the time it took to run the query and compute the value
+	QueryDuration time.Duration
+}
+
+// PostgreSQLProbeError is the error of a failed ProbePostgreSQL, telling the stage which failed.
+// The wrapped error implements ConditionReasonError once the database was involved, its reason
+// tells a failed authentication from a failed connection
+type PostgreSQLProbeError struct {
+	Stage string
+	err   error
+}
+
+func (e *PostgreSQLProbeError) Error() string {
+	return fmt.Sprintf("postgreSQL probe failed at %s: %s", e.Stage, e.err)
+}
+
+func (e *PostgreSQLProbeError) Unwrap() error {
+	return e.err
+}
+
+// ProbePostgreSQL validates a PostgreSQL trigger without deploying a ScaledObject. It connects with
+// config, runs the query once and returns the value with the time each step took. The probe uses
+// its own connection, so it doesn't interfere with the scalers of the operator
+func ProbePostgreSQL(ctx context.Context, config *ScalerConfig) (PostgreSQLProbeResult, error) {
+	return probePostgreSQL(ctx, config, newPostgreSQLConnectionPool(openPostgreSQLConnection, 0))
+}
+
+func probePostgreSQL(ctx context.Context, config *ScalerConfig, connections *postgreSQLConnectionPool) (PostgreSQLProbeResult, error) {
+	var result PostgreSQLProbeResult
+	if _, err := parsePostgreSQLMetadata(config); err != nil {
+		return result, &PostgreSQLProbeError{Stage: PostgreSQLProbeStageMetadata, err: fmt.Errorf("error parsing postgreSQL metadata: %s", err)}
+	}
+
+	probeConfig := *config
+	probeConfig.TriggerMetadata = map[string]string{}
+	for key, value := range config.TriggerMetadata {
+		probeConfig.TriggerMetadata[key] = value
+	}
+	for _, key := range postgreSQLProbeIgnoredMetadataKeys {
+		delete(probeConfig.TriggerMetadata, key)
+	}
+	probeConfig.TriggerMetadata["eagerConnect"] = "true"
+
+	start := time.Now()
+	scaler, err := newPostgreSQLScaler(&probeConfig, connections)
+	result.ConnectDuration = time.Since(start)
+	if err != nil {
+		var categorized *postgreSQLError
+		if !errors.As(err, &categorized) {
+			err = &postgreSQLError{reason: postgreSQLErrorReasonConnection, err: err}
+		}
+		return result, &PostgreSQLProbeError{Stage: PostgreSQLProbeStageConnect, err: err}
+	}
+	defer scaler.Close(ctx)
+
+	start = time.Now()
+	value, err := scaler.getActiveNumber(ctx)
+	result.QueryDuration = time.Since(start)
+	if err != nil {
+		return result, &PostgreSQLProbeError{Stage: PostgreSQLProbeStageQuery, err: newPostgreSQLError(err)}
+	}
+	result.Value = value
+	return result, nil
+}
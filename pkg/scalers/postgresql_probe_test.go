@@ -0,0 +1,141 @@
+package scalers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+)
+
+func TestProbePostgreSQL(t *testing.T) {
+	testData := []struct {
+		name     string
+		metadata map[string]string
+		mock     func(sqlmock.Sqlmock)
+		value    float64
+		stage    string
+		reason   string
+	}{
+		{
+			name: "success",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectPing()
+				mock.ExpectQuery("SELECT count").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(7))
+			},
+			value: 7,
+		},
+		{
+			name:     "invalid metadata",
+			metadata: map[string]string{"targetQueryValue": "5"},
+			mock:     func(sqlmock.Sqlmock) {},
+			stage:    PostgreSQLProbeStageMetadata,
+		},
+		{
+			name: "authentication failure",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectPing().WillReturnError(&pq.Error{Code: "28P01", Message: `password authentication failed for user "keda"`})
+			},
+			stage:  PostgreSQLProbeStageConnect,
+			reason: postgreSQLErrorReasonAuthentication,
+		},
+		{
+			name: "connection failure",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectPing().WillReturnError(io.EOF)
+			},
+			stage:  PostgreSQLProbeStageConnect,
+			reason: postgreSQLErrorReasonConnection,
+		},
+		{
+			name: "query failure",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectPing()
+				mock.ExpectQuery("SELECT count").WillReturnError(&pq.Error{Code: "42P01", Message: `relation "jobs" does not exist`})
+			},
+			stage:  PostgreSQLProbeStageQuery,
+			reason: postgreSQLErrorReasonQuery,
+		},
+		{
+			name:     "query failure isn't hidden by onError",
+			metadata: map[string]string{"query": "SELECT count(*) FROM jobs", "targetQueryValue": "5", "onError": postgreSQLOnErrorLastValue, "maxStaleness": "1m", "treatErrorAsZeroSqlStates": "42P01"},
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectPing()
+				mock.ExpectQuery("SELECT count").WillReturnError(&pq.Error{Code: "42P01", Message: `relation "jobs" does not exist`})
+			},
+			stage:  PostgreSQLProbeStageQuery,
+			reason: postgreSQLErrorReasonQuery,
+		},
+		{
+			name: "non-finite value",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectPing()
+				mock.ExpectQuery("SELECT count").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow("NaN"))
+			},
+			stage:  PostgreSQLProbeStageQuery,
+			reason: postgreSQLErrorReasonQuery,
+		},
+	}
+
+	for _, testData := range testData {
+		t.Run(testData.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+			if err != nil {
+				t.Fatal("Could not create sqlmock:", err)
+			}
+			testData.mock(mock)
+			if testData.stage != PostgreSQLProbeStageMetadata {
+				mock.ExpectClose()
+			}
+			metadata := testData.metadata
+			if metadata == nil {
+				metadata = map[string]string{"query": "SELECT count(*) FROM jobs", "targetQueryValue": "5"}
+			}
+			config := &ScalerConfig{TriggerMetadata: metadata, AuthParams: map[string]string{"connection": "host=localhost"}}
+
+			result, err := probePostgreSQL(context.Background(), config, newPostgreSQLConnectionPool(func(*postgreSQLMetadata) (*sql.DB, error) {
+				return db, nil
+			}, 0))
+			if testData.stage == "" {
+				if err != nil {
+					t.Fatal("Unexpected probe error:", err)
+				}
+				if result.Value != testData.value {
+					t.Errorf("Expected value %v but got %v", testData.value, result.Value)
+				}
+				if result.ConnectDuration <= 0 || result.QueryDuration <= 0 {
+					t.Errorf("Expected the durations to be measured but got %+v", result)
+				}
+			} else {
+				var probeErr *PostgreSQLProbeError
+				if !errors.As(err, &probeErr) || probeErr.Stage != testData.stage {
+					t.Fatalf("Expected an error at stage %s but got %v", testData.stage, err)
+				}
+				var reasonErr ConditionReasonError
+				if testData.reason != "" && (!errors.As(err, &reasonErr) || reasonErr.ConditionReason() != testData.reason) {
+					t.Errorf("Expected an error with reason %s but got %v", testData.reason, err)
+				}
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestProbePostgreSQLDoesntChangeConfig(t *testing.T) {
+	metadata := map[string]string{"query": "SELECT count(*) FROM jobs", "targetQueryValue": "5", "onError": postgreSQLOnErrorLastValue}
+	_, err := probePostgreSQL(context.Background(), &ScalerConfig{TriggerMetadata: metadata, AuthParams: map[string]string{"connection": "host=localhost"}},
+		newPostgreSQLConnectionPool(func(*postgreSQLMetadata) (*sql.DB, error) {
+			return sql.OpenDB(&postgreSQLUnreachableConnector{}), nil
+		}, 0))
+	if err == nil {
+		t.Error("Expected the probe to fail without a database")
+	}
+	if metadata["onError"] != postgreSQLOnErrorLastValue || metadata["eagerConnect"] != "" {
+		t.Errorf("Expected the metadata to stay unchanged but got %v", metadata)
+	}
+}
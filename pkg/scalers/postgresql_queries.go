@@ -0,0 +1,93 @@
+package scalers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// postgreSQLQueriesIncompatibleMetadataKeys are options which only apply to a single query
+var postgreSQLQueriesIncompatibleMetadataKeys = []string{"query", "estimateMode", "valueExpression", "bindWorkloadParameters", "targetFromQuery", "valueType", "subtractSecondColumn", "queryFile", "timestampFromQuery", "resultFormat", "strictSingleRow"}
+
+// parsePostgreSQLQueriesMetadata parses the queries whose results are combined into the metric as
+// w1*q1 + w2*q2 + ..., weighted by queryWeights or all by 1
+func parsePostgreSQLQueriesMetadata(config *ScalerConfig, meta *postgreSQLMetadata) error {
+	val, ok := config.TriggerMetadata["queries"]
+	if !ok || val == "" {
+		if _, ok := config.TriggerMetadata["queryWeights"]; ok {
+			return fmt.Errorf("queryWeights can only be used with queries")
+		}
+		return nil
+	}
+
+	if meta.metricMode != postgreSQLMetricModeAbsolute && meta.metricMode != postgreSQLMetricModeRate {
+		return fmt.Errorf("queries can only be used with metricMode %s or %s", postgreSQLMetricModeAbsolute, postgreSQLMetricModeRate)
+	}
+	for _, key := range postgreSQLQueriesIncompatibleMetadataKeys {
+		if _, ok := config.TriggerMetadata[key]; ok {
+			return fmt.Errorf("%s can't be used with queries", key)
+		}
+	}
+
+	var queries []string
+	if err := json.Unmarshal([]byte(val), &queries); err != nil {
+		return fmt.Errorf("queries must be a JSON array of queries: %s", err)
+	}
+	if len(queries) == 0 {
+		return fmt.Errorf("queries must contain at least one query")
+	}
+	for i, query := range queries {
+		if strings.TrimSpace(query) == "" {
+			return fmt.Errorf("query %d of queries is empty", i+1)
+		}
+	}
+	meta.queries = queries
+
+	meta.queryWeights = make([]float64, len(queries))
+	for i := range meta.queryWeights {
+		meta.queryWeights[i] = 1
+	}
+	if val, ok := config.TriggerMetadata["queryWeights"]; ok && val != "" {
+		weights := strings.Split(val, ",")
+		if len(weights) != len(queries) {
+			return fmt.Errorf("queryWeights must contain one weight per query, got %d weights for %d queries", len(weights), len(queries))
+		}
+		for i, weight := range weights {
+			weight, err := strconv.ParseFloat(strings.TrimSpace(weight), 64)
+			if err != nil {
+				return fmt.Errorf("queryWeights parsing error %s", err.Error())
+			}
+			if weight <= 0 || math.IsInf(weight, 0) {
+				return fmt.Errorf("queryWeights must be positive numbers, got %v", weight)
+			}
+			meta.queryWeights[i] = weight
+		}
+	}
+	return nil
+}
+
+// queryWeightedValue runs each of the queries and sums their results multiplied by their weight. Each query
+// returns a single value, no rows and NULL count as the defaultValueOnNoRows like with a single query
+func (s *postgreSQLScaler) queryWeightedValue(ctx context.Context, connection postgreSQLQuerier) (float64, error) {
+	var sum float64
+	for i, query := range s.metadata.queries {
+		var value sql.NullString
+		err := connection.QueryRowContext(ctx, query).Scan(&value)
+		if errors.Is(err, sql.ErrNoRows) {
+			value = sql.NullString{}
+		} else if err != nil {
+			return 0, fmt.Errorf("query %d of queries: %w", i+1, err)
+		}
+		result, err := parsePostgreSQLResultValue(value, s.metadata.defaultValueOnNoRows)
+		if err != nil {
+			return 0, fmt.Errorf("query %d of queries: %w", i+1, err)
+		}
+		sum += s.metadata.queryWeights[i] * result
+	}
+	return sum, nil
+}
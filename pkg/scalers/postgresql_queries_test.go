@@ -0,0 +1,241 @@
+package scalers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+var testPostgreSQLQueriesMetadata = []parsePostgresMetadataTestData{
+	// queries with default equal weights
+	{
+		metadata:    map[string]string{"queries": `["SELECT count(*) FROM jobs", "SELECT max(latency) FROM jobs"]`, "targetQueryValue": "12"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		resolvedEnv: map[string]string{},
+		raisesError: false,
+	},
+	// queries with queryWeights
+	{
+		metadata:    map[string]string{"queries": `["SELECT 1", "SELECT 2"]`, "queryWeights": "2, 0.5", "targetQueryValue": "12"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		resolvedEnv: map[string]string{},
+		raisesError: false,
+	},
+	// queries in metricMode rate
+	{
+		metadata:    map[string]string{"metricMode": "rate", "queries": `["SELECT 1", "SELECT 2"]`, "targetQueryValue": "12"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		resolvedEnv: map[string]string{},
+		raisesError: false,
+	},
+	// more queryWeights than queries
+	{
+		metadata:    map[string]string{"queries": `["SELECT 1", "SELECT 2"]`, "queryWeights": "1,2,3", "targetQueryValue": "12"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		resolvedEnv: map[string]string{},
+		raisesError: true,
+	},
+	// fewer queryWeights than queries
+	{
+		metadata:    map[string]string{"queries": `["SELECT 1", "SELECT 2"]`, "queryWeights": "1", "targetQueryValue": "12"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		resolvedEnv: map[string]string{},
+		raisesError: true,
+	},
+	// zero queryWeight
+	{
+		metadata:    map[string]string{"queries": `["SELECT 1", "SELECT 2"]`, "queryWeights": "1,0", "targetQueryValue": "12"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		resolvedEnv: map[string]string{},
+		raisesError: true,
+	},
+	// negative queryWeight
+	{
+		metadata:    map[string]string{"queries": `["SELECT 1", "SELECT 2"]`, "queryWeights": "1,-2", "targetQueryValue": "12"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		resolvedEnv: map[string]string{},
+		raisesError: true,
+	},
+	// unparseable queryWeight
+	{
+		metadata:    map[string]string{"queries": `["SELECT 1", "SELECT 2"]`, "queryWeights": "1,high", "targetQueryValue": "12"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		resolvedEnv: map[string]string{},
+		raisesError: true,
+	},
+	// queryWeights without queries
+	{
+		metadata:    map[string]string{"query": "query", "queryWeights": "1", "targetQueryValue": "12"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		resolvedEnv: map[string]string{},
+		raisesError: true,
+	},
+	// queries which aren't a JSON array
+	{
+		metadata:    map[string]string{"queries": "SELECT 1; SELECT 2", "targetQueryValue": "12"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		resolvedEnv: map[string]string{},
+		raisesError: true,
+	},
+	// empty queries
+	{
+		metadata:    map[string]string{"queries": "[]", "targetQueryValue": "12"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		resolvedEnv: map[string]string{},
+		raisesError: true,
+	},
+	// empty query in queries
+	{
+		metadata:    map[string]string{"queries": `["SELECT 1", " "]`, "targetQueryValue": "12"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		resolvedEnv: map[string]string{},
+		raisesError: true,
+	},
+	// queries with query
+	{
+		metadata:    map[string]string{"query": "query", "queries": `["SELECT 1", "SELECT 2"]`, "targetQueryValue": "12"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		resolvedEnv: map[string]string{},
+		raisesError: true,
+	},
+	// queries with metricMode age
+	{
+		metadata:    map[string]string{"metricMode": "age", "queries": `["SELECT 1", "SELECT 2"]`, "targetQueryValue": "12"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		resolvedEnv: map[string]string{},
+		raisesError: true,
+	},
+	// queries with valueExpression
+	{
+		metadata:    map[string]string{"queries": `["SELECT 1", "SELECT 2"]`, "valueExpression": "pending", "targetQueryValue": "12"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		resolvedEnv: map[string]string{},
+		raisesError: true,
+	},
+	// queries with estimateMode
+	{
+		metadata:    map[string]string{"queries": `["SELECT 1", "SELECT 2"]`, "estimateMode": "true", "targetQueryValue": "12"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		resolvedEnv: map[string]string{},
+		raisesError: true,
+	},
+	// queries with targetFromQuery
+	{
+		metadata:    map[string]string{"queries": `["SELECT 1", "SELECT 2"]`, "targetFromQuery": "true", "targetQueryValue": "12"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		resolvedEnv: map[string]string{},
+		raisesError: true,
+	},
+	// queries with valueType
+	{
+		metadata:    map[string]string{"queries": `["SELECT 1", "SELECT 2"]`, "valueType": "integer", "targetQueryValue": "12"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		resolvedEnv: map[string]string{},
+		raisesError: true,
+	},
+	// queries with subtractSecondColumn
+	{
+		metadata:    map[string]string{"queries": `["SELECT 1", "SELECT 2"]`, "subtractSecondColumn": "true", "targetQueryValue": "12"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		resolvedEnv: map[string]string{},
+		raisesError: true,
+	},
+	// queries with queryFile
+	{
+		metadata:    map[string]string{"queries": `["SELECT 1", "SELECT 2"]`, "queryFile": "/etc/keda/query.sql", "targetQueryValue": "12"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		resolvedEnv: map[string]string{},
+		raisesError: true,
+	},
+	// queries with timestampFromQuery
+	{
+		metadata:    map[string]string{"queries": `["SELECT 1", "SELECT 2"]`, "timestampFromQuery": "true", "targetQueryValue": "12"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		resolvedEnv: map[string]string{},
+		raisesError: true,
+	},
+	// queries with resultFormat
+	{
+		metadata:    map[string]string{"queries": `["SELECT 1", "SELECT 2"]`, "resultFormat": "hex", "targetQueryValue": "12"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		resolvedEnv: map[string]string{},
+		raisesError: true,
+	},
+	// queries with strictSingleRow
+	{
+		metadata:    map[string]string{"queries": `["SELECT 1", "SELECT 2"]`, "strictSingleRow": "true", "targetQueryValue": "12"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		resolvedEnv: map[string]string{},
+		raisesError: true,
+	},
+}
+
+func TestParsePostgreSQLQueriesMetadata(t *testing.T) {
+	testParsePostgreSQLMetadata(t, testPostgreSQLQueriesMetadata)
+}
+
+func TestPostgreSQLWeightedQueries(t *testing.T) {
+	testData := []struct {
+		name     string
+		metadata map[string]string
+		expected float64
+	}{
+		{name: "default equal weights", metadata: map[string]string{}, expected: 14},
+		{name: "queryWeights", metadata: map[string]string{"queryWeights": "2,0.5"}, expected: 22},
+	}
+	for _, testData := range testData {
+		t.Run(testData.name, func(t *testing.T) {
+			metadata := map[string]string{"queries": `["SELECT count(*) FROM jobs", "SELECT max(latency) FROM jobs"]`, "targetQueryValue": "5"}
+			for key, value := range testData.metadata {
+				metadata[key] = value
+			}
+			scaler, mock := newPostgreSQLMockScaler(t, &ScalerConfig{TriggerMetadata: metadata, AuthParams: map[string]string{"connection": "host=localhost"}})
+
+			mock.ExpectQuery("SELECT count").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(10))
+			mock.ExpectQuery("SELECT max").WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(4))
+			value, err := scaler.getActiveNumber(context.Background())
+			if err != nil {
+				t.Fatal("Unexpected error:", err)
+			}
+			if value != testData.expected {
+				t.Errorf("Expected %v but got %v", testData.expected, value)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestPostgreSQLWeightedQueriesNoRows(t *testing.T) {
+	scaler, mock := newPostgreSQLMockScaler(t, &ScalerConfig{
+		TriggerMetadata: map[string]string{"queries": `["SELECT count(*) FROM jobs", "SELECT latency FROM jobs"]`, "queryWeights": "1,3", "targetQueryValue": "5"},
+		AuthParams:      map[string]string{"connection": "host=localhost"},
+	})
+
+	// without defaultValueOnNoRows a query returning no rows counts as 0
+	mock.ExpectQuery("SELECT count").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(10))
+	mock.ExpectQuery("SELECT latency").WillReturnRows(sqlmock.NewRows([]string{"latency"}))
+	if value, err := scaler.getActiveNumber(context.Background()); err != nil || value != 10 {
+		t.Errorf("Expected 10 but got %v (%v)", value, err)
+	}
+
+	scaler, mock = newPostgreSQLMockScaler(t, &ScalerConfig{
+		TriggerMetadata: map[string]string{"queries": `["SELECT count(*) FROM jobs", "SELECT latency FROM jobs"]`, "queryWeights": "1,3",
+			"defaultValueOnNoRows": "2", "targetQueryValue": "5"},
+		AuthParams: map[string]string{"connection": "host=localhost"},
+	})
+	mock.ExpectQuery("SELECT count").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(nil))
+	mock.ExpectQuery("SELECT latency").WillReturnRows(sqlmock.NewRows([]string{"latency"}))
+	value, err := scaler.getActiveNumber(context.Background())
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if value != 8 {
+		t.Errorf("Expected 8 but got %v", value)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,45 @@
+package scalers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// parsePostgreSQLQueryCommentMetadata builds the comment attributing the queries to the ScaledObject with queryCommentTag
+func parsePostgreSQLQueryCommentMetadata(config *ScalerConfig, meta *postgreSQLMetadata) error {
+	if val, ok := config.TriggerMetadata["queryCommentTag"]; ok {
+		queryCommentTag, err := strconv.ParseBool(val)
+		if err != nil {
+			return fmt.Errorf("queryCommentTag parsing error %s", err.Error())
+		}
+		if queryCommentTag {
+			meta.queryComment = formatPostgreSQLQueryComment(config.ScalableObjectNamespace, config.ScalableObjectName)
+		}
+	}
+	return nil
+}
+
+// formatPostgreSQLQueryComment returns the comment tagging the queries of the ScaledObject in the style of
+// sqlcommenter, so the query logs and pg_stat_activity of the database can be attributed to it. The values
+// are URL encoded, so they can't end the comment
+func formatPostgreSQLQueryComment(namespace, name string) string {
+	return fmt.Sprintf("/* keda,namespace='%s',scaledobject='%s' */ ", url.QueryEscape(namespace), url.QueryEscape(name))
+}
+
+// postgreSQLCommentedQuerier prepends the comment to every query. A comment doesn't change what the
+// query does, even in front of EXPLAIN
+type postgreSQLCommentedQuerier struct {
+	querier postgreSQLQuerier
+	comment string
+}
+
+func (q *postgreSQLCommentedQuerier) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return q.querier.QueryContext(ctx, q.comment+query, args...)
+}
+
+func (q *postgreSQLCommentedQuerier) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return q.querier.QueryRowContext(ctx, q.comment+query, args...)
+}
@@ -0,0 +1,63 @@
+package scalers
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+var testPostgreSQLQueryCommentMetadata = []parsePostgresMetadataTestData{
+	// queryCommentTag
+	{
+		metadata:   map[string]string{"query": "select 1", "targetQueryValue": "1", "queryCommentTag": "true"},
+		authParams: map[string]string{"connection": "test_connection_string"},
+	},
+	// queryCommentTag invalid
+	{
+		metadata:    map[string]string{"query": "select 1", "targetQueryValue": "1", "queryCommentTag": "on please"},
+		authParams:  map[string]string{"connection": "test_connection_string"},
+		raisesError: true,
+	},
+}
+
+func TestParsePostgreSQLQueryCommentMetadata(t *testing.T) {
+	testParsePostgreSQLMetadata(t, testPostgreSQLQueryCommentMetadata)
+}
+
+func TestFormatPostgreSQLQueryComment(t *testing.T) {
+	if comment := formatPostgreSQLQueryComment("default", "orders"); comment != "/* keda,namespace='default',scaledobject='orders' */ " {
+		t.Errorf("Unexpected comment %q", comment)
+	}
+	// a value can't end the comment or the quoted value
+	comment := formatPostgreSQLQueryComment("a*/ DROP TABLE jobs; /*", "o'rders")
+	if strings.Count(comment, "*/") != 1 || strings.Count(comment, "'") != 4 {
+		t.Errorf("Expected the values to be escaped but got %q", comment)
+	}
+}
+
+func TestPostgreSQLQueryCommentTag(t *testing.T) {
+	scaler, mock := newPostgreSQLMockScaler(t, &ScalerConfig{
+		ScalableObjectName:      "orders",
+		ScalableObjectNamespace: "default",
+		TriggerMetadata:         map[string]string{"query": "SELECT count(*) FROM jobs", "targetQueryValue": "5", "maintenanceQuery": "SELECT false", "queryCommentTag": "true"},
+		AuthParams:              map[string]string{"connection": "host=localhost"},
+	})
+	comment := regexp.QuoteMeta("/* keda,namespace='default',scaledobject='orders' */ ")
+	// every query run for the value is tagged, the query itself is unchanged
+	mock.ExpectQuery("^" + comment + regexp.QuoteMeta("SELECT false") + "$").WillReturnRows(sqlmock.NewRows([]string{"bool"}).AddRow(false))
+	mock.ExpectQuery("^" + comment + regexp.QuoteMeta("SELECT count(*) FROM jobs") + "$").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(7))
+
+	value, err := scaler.getActiveNumber(context.Background())
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if value != 7 {
+		t.Errorf("Expected 7 but got %v", value)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
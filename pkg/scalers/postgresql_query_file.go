@@ -0,0 +1,96 @@
+package scalers
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// postgreSQLQueryFile is the file the query is read from, rendered e.g. by an init container. Like the
+// TLS files it's checked for changes before every query, so a new query is used without restarting
+type postgreSQLQueryFile struct {
+	path    string
+	modTime time.Time
+	query   string
+}
+
+// readPostgreSQLQueryFile returns the trimmed query in path and the modification time it was read at
+func readPostgreSQLQueryFile(path string) (string, time.Time, error) {
+	// os.Stat follows symlinks, so updates of mounted volumes are detected too
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	query := strings.TrimSpace(string(content))
+	if query == "" {
+		return "", time.Time{}, fmt.Errorf("queryFile %s is empty", path)
+	}
+	return query, info.ModTime(), nil
+}
+
+// parsePostgreSQLQueryFileMetadata reads the query from the queryFile instead of the query of the trigger
+func parsePostgreSQLQueryFileMetadata(config *ScalerConfig, meta *postgreSQLMetadata) error {
+	path, ok := config.TriggerMetadata["queryFile"]
+	if !ok {
+		return nil
+	}
+	if !isPostgreSQLQueryMetricMode(meta.metricMode) {
+		return fmt.Errorf("queryFile can only be used with metricMode %s, %s, %s or %s", postgreSQLMetricModeAbsolute, postgreSQLMetricModeRate,
+			postgreSQLMetricModeAge, postgreSQLMetricModeRowCount)
+	}
+	if path == "" {
+		return nil
+	}
+	if _, ok := config.TriggerMetadata["query"]; ok {
+		return fmt.Errorf("query and queryFile can't be combined")
+	}
+	query, modTime, err := readPostgreSQLQueryFile(path)
+	if err != nil {
+		return fmt.Errorf("queryFile reading error %s", err.Error())
+	}
+	meta.query, meta.queryFile, meta.queryFileModTime = query, path, modTime
+	return nil
+}
+
+// getQuery returns the query, reloaded from the queryFile if there is one
+func (s *postgreSQLScaler) getQuery() string {
+	if s.queryFile == nil {
+		return s.metadata.query
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.queryFile.query
+}
+
+// reloadQueryFile reads the queryFile again when it changed. A file which can't be read, e.g. in the middle
+// of an update, keeps the previous query and is checked again next time
+func (s *postgreSQLScaler) reloadQueryFile() {
+	if s.queryFile == nil {
+		return
+	}
+	info, err := os.Stat(s.queryFile.path)
+	s.mutex.Lock()
+	changed := err == nil && !info.ModTime().Equal(s.queryFile.modTime)
+	s.mutex.Unlock()
+	if !changed {
+		return
+	}
+
+	query, modTime, err := readPostgreSQLQueryFile(s.queryFile.path)
+	if err != nil {
+		s.logger.Error(err, "could not reload postgreSQL queryFile, keeping the previous query", "queryFile", s.queryFile.path)
+		return
+	}
+	s.mutex.Lock()
+	reloaded := query != s.queryFile.query
+	s.queryFile.query, s.queryFile.modTime = query, modTime
+	s.mutex.Unlock()
+	if reloaded {
+		s.logger.Info("postgreSQL queryFile changed, using the new query", "queryFile", s.queryFile.path)
+	}
+}
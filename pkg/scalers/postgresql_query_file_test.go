@@ -0,0 +1,113 @@
+package scalers
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// writePostgreSQLQueryFile writes query to path with the given modification time, as the mod time
+// resolution of the file system may not tell writes within a test apart
+func writePostgreSQLQueryFile(t *testing.T, path, query string, modTime time.Time) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(query), 0o600); err != nil {
+		t.Fatal("Could not write queryFile:", err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal("Could not set queryFile mod time:", err)
+	}
+}
+
+func TestParsePostgreSQLMetadataQueryFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "query.sql")
+	writePostgreSQLQueryFile(t, path, "\n  SELECT count(*) FROM jobs\n", time.Now())
+	empty := filepath.Join(dir, "empty.sql")
+	writePostgreSQLQueryFile(t, empty, " \n", time.Now())
+
+	testData := []struct {
+		name        string
+		metadata    map[string]string
+		raisesError bool
+	}{
+		{name: "queryFile", metadata: map[string]string{"queryFile": path}},
+		{name: "queryFile with metricMode age", metadata: map[string]string{"queryFile": path, "metricMode": postgreSQLMetricModeAge}},
+		{name: "missing file", metadata: map[string]string{"queryFile": filepath.Join(dir, "missing.sql")}, raisesError: true},
+		{name: "empty file", metadata: map[string]string{"queryFile": empty}, raisesError: true},
+		{name: "queryFile and query", metadata: map[string]string{"queryFile": path, "query": "SELECT 1"}, raisesError: true},
+		{name: "queryFile with metricMode connectionSaturation", metadata: map[string]string{"queryFile": path, "metricMode": postgreSQLMetricModeConnectionSaturation}, raisesError: true},
+		{name: "queryFile with bindWorkloadParameters", metadata: map[string]string{"queryFile": path, "bindWorkloadParameters": "true"}, raisesError: true},
+	}
+
+	for _, testData := range testData {
+		t.Run(testData.name, func(t *testing.T) {
+			metadata := map[string]string{"targetQueryValue": "5"}
+			for key, value := range testData.metadata {
+				metadata[key] = value
+			}
+			meta, err := parsePostgreSQLMetadata(&ScalerConfig{TriggerMetadata: metadata, AuthParams: map[string]string{"connection": "host=localhost"}})
+			if err != nil && !testData.raisesError {
+				t.Fatal("Expected success but got error", err)
+			}
+			if err == nil && testData.raisesError {
+				t.Fatal("Expected error but got success")
+			}
+			if err == nil && meta.query != "SELECT count(*) FROM jobs" {
+				t.Errorf("Expected the trimmed query of the file but got %q", meta.query)
+			}
+		})
+	}
+}
+
+func TestPostgreSQLQueryFileReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "query.sql")
+	modTime := time.Now().Add(-time.Hour)
+	writePostgreSQLQueryFile(t, path, "SELECT count(*) FROM jobs", modTime)
+
+	scaler, mock := newPostgreSQLMockScaler(t, &ScalerConfig{
+		TriggerMetadata: map[string]string{"queryFile": path, "targetQueryValue": "5"},
+		AuthParams:      map[string]string{"connection": "host=localhost"},
+	})
+	read := func(expectedQuery string, value int) {
+		t.Helper()
+		mock.ExpectQuery(expectedQuery).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(value))
+		got, err := scaler.getActiveNumber(context.Background())
+		if err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+		if got != float64(value) {
+			t.Errorf("Expected %d but got %v", value, got)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	read("FROM jobs", 1)
+
+	// unchanged file
+	read("FROM jobs", 2)
+
+	modTime = modTime.Add(time.Minute)
+	writePostgreSQLQueryFile(t, path, "SELECT count(*) FROM orders", modTime)
+	read("FROM orders", 3)
+
+	// an empty file in the middle of an update keeps the previous query
+	modTime = modTime.Add(time.Minute)
+	writePostgreSQLQueryFile(t, path, "", modTime)
+	read("FROM orders", 4)
+
+	// a removed file keeps the previous query
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	read("FROM orders", 5)
+
+	modTime = modTime.Add(time.Minute)
+	writePostgreSQLQueryFile(t, path, "SELECT count(*) FROM invoices", modTime)
+	read("FROM invoices", 6)
+}
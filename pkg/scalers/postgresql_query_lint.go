@@ -0,0 +1,78 @@
+package scalers
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	// postgreSQLConstantQueryCheckWarn logs a warning at creation for a query which looks constant
+	postgreSQLConstantQueryCheckWarn = "warn"
+	// postgreSQLConstantQueryCheckFail rejects a query which looks constant
+	postgreSQLConstantQueryCheckFail = "fail"
+)
+
+var (
+	// postgreSQLQueryCommentsAndLiterals matches comments, string literals and quoted identifiers
+	postgreSQLQueryCommentsAndLiterals = regexp.MustCompile(`(?s)--[^\n]*|/\*.*?\*/|'(?:[^']|'')*'|"(?:[^"]|"")*"`)
+	postgreSQLQueryWords               = regexp.MustCompile(`[a-z_][a-z0-9_$]*`)
+	// postgreSQLQueryTypeOrAlias matches the words following a cast or AS, e.g. 1::int or 1 AS pending
+	postgreSQLQueryTypeOrAlias = regexp.MustCompile(`(::\s*|\bas\s+)[a-z_][a-z0-9_$]*`)
+)
+
+// postgreSQLConstantQueryWords are the words which can appear in a constant expression
+var postgreSQLConstantQueryWords = map[string]bool{
+	"select": true, "values": true, "null": true, "true": true, "false": true,
+	"and": true, "or": true, "not": true, "is": true, "case": true, "when": true, "then": true, "else": true, "end": true,
+}
+
+// parsePostgreSQLQueryLintMetadata parses the constantQueryCheck and rejects a constant query with fail
+func parsePostgreSQLQueryLintMetadata(config *ScalerConfig, meta *postgreSQLMetadata) error {
+	val, ok := config.TriggerMetadata["constantQueryCheck"]
+	if !ok || val == "" {
+		return nil
+	}
+	switch val {
+	case postgreSQLConstantQueryCheckWarn, postgreSQLConstantQueryCheckFail:
+		meta.constantQueryCheck = val
+	default:
+		return fmt.Errorf("unknown constantQueryCheck %s, must be one of %s, %s", val, postgreSQLConstantQueryCheckWarn, postgreSQLConstantQueryCheckFail)
+	}
+	// the queries of the built-in metric modes read the catalogs
+	if meta.metricMode != postgreSQLMetricModeAbsolute && meta.metricMode != postgreSQLMetricModeRate && meta.metricMode != postgreSQLMetricModeAge {
+		return fmt.Errorf("constantQueryCheck can't be used with metricMode %s", meta.metricMode)
+	}
+	if query, ok := findPostgreSQLConstantQuery(meta); ok && val == postgreSQLConstantQueryCheckFail {
+		return fmt.Errorf("query %q doesn't read any table and always returns the same value", query)
+	}
+	return nil
+}
+
+// findPostgreSQLConstantQuery returns the query, or the first of the queries, which looks constant
+func findPostgreSQLConstantQuery(meta *postgreSQLMetadata) (string, bool) {
+	for _, query := range append([]string{meta.query}, meta.queries...) {
+		if isPostgreSQLConstantQuery(query) {
+			return query, true
+		}
+	}
+	return "", false
+}
+
+// isPostgreSQLConstantQuery reports whether the query is a SELECT or VALUES of a constant expression, e.g. a
+// SELECT 1 placeholder. It's conservative: anything reading a table, calling a function or referencing a name,
+// such as now() or current_timestamp, isn't considered constant
+func isPostgreSQLConstantQuery(query string) bool {
+	query = strings.ToLower(postgreSQLQueryCommentsAndLiterals.ReplaceAllString(query, " 0 "))
+	query = postgreSQLQueryTypeOrAlias.ReplaceAllString(query, " ")
+	words := postgreSQLQueryWords.FindAllString(query, -1)
+	if len(words) == 0 || (words[0] != "select" && words[0] != "values") {
+		return false
+	}
+	for _, word := range words {
+		if !postgreSQLConstantQueryWords[word] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,77 @@
+package scalers
+
+import "testing"
+
+var testPostgreSQLQueryLintMetadata = []parsePostgresMetadataTestData{
+	// constantQueryCheck fail with a constant query
+	{
+		metadata:    map[string]string{"query": "SELECT 1", "targetQueryValue": "12", "constantQueryCheck": "fail"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		resolvedEnv: map[string]string{},
+		raisesError: true,
+	},
+	// constantQueryCheck fail with a table query
+	{
+		metadata:    map[string]string{"query": "SELECT count(*) FROM jobs", "targetQueryValue": "12", "constantQueryCheck": "fail"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		resolvedEnv: map[string]string{},
+		raisesError: false,
+	},
+	// constantQueryCheck fail with a constant query in queries
+	{
+		metadata:    map[string]string{"queries": `["SELECT count(*) FROM jobs", "SELECT 1"]`, "targetQueryValue": "12", "constantQueryCheck": "fail"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		resolvedEnv: map[string]string{},
+		raisesError: true,
+	},
+	// constantQueryCheck warn with a constant query
+	{
+		metadata:    map[string]string{"query": "SELECT 1", "targetQueryValue": "12", "constantQueryCheck": "warn"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		resolvedEnv: map[string]string{},
+		raisesError: false,
+	},
+	// unknown constantQueryCheck
+	{
+		metadata:    map[string]string{"query": "SELECT 1", "targetQueryValue": "12", "constantQueryCheck": "error"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		resolvedEnv: map[string]string{},
+		raisesError: true,
+	},
+}
+
+func TestParsePostgreSQLQueryLintMetadata(t *testing.T) {
+	testParsePostgreSQLMetadata(t, testPostgreSQLQueryLintMetadata)
+}
+
+func TestIsPostgreSQLConstantQuery(t *testing.T) {
+	testData := []struct {
+		query    string
+		constant bool
+	}{
+		{query: "SELECT 1", constant: true},
+		{query: "select 42::int AS pending", constant: true},
+		{query: "SELECT 1 + 2 * 3;", constant: true},
+		{query: "SELECT 'placeholder'", constant: true},
+		{query: "SELECT NULL", constant: true},
+		{query: "VALUES (5)", constant: true},
+		{query: "-- TODO replace\nSELECT 0", constant: true},
+		{query: "SELECT /* from jobs */ 1", constant: true},
+		{query: "SELECT CASE WHEN true THEN 1 ELSE 0 END", constant: true},
+		{query: "SELECT count(*) FROM jobs", constant: false},
+		{query: "SELECT count(*) FROM jobs WHERE state = 'SELECT 1'", constant: false},
+		{query: "SELECT pending_jobs()", constant: false},
+		{query: "SELECT current_timestamp", constant: false},
+		{query: "SELECT extract(epoch FROM now() - max(created_at)) FROM events", constant: false},
+		{query: "SELECT (SELECT count(*) FROM jobs) - 1", constant: false},
+		{query: "WITH pending AS (SELECT 1) SELECT * FROM pending", constant: false},
+		{query: "TABLE backlog", constant: false},
+		{query: "EXECUTE pending", constant: false},
+	}
+
+	for _, testData := range testData {
+		if constant := isPostgreSQLConstantQuery(testData.query); constant != testData.constant {
+			t.Errorf("Expected %q constant %v but got %v", testData.query, testData.constant, constant)
+		}
+	}
+}
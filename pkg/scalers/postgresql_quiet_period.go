@@ -0,0 +1,46 @@
+package scalers
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// parsePostgreSQLQuietPeriodMetadata parses the quietPeriodReads before the trigger reports inactive
+func parsePostgreSQLQuietPeriodMetadata(config *ScalerConfig, meta *postgreSQLMetadata) error {
+	if val, ok := config.TriggerMetadata["quietPeriodReads"]; ok && val != "" {
+		quietPeriodReads, err := strconv.Atoi(val)
+		if err != nil {
+			return fmt.Errorf("quietPeriodReads parsing error %s", err.Error())
+		}
+		if quietPeriodReads < 0 {
+			return fmt.Errorf("quietPeriodReads must not be negative, got %d", quietPeriodReads)
+		}
+		meta.quietPeriodReads = quietPeriodReads
+	}
+	return nil
+}
+
+// postgreSQLQuietPeriod keeps a trigger active until a number of consecutive reads were inactive, so a
+// backlog which briefly drops to zero between bursts doesn't flap the workload. A trigger which was
+// never active is quiet from the start, otherwise new scalers would activate idle workloads
+type postgreSQLQuietPeriod struct {
+	reads      int
+	quietReads int
+}
+
+func newPostgreSQLQuietPeriod(reads int) *postgreSQLQuietPeriod {
+	return &postgreSQLQuietPeriod{reads: reads, quietReads: reads}
+}
+
+// active records a read and returns whether the trigger is active: on any active read and until reads
+// consecutive inactive ones followed it
+func (q *postgreSQLQuietPeriod) active(active bool) bool {
+	if active {
+		q.quietReads = 0
+		return true
+	}
+	if q.quietReads < q.reads {
+		q.quietReads++
+	}
+	return q.quietReads < q.reads
+}
@@ -0,0 +1,77 @@
+package scalers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+var testPostgreSQLQuietPeriodMetadata = []parsePostgresMetadataTestData{
+	// negative quietPeriodReads
+	{
+		metadata:    map[string]string{"query": "query", "targetQueryValue": "5", "quietPeriodReads": "-1"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		resolvedEnv: map[string]string{},
+		raisesError: true,
+	},
+}
+
+func TestParsePostgreSQLQuietPeriodMetadata(t *testing.T) {
+	testParsePostgreSQLMetadata(t, testPostgreSQLQuietPeriodMetadata)
+}
+
+func TestPostgreSQLQuietPeriod(t *testing.T) {
+	quietPeriod := newPostgreSQLQuietPeriod(3)
+	testData := []struct {
+		name     string
+		read     bool
+		expected bool
+	}{
+		{name: "idle from the start", read: false, expected: false},
+		{name: "backlog", read: true, expected: true},
+		{name: "first quiet read", read: false, expected: true},
+		{name: "second quiet read", read: false, expected: true},
+		{name: "backlog resets the count", read: true, expected: true},
+		{name: "first quiet read after reset", read: false, expected: true},
+		{name: "second quiet read after reset", read: false, expected: true},
+		{name: "third quiet read", read: false, expected: false},
+		{name: "still quiet", read: false, expected: false},
+		{name: "backlog again", read: true, expected: true},
+	}
+
+	for _, testData := range testData {
+		if active := quietPeriod.active(testData.read); active != testData.expected {
+			t.Errorf("%s: expected active %v but got %v", testData.name, testData.expected, active)
+		}
+	}
+}
+
+func TestPostgreSQLQuietPeriodReads(t *testing.T) {
+	scaler, mock := newPostgreSQLMockScaler(t, &ScalerConfig{
+		TriggerMetadata: map[string]string{"query": "SELECT count(*) FROM jobs", "targetQueryValue": "5", "quietPeriodReads": "2"},
+		AuthParams:      map[string]string{"connection": "host=localhost"},
+	})
+	reads := []struct {
+		count  int
+		active bool
+	}{
+		{count: 0, active: false},
+		{count: 4, active: true},
+		{count: 0, active: true},
+		{count: 1, active: true},
+		{count: 0, active: true},
+		{count: 0, active: false},
+	}
+
+	for i, read := range reads {
+		mock.ExpectQuery("SELECT count").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(read.count))
+		active, err := scaler.IsActive(context.Background())
+		if err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+		if active != read.active {
+			t.Errorf("read %d of %d: expected active %v but got %v", i, read.count, read.active, active)
+		}
+	}
+}
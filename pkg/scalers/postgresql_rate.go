@@ -0,0 +1,54 @@
+package scalers
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// parsePostgreSQLRateMetadata parses signedRate, which reports a decreasing counter as negative rate
+func parsePostgreSQLRateMetadata(config *ScalerConfig, meta *postgreSQLMetadata) error {
+	if val, ok := config.TriggerMetadata["signedRate"]; ok {
+		signedRate, err := strconv.ParseBool(val)
+		if err != nil {
+			return fmt.Errorf("signedRate parsing error %s", err.Error())
+		}
+		if signedRate && meta.metricMode != postgreSQLMetricModeRate {
+			return fmt.Errorf("signedRate can only be used with metricMode %s", postgreSQLMetricModeRate)
+		}
+		meta.signedRate = signedRate
+	}
+	return nil
+}
+
+// postgreSQLRateTracker turns consecutive readings of a counter into a per-second rate
+type postgreSQLRateTracker struct {
+	previousValue float64
+	previousTime  time.Time
+	hasPrevious   bool
+	// previousRate is returned again for the same reading, e.g. a value served by the shared poller
+	// or pushed through notifyChannel which is read several times
+	previousRate float64
+	// signed reports a decreasing value as a negative rate instead of taking it for a counter reset,
+	// e.g. how fast a backlog drains
+	signed bool
+}
+
+// rate returns the per-second change since the previous reading and stores the new reading as
+// baseline. The first reading and counter resets, where the value decreased, report 0. A signed
+// tracker has no resets, it returns the growth of a backlog as positive and its drain as negative rate.
+// A reading taken at the same time as the previous one is the same reading and keeps its rate
+func (r *postgreSQLRateTracker) rate(value float64, readAt time.Time) float64 {
+	if r.hasPrevious && readAt.Equal(r.previousTime) {
+		return r.previousRate
+	}
+	previousValue, previousTime, hasPrevious := r.previousValue, r.previousTime, r.hasPrevious
+	r.previousValue, r.previousTime, r.hasPrevious = value, readAt, true
+
+	elapsed := readAt.Sub(previousTime).Seconds()
+	r.previousRate = 0
+	if hasPrevious && elapsed > 0 && (value >= previousValue || r.signed) {
+		r.previousRate = (value - previousValue) / elapsed
+	}
+	return r.previousRate
+}
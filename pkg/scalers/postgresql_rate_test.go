@@ -0,0 +1,109 @@
+package scalers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+type postgreSQLRateTestData struct {
+	name    string
+	value   float64
+	elapsed time.Duration
+	rate    float64
+}
+
+var testPostgreSQLRates = []postgreSQLRateTestData{
+	{name: "first reading has no baseline", value: 100, elapsed: 0, rate: 0},
+	{name: "growing counter", value: 160, elapsed: 30 * time.Second, rate: 2},
+	{name: "unchanged counter", value: 160, elapsed: 10 * time.Second, rate: 0},
+	{name: "counter reset", value: 20, elapsed: 10 * time.Second, rate: 0},
+	{name: "growth after reset uses new baseline", value: 70, elapsed: 5 * time.Second, rate: 10},
+	{name: "same reading keeps its rate", value: 70, elapsed: 0, rate: 10},
+}
+
+func TestPostgreSQLRateTracker(t *testing.T) {
+	tracker := postgreSQLRateTracker{}
+	now := time.Now()
+	for _, testData := range testPostgreSQLRates {
+		now = now.Add(testData.elapsed)
+		if rate := tracker.rate(testData.value, now); rate != testData.rate {
+			t.Errorf("%s: expected rate %v but got %v", testData.name, testData.rate, rate)
+		}
+	}
+}
+
+var testPostgreSQLSignedRates = []postgreSQLRateTestData{
+	{name: "first reading has no baseline", value: 500, elapsed: 0, rate: 0},
+	{name: "growing backlog", value: 800, elapsed: 30 * time.Second, rate: 10},
+	{name: "draining backlog", value: 200, elapsed: 60 * time.Second, rate: -10},
+	{name: "drained backlog", value: 0, elapsed: 40 * time.Second, rate: -5},
+	{name: "empty backlog", value: 0, elapsed: 10 * time.Second, rate: 0},
+}
+
+func TestPostgreSQLSignedRateTracker(t *testing.T) {
+	tracker := postgreSQLRateTracker{signed: true}
+	now := time.Now()
+	for _, testData := range testPostgreSQLSignedRates {
+		now = now.Add(testData.elapsed)
+		if rate := tracker.rate(testData.value, now); rate != testData.rate {
+			t.Errorf("%s: expected rate %v but got %v", testData.name, testData.rate, rate)
+		}
+	}
+}
+
+var testPostgreSQLRateMetadata = []parsePostgresMetadataTestData{
+	// signedRate
+	{
+		metadata:   map[string]string{"query": "test_query", "targetQueryValue": "5", "metricMode": "rate", "signedRate": "true"},
+		authParams: map[string]string{"connection": "test_connection_string"},
+	},
+	// signedRate without metricMode rate
+	{
+		metadata:    map[string]string{"query": "test_query", "targetQueryValue": "5", "signedRate": "true"},
+		authParams:  map[string]string{"connection": "test_connection_string"},
+		raisesError: true,
+	},
+	// invalid signedRate
+	{
+		metadata:    map[string]string{"query": "test_query", "targetQueryValue": "5", "metricMode": "rate", "signedRate": "sometimes"},
+		authParams:  map[string]string{"connection": "test_connection_string"},
+		raisesError: true,
+	},
+}
+
+func TestParsePostgreSQLRateMetadata(t *testing.T) {
+	testParsePostgreSQLMetadata(t, testPostgreSQLRateMetadata)
+}
+
+func TestPostgreSQLScalerRateMode(t *testing.T) {
+	// reportRate is the former way to select metricMode rate
+	for _, rateMetadata := range []map[string]string{{"metricMode": "rate"}, {"reportRate": "true"}} {
+		metadata := map[string]string{"query": "SELECT max(id) FROM events", "targetQueryValue": "5"}
+		for key, value := range rateMetadata {
+			metadata[key] = value
+		}
+		scaler, mock := newPostgreSQLMockScaler(t, &ScalerConfig{
+			TriggerMetadata: metadata,
+			AuthParams:      map[string]string{"connection": "host=localhost"},
+		})
+
+		mock.ExpectQuery("SELECT max\\(id\\) FROM events").WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(1000))
+		if value, err := scaler.getActiveNumber(context.Background()); err != nil || value != 0 {
+			t.Errorf("%v: expected rate 0 without baseline but got %v (%v)", rateMetadata, value, err)
+		}
+
+		// pretend the baseline was taken 10 seconds ago
+		scaler.rateTracker.previousTime = scaler.rateTracker.previousTime.Add(-10 * time.Second)
+		mock.ExpectQuery("SELECT max\\(id\\) FROM events").WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(1500))
+		value, err := scaler.getActiveNumber(context.Background())
+		if err != nil {
+			t.Fatalf("%v: unexpected error getting rate: %s", rateMetadata, err)
+		}
+		if value < 49 || value > 50 {
+			t.Errorf("%v: expected rate of about 50/s but got %v", rateMetadata, value)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package scalers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// postgreSQLReplicationSlotLagQuery returns the current WAL position, or the last received one on a standby,
+// and the oldest position the slot still needs: confirmed_flush_lsn for logical slots, restart_lsn for physical ones
+const postgreSQLReplicationSlotLagQuery = `SELECT CASE WHEN pg_is_in_recovery() THEN pg_last_wal_receive_lsn() ELSE pg_current_wal_lsn() END::text, ` +
+	`COALESCE(confirmed_flush_lsn, restart_lsn)::text FROM pg_replication_slots WHERE slot_name = $1`
+
+// parsePostgreSQLReplicationSlotLagMetadata parses the replication slot metricMode replicationSlotLag reports the lag of
+func parsePostgreSQLReplicationSlotLagMetadata(config *ScalerConfig, meta *postgreSQLMetadata) error {
+	if meta.metricMode != postgreSQLMetricModeReplicationSlotLag {
+		if _, ok := config.TriggerMetadata["slotName"]; ok {
+			return fmt.Errorf("slotName can only be used with metricMode %s", postgreSQLMetricModeReplicationSlotLag)
+		}
+		return nil
+	}
+	if meta.dialect == postgreSQLDialectCockroach {
+		return fmt.Errorf("metricMode %s can't be used with dialect %s", meta.metricMode, meta.dialect)
+	}
+	val, ok := config.TriggerMetadata["slotName"]
+	if !ok || val == "" {
+		return fmt.Errorf("no slotName given")
+	}
+	meta.slotName = val
+	meta.query = postgreSQLReplicationSlotLagQuery
+	meta.queryArgs = []interface{}{val}
+	return nil
+}
+
+// parsePostgreSQLLSN parses a pg_lsn in its textual X/Y form, the upper and lower 32 bits in hexadecimal
+func parsePostgreSQLLSN(lsn string) (uint64, error) {
+	upper, lower, found := strings.Cut(lsn, "/")
+	if !found {
+		return 0, fmt.Errorf("invalid LSN %q", lsn)
+	}
+	hi, err := strconv.ParseUint(upper, 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid LSN %q", lsn)
+	}
+	lo, err := strconv.ParseUint(lower, 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid LSN %q", lsn)
+	}
+	return hi<<32 | lo, nil
+}
+
+// computePostgreSQLReplicationSlotLag returns the bytes of WAL between the current position and the slot.
+// A slot ahead of the position, as seen on a lagging standby, has no lag
+func computePostgreSQLReplicationSlotLag(currentLSN, slotLSN string) (float64, error) {
+	current, err := parsePostgreSQLLSN(currentLSN)
+	if err != nil {
+		return 0, err
+	}
+	slot, err := parsePostgreSQLLSN(slotLSN)
+	if err != nil {
+		return 0, err
+	}
+	if slot >= current {
+		return 0, nil
+	}
+	return float64(current - slot), nil
+}
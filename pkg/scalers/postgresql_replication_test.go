@@ -0,0 +1,114 @@
+package scalers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+type postgreSQLReplicationSlotLagTestData struct {
+	currentLSN  string
+	slotLSN     string
+	lag         float64
+	raisesError bool
+}
+
+var testPostgreSQLReplicationSlotLag = []postgreSQLReplicationSlotLagTestData{
+	{currentLSN: "0/3000000", slotLSN: "0/3000000", lag: 0},
+	{currentLSN: "0/3000100", slotLSN: "0/3000000", lag: 256},
+	// the difference crosses the 32 bit boundary of the lower half
+	{currentLSN: "1/10", slotLSN: "0/FFFFFFF0", lag: 32},
+	{currentLSN: "16/B374D848", slotLSN: "16/B3740000", lag: 55368},
+	// slot ahead of a lagging standby
+	{currentLSN: "0/3000000", slotLSN: "0/3000100", lag: 0},
+	{currentLSN: "3000000", slotLSN: "0/3000000", raisesError: true},
+	{currentLSN: "0/3000000", slotLSN: "0/XYZ", raisesError: true},
+	{currentLSN: "100000000/0", slotLSN: "0/0", raisesError: true},
+}
+
+var testPostgreSQLReplicationSlotLagMetadata = []parsePostgresMetadataTestData{
+	// metricMode replicationSlotLag
+	{
+		metadata:    map[string]string{"metricMode": "replicationSlotLag", "slotName": "debezium", "targetQueryValue": "1048576"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		resolvedEnv: map[string]string{},
+		raisesError: false,
+	},
+	// metricMode replicationSlotLag without slotName
+	{
+		metadata:    map[string]string{"metricMode": "replicationSlotLag", "targetQueryValue": "1048576"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		resolvedEnv: map[string]string{},
+		raisesError: true,
+	},
+	// metricMode replicationSlotLag with query
+	{
+		metadata:    map[string]string{"metricMode": "replicationSlotLag", "slotName": "debezium", "query": "query", "targetQueryValue": "1048576"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		resolvedEnv: map[string]string{},
+		raisesError: true,
+	},
+	// metricMode replicationSlotLag with dialect cockroach
+	{
+		metadata:    map[string]string{"metricMode": "replicationSlotLag", "slotName": "debezium", "targetQueryValue": "1048576", "dialect": "cockroach"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		resolvedEnv: map[string]string{},
+		raisesError: true,
+	},
+	// slotName without metricMode replicationSlotLag
+	{
+		metadata:    map[string]string{"query": "query", "slotName": "debezium", "targetQueryValue": "5"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		resolvedEnv: map[string]string{},
+		raisesError: true,
+	},
+}
+
+func TestParsePostgreSQLReplicationSlotLagMetadata(t *testing.T) {
+	testParsePostgreSQLMetadata(t, testPostgreSQLReplicationSlotLagMetadata)
+}
+
+func TestPostgreSQLReplicationSlotLag(t *testing.T) {
+	for _, testData := range testPostgreSQLReplicationSlotLag {
+		lag, err := computePostgreSQLReplicationSlotLag(testData.currentLSN, testData.slotLSN)
+		if testData.raisesError {
+			if err == nil {
+				t.Errorf("Expected error for %s - %s but got success", testData.currentLSN, testData.slotLSN)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Unexpected error for %s - %s: %s", testData.currentLSN, testData.slotLSN, err)
+		}
+		if lag != testData.lag {
+			t.Errorf("Expected lag %v for %s - %s but got %v", testData.lag, testData.currentLSN, testData.slotLSN, lag)
+		}
+	}
+}
+
+func TestPostgreSQLReplicationSlotLagQuery(t *testing.T) {
+	scaler, mock := newPostgreSQLMockScaler(t, &ScalerConfig{
+		TriggerMetadata: map[string]string{"metricMode": "replicationSlotLag", "slotName": "debezium", "targetQueryValue": "1048576"},
+		AuthParams:      map[string]string{"connection": "host=localhost"},
+	})
+	columns := []string{"current", "slot"}
+	mock.ExpectQuery("FROM pg_replication_slots").WithArgs("debezium").WillReturnRows(sqlmock.NewRows(columns).AddRow("0/3200000", "0/3000000"))
+	// the slot was dropped or not created yet
+	mock.ExpectQuery("FROM pg_replication_slots").WithArgs("debezium").WillReturnRows(sqlmock.NewRows(columns))
+	// the slot never reserved WAL
+	mock.ExpectQuery("FROM pg_replication_slots").WithArgs("debezium").WillReturnRows(sqlmock.NewRows(columns).AddRow("0/3200000", nil))
+
+	for _, expected := range []int64{2097152, 0, 0} {
+		metrics, err := scaler.GetMetrics(context.Background(), "s0-postgresql")
+		if err != nil {
+			t.Fatal("Unexpected error getting metrics:", err)
+		}
+		if metrics[0].Value.Value() != expected {
+			t.Errorf("Expected metric value %d but got %d", expected, metrics[0].Value.Value())
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,112 @@
+package scalers
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// parsePostgreSQLResultFormatMetadata selects the parser of the resultFormat the query returns
+func parsePostgreSQLResultFormatMetadata(config *ScalerConfig, meta *postgreSQLMetadata) error {
+	if val, ok := config.TriggerMetadata["resultFormat"]; ok && val != "" {
+		parser, ok := postgreSQLResultParsers[val]
+		if !ok {
+			return fmt.Errorf("unknown resultFormat %s, must be one of %s", val, strings.Join(getPostgreSQLResultFormats(), ", "))
+		}
+		if (meta.metricMode != postgreSQLMetricModeAbsolute && meta.metricMode != postgreSQLMetricModeRate) || meta.estimateMode {
+			return fmt.Errorf("resultFormat can only be used with metricMode %s or %s without estimateMode", postgreSQLMetricModeAbsolute, postgreSQLMetricModeRate)
+		}
+		if meta.valueExpression != nil || meta.valueType == postgreSQLValueTypeInteger {
+			return fmt.Errorf("resultFormat can't be combined with valueExpression or valueType %s", postgreSQLValueTypeInteger)
+		}
+		meta.resultParser = parser
+	}
+	return nil
+}
+
+// postgreSQLResultParser converts the trimmed text of the result column into the metric value
+type postgreSQLResultParser func(raw string) (float64, error)
+
+// postgreSQLResultParsers are the parsers which can be selected with resultFormat. Without resultFormat
+// the result is a number or an interval
+var postgreSQLResultParsers = map[string]postgreSQLResultParser{
+	"decimal":  parsePostgreSQLDecimalResult,
+	"hex":      parsePostgreSQLHexResult,
+	"base64":   parsePostgreSQLBase64Result,
+	"bool":     parsePostgreSQLBoolResult,
+	"interval": parsePostgreSQLInterval,
+}
+
+// getPostgreSQLResultFormats returns the names of the registered parsers in a stable order for error messages
+func getPostgreSQLResultFormats() []string {
+	formats := make([]string, 0, len(postgreSQLResultParsers))
+	for format := range postgreSQLResultParsers {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+func parsePostgreSQLDecimalResult(raw string) (float64, error) {
+	number, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return 0, fmt.Errorf("query result %q is not a decimal number", raw)
+	}
+	return number, nil
+}
+
+// parsePostgreSQLHexResult parses a hexadecimal integer with an optional sign and 0x prefix, e.g. the text
+// of a bit string or an id stored as hex
+func parsePostgreSQLHexResult(raw string) (float64, error) {
+	digits := raw
+	sign := 1.0
+	switch {
+	case strings.HasPrefix(digits, "-"):
+		sign = -1
+		digits = digits[1:]
+	case strings.HasPrefix(digits, "+"):
+		digits = digits[1:]
+	}
+	digits = strings.TrimPrefix(strings.TrimPrefix(digits, "0x"), "0X")
+	number, err := strconv.ParseUint(digits, 16, 64)
+	if err != nil {
+		return 0, fmt.Errorf("query result %q is not a hexadecimal number", raw)
+	}
+	return sign * float64(number), nil
+}
+
+// parsePostgreSQLBase64Result decodes a base64 encoded decimal number, e.g. the result of encode(..., 'base64')
+func parsePostgreSQLBase64Result(raw string) (float64, error) {
+	decoded, err := base64.StdEncoding.DecodeString(raw)
+	if err != nil {
+		return 0, fmt.Errorf("query result %q is not base64: %s", raw, err)
+	}
+	number, err := strconv.ParseFloat(strings.TrimSpace(string(decoded)), 64)
+	if err != nil {
+		return 0, fmt.Errorf("decoded query result %q is not a decimal number", decoded)
+	}
+	return number, nil
+}
+
+// parsePostgreSQLBoolResult reports true as 1 and false as 0, accepting the spellings of the boolean type
+func parsePostgreSQLBoolResult(raw string) (float64, error) {
+	switch strings.ToLower(raw) {
+	case "t", "true", "y", "yes", "on", "1":
+		return 1, nil
+	case "f", "false", "n", "no", "off", "0":
+		return 0, nil
+	}
+	return 0, fmt.Errorf("query result %q is not a boolean", raw)
+}
+
+// parsePostgreSQLFormattedResultValue converts the query result with parser, the default number or
+// interval handling if it's nil. NULL is reported as nullValue
+func parsePostgreSQLFormattedResultValue(value sql.NullString, nullValue float64, parser postgreSQLResultParser) (float64, error) {
+	if parser == nil || !value.Valid {
+		return parsePostgreSQLResultValue(value, nullValue)
+	}
+	return parser(strings.TrimSpace(value.String))
+}
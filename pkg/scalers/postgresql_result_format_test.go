@@ -0,0 +1,117 @@
+package scalers
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+type postgreSQLResultFormatTestData struct {
+	format      string
+	raw         string
+	expected    float64
+	raisesError bool
+}
+
+var testPostgreSQLResultFormats = []postgreSQLResultFormatTestData{
+	{format: "decimal", raw: "42.5", expected: 42.5},
+	{format: "decimal", raw: "-3", expected: -3},
+	// an interval isn't taken for a number
+	{format: "decimal", raw: "00:00:05", raisesError: true},
+	{format: "hex", raw: "ff", expected: 255},
+	{format: "hex", raw: "0x1A", expected: 26},
+	{format: "hex", raw: "-0x10", expected: -16},
+	{format: "hex", raw: "0xzz", raisesError: true},
+	// base64 of "1234" and " 7.5\n"
+	{format: "base64", raw: "MTIzNA==", expected: 1234},
+	{format: "base64", raw: "IDcuNQo=", expected: 7.5},
+	{format: "base64", raw: "not base64!", raisesError: true},
+	// base64 of "abc"
+	{format: "base64", raw: "YWJj", raisesError: true},
+	{format: "bool", raw: "t", expected: 1},
+	{format: "bool", raw: "TRUE", expected: 1},
+	{format: "bool", raw: "off", expected: 0},
+	{format: "bool", raw: "0", expected: 0},
+	{format: "bool", raw: "maybe", raisesError: true},
+	{format: "interval", raw: "1 day 00:00:30", expected: 86430},
+	{format: "interval", raw: "PT2M", expected: 120},
+	{format: "interval", raw: "42", raisesError: true},
+}
+
+var testPostgreSQLResultFormatMetadata = []parsePostgresMetadataTestData{
+	// resultFormat
+	{
+		metadata:   map[string]string{"query": "select 1", "targetQueryValue": "1", "resultFormat": "base64"},
+		authParams: map[string]string{"connection": "test_connection_string"},
+	},
+	// unknown resultFormat
+	{
+		metadata:    map[string]string{"query": "select 1", "targetQueryValue": "1", "resultFormat": "roman"},
+		authParams:  map[string]string{"connection": "test_connection_string"},
+		raisesError: true,
+	},
+	// resultFormat with metricMode age
+	{
+		metadata:    map[string]string{"query": "select 1", "targetQueryValue": "1", "resultFormat": "hex", "metricMode": "age"},
+		authParams:  map[string]string{"connection": "test_connection_string"},
+		raisesError: true,
+	},
+}
+
+func TestParsePostgreSQLResultFormatMetadata(t *testing.T) {
+	testParsePostgreSQLMetadata(t, testPostgreSQLResultFormatMetadata)
+}
+
+func TestPostgreSQLResultFormats(t *testing.T) {
+	tested := map[string]bool{}
+	for _, testData := range testPostgreSQLResultFormats {
+		tested[testData.format] = true
+		value, err := parsePostgreSQLFormattedResultValue(sql.NullString{String: testData.raw, Valid: true}, 0, postgreSQLResultParsers[testData.format])
+		if testData.raisesError {
+			if err == nil {
+				t.Errorf("%s %q: expected error but got %v", testData.format, testData.raw, value)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s %q: unexpected error %s", testData.format, testData.raw, err)
+			continue
+		}
+		if value != testData.expected {
+			t.Errorf("%s %q: expected %v but got %v", testData.format, testData.raw, testData.expected, value)
+		}
+	}
+	for _, format := range getPostgreSQLResultFormats() {
+		if !tested[format] {
+			t.Errorf("resultFormat %s isn't tested", format)
+		}
+	}
+
+	// NULL is the default value of every format
+	for _, format := range getPostgreSQLResultFormats() {
+		value, err := parsePostgreSQLFormattedResultValue(sql.NullString{}, 3, postgreSQLResultParsers[format])
+		if err != nil || value != 3 {
+			t.Errorf("%s NULL: expected 3 but got %v, %v", format, value, err)
+		}
+	}
+}
+
+func TestPostgreSQLResultFormatQuery(t *testing.T) {
+	scaler, mock := newPostgreSQLMockScaler(t, &ScalerConfig{
+		TriggerMetadata: map[string]string{"query": "SELECT pending_hex FROM counters", "targetQueryValue": "5", "resultFormat": "hex"},
+		AuthParams:      map[string]string{"connection": "host=localhost"},
+	})
+	mock.ExpectQuery("SELECT pending_hex").WillReturnRows(sqlmock.NewRows([]string{"pending_hex"}).AddRow("0x2a"))
+	value, err := scaler.getActiveNumber(context.Background())
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if value != 42 {
+		t.Errorf("Expected 42 but got %v", value)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
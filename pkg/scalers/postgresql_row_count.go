@@ -0,0 +1,81 @@
+package scalers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+)
+
+const (
+	// postgreSQLOnMaxRowsError fails a read whose query returns more than maxRows rows
+	postgreSQLOnMaxRowsError = "error"
+	// postgreSQLOnMaxRowsPartial reports maxRows for a query returning more rows
+	postgreSQLOnMaxRowsPartial = "partial"
+)
+
+// postgreSQLRowCountMetadataKeys are the settings of metricMode rowCount
+var postgreSQLRowCountMetadataKeys = []string{"maxRows", "onMaxRows"}
+
+// parsePostgreSQLRowCountMetadata parses the cap on the rows metricMode rowCount iterates. Without maxRows every
+// row is counted, however many the query returns
+func parsePostgreSQLRowCountMetadata(config *ScalerConfig, meta *postgreSQLMetadata) error {
+	if meta.metricMode != postgreSQLMetricModeRowCount {
+		for _, key := range postgreSQLRowCountMetadataKeys {
+			if _, ok := config.TriggerMetadata[key]; ok {
+				return fmt.Errorf("%s can only be used with metricMode %s", key, postgreSQLMetricModeRowCount)
+			}
+		}
+		return nil
+	}
+
+	if val, ok := config.TriggerMetadata["maxRows"]; ok && val != "" {
+		maxRows, err := strconv.Atoi(val)
+		if err != nil {
+			return fmt.Errorf("maxRows parsing error %s", err.Error())
+		}
+		if maxRows <= 0 {
+			return fmt.Errorf("maxRows must be positive, got %d", maxRows)
+		}
+		meta.maxRows = maxRows
+	}
+
+	meta.onMaxRows = postgreSQLOnMaxRowsError
+	if val, ok := config.TriggerMetadata["onMaxRows"]; ok && val != "" {
+		if meta.maxRows == 0 {
+			return fmt.Errorf("onMaxRows can only be used with maxRows")
+		}
+		switch val {
+		case postgreSQLOnMaxRowsError, postgreSQLOnMaxRowsPartial:
+			meta.onMaxRows = val
+		default:
+			return fmt.Errorf("unknown onMaxRows %s, must be one of %s, %s", val, postgreSQLOnMaxRowsError, postgreSQLOnMaxRowsPartial)
+		}
+	}
+	return nil
+}
+
+// queryRowCount counts the rows of the query. It stops iterating after maxRows rows, so a runaway query
+// neither keeps the scaler reading nor holds the rest of its result set open
+func (s *postgreSQLScaler) queryRowCount(ctx context.Context, connection postgreSQLQuerier) (float64, error) {
+	rows, err := connection.QueryContext(ctx, s.getQuery(), s.metadata.queryArgs...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	count := 0
+	for rows.Next() {
+		if s.metadata.maxRows > 0 && count == s.metadata.maxRows {
+			if s.metadata.onMaxRows == postgreSQLOnMaxRowsPartial {
+				s.logger.V(1).Info("postgreSQL query returned more rows than maxRows, reporting maxRows", "maxRows", s.metadata.maxRows)
+				return float64(count), nil
+			}
+			return 0, fmt.Errorf("query returned more than maxRows %d rows", s.metadata.maxRows)
+		}
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	return float64(count), nil
+}
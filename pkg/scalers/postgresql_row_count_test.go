@@ -0,0 +1,101 @@
+package scalers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestPostgreSQLRowCountMetadata(t *testing.T) {
+	testData := []struct {
+		name        string
+		metadata    map[string]string
+		maxRows     int
+		onMaxRows   string
+		raisesError bool
+	}{
+		{name: "no cap", metadata: map[string]string{}, onMaxRows: postgreSQLOnMaxRowsError},
+		{name: "cap failing", metadata: map[string]string{"maxRows": "1000"}, maxRows: 1000, onMaxRows: postgreSQLOnMaxRowsError},
+		{name: "cap reporting partial count", metadata: map[string]string{"maxRows": "1000", "onMaxRows": "partial"}, maxRows: 1000, onMaxRows: postgreSQLOnMaxRowsPartial},
+		{name: "zero maxRows", metadata: map[string]string{"maxRows": "0"}, raisesError: true},
+		{name: "unparseable maxRows", metadata: map[string]string{"maxRows": "many"}, raisesError: true},
+		{name: "unknown onMaxRows", metadata: map[string]string{"maxRows": "10", "onMaxRows": "truncate"}, raisesError: true},
+		{name: "onMaxRows without maxRows", metadata: map[string]string{"onMaxRows": "partial"}, raisesError: true},
+		{name: "maxRows without metricMode rowCount", metadata: map[string]string{"metricMode": "absolute", "maxRows": "10"}, raisesError: true},
+	}
+	for _, testData := range testData {
+		t.Run(testData.name, func(t *testing.T) {
+			metadata := map[string]string{"metricMode": "rowCount", "query": "SELECT id FROM jobs", "targetQueryValue": "5"}
+			for key, value := range testData.metadata {
+				metadata[key] = value
+			}
+			meta, err := parsePostgreSQLMetadata(&ScalerConfig{TriggerMetadata: metadata, AuthParams: map[string]string{"connection": "host=localhost"}})
+			if testData.raisesError {
+				if err == nil {
+					t.Error("Expected error but got success")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal("Unexpected error:", err)
+			}
+			if meta.maxRows != testData.maxRows || meta.onMaxRows != testData.onMaxRows {
+				t.Errorf("Expected maxRows %d and onMaxRows %s but got %d and %s", testData.maxRows, testData.onMaxRows, meta.maxRows, meta.onMaxRows)
+			}
+		})
+	}
+}
+
+func TestPostgreSQLRowCount(t *testing.T) {
+	testData := []struct {
+		name        string
+		metadata    map[string]string
+		expected    int64
+		raisesError bool
+	}{
+		{name: "no cap reads every row", metadata: map[string]string{}, raisesError: true},
+		{name: "cap above the result reads every row", metadata: map[string]string{"maxRows": "10"}, raisesError: true},
+		{name: "cap failing", metadata: map[string]string{"maxRows": "3"}, raisesError: true},
+		{name: "cap reporting partial count", metadata: map[string]string{"maxRows": "3", "onMaxRows": "partial"}, expected: 3},
+	}
+	for _, testData := range testData {
+		t.Run(testData.name, func(t *testing.T) {
+			metadata := map[string]string{"metricMode": "rowCount", "query": "SELECT id FROM jobs", "targetQueryValue": "5"}
+			for key, value := range testData.metadata {
+				metadata[key] = value
+			}
+			scaler, mock := newPostgreSQLMockScaler(t, &ScalerConfig{TriggerMetadata: metadata, AuthParams: map[string]string{"connection": "host=localhost"}})
+
+			// the fifth row fails, so only a read which stops at the cap succeeds
+			rows := sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2).AddRow(3).AddRow(4).AddRow(5).RowError(4, errors.New("out of memory"))
+			mock.ExpectQuery("SELECT id FROM jobs").WillReturnRows(rows)
+			value, err := scaler.getActiveNumber(context.Background())
+			if testData.raisesError {
+				if err == nil {
+					t.Error("Expected error but got success")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal("Unexpected error:", err)
+			}
+			if int64(value) != testData.expected {
+				t.Errorf("Expected %d rows but got %v", testData.expected, value)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+
+	scaler, mock := newPostgreSQLMockScaler(t, &ScalerConfig{
+		TriggerMetadata: map[string]string{"metricMode": "rowCount", "query": "SELECT id FROM jobs", "targetQueryValue": "5", "maxRows": "10"},
+		AuthParams:      map[string]string{"connection": "host=localhost"},
+	})
+	mock.ExpectQuery("SELECT id FROM jobs").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2))
+	if value, err := scaler.getActiveNumber(context.Background()); err != nil || value != 2 {
+		t.Errorf("Expected 2 rows within the cap but got %v (%v)", value, err)
+	}
+}
@@ -0,0 +1,87 @@
+package scalers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// postgreSQLSampleMethodSystem samples whole pages, cheap but less accurate for clustered rows
+	postgreSQLSampleMethodSystem = "system"
+	// postgreSQLSampleMethodBernoulli samples single rows, it reads the whole table but skips evaluating most rows
+	postgreSQLSampleMethodBernoulli = "bernoulli"
+)
+
+// postgreSQLSampledCountMetadataKeys are the settings of metricMode sampledCount besides the table
+var postgreSQLSampledCountMetadataKeys = []string{"samplePercent", "sampleMethod", "sampleFilter"}
+
+// parsePostgreSQLSampledCountMetadata builds the query of metricMode sampledCount counting a sample of
+// samplePercent of the table
+func parsePostgreSQLSampledCountMetadata(config *ScalerConfig, meta *postgreSQLMetadata) error {
+	if meta.metricMode != postgreSQLMetricModeSampledCount {
+		for _, key := range postgreSQLSampledCountMetadataKeys {
+			if _, ok := config.TriggerMetadata[key]; ok {
+				return fmt.Errorf("%s can only be used with metricMode %s", key, postgreSQLMetricModeSampledCount)
+			}
+		}
+		return nil
+	}
+	if meta.dialect == postgreSQLDialectCockroach {
+		return fmt.Errorf("metricMode %s can't be used with dialect %s", meta.metricMode, meta.dialect)
+	}
+	table := config.TriggerMetadata["table"]
+	if table == "" {
+		return fmt.Errorf("metricMode %s requires table", meta.metricMode)
+	}
+	samplePercent, err := strconv.ParseFloat(config.TriggerMetadata["samplePercent"], 64)
+	if err != nil {
+		return fmt.Errorf("samplePercent parsing error %s", err.Error())
+	}
+	if samplePercent <= 0 || samplePercent > 100 {
+		return fmt.Errorf("samplePercent must be greater than 0 and at most 100, got %v", samplePercent)
+	}
+	method := postgreSQLSampleMethodSystem
+	if val := config.TriggerMetadata["sampleMethod"]; val != "" {
+		if val != postgreSQLSampleMethodSystem && val != postgreSQLSampleMethodBernoulli {
+			return fmt.Errorf("unknown sampleMethod %s, must be one of %s, %s", val, postgreSQLSampleMethodSystem, postgreSQLSampleMethodBernoulli)
+		}
+		method = val
+	}
+	meta.query, err = buildPostgreSQLSampledCountQuery(table, method, config.TriggerMetadata["sampleFilter"])
+	if err != nil {
+		return err
+	}
+	meta.samplePercent = samplePercent
+	meta.queryArgs = []interface{}{samplePercent}
+	return nil
+}
+
+// buildPostgreSQLSampledCountQuery returns the query counting a sample of $1 percent of the rows of table
+// matching filter, if there is one
+func buildPostgreSQLSampledCountQuery(table, method, filter string) (string, error) {
+	quotedTable, err := quotePostgreSQLTable(table)
+	if err != nil {
+		return "", err
+	}
+	query := fmt.Sprintf("SELECT count(*) FROM %s TABLESAMPLE %s ($1::real)", quotedTable, strings.ToUpper(method))
+	if filter != "" {
+		query += " WHERE " + filter
+	}
+	return query, nil
+}
+
+// extrapolatePostgreSQLSample returns the estimated count of all rows from the count of a sample of percent
+func extrapolatePostgreSQLSample(sampled, percent float64) float64 {
+	return sampled * 100 / percent
+}
+
+// querySampledCount counts the sample and extrapolates it to the whole table
+func (s *postgreSQLScaler) querySampledCount(ctx context.Context, connection postgreSQLQuerier) (float64, error) {
+	var sampled float64
+	if err := connection.QueryRowContext(ctx, s.metadata.query, s.metadata.queryArgs...).Scan(&sampled); err != nil {
+		return 0, err
+	}
+	return extrapolatePostgreSQLSample(sampled, s.metadata.samplePercent), nil
+}
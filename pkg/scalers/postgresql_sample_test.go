@@ -0,0 +1,133 @@
+package scalers
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+var testPostgreSQLSampledCountMetadata = []parsePostgresMetadataTestData{
+	// metricMode sampledCount
+	{
+		metadata:    map[string]string{"metricMode": "sampledCount", "table": "jobs", "samplePercent": "1", "sampleMethod": "bernoulli", "targetQueryValue": "12"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		resolvedEnv: map[string]string{},
+		raisesError: false,
+	},
+	// metricMode sampledCount without samplePercent
+	{
+		metadata:    map[string]string{"metricMode": "sampledCount", "table": "jobs", "targetQueryValue": "12"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		resolvedEnv: map[string]string{},
+		raisesError: true,
+	},
+	// metricMode sampledCount with samplePercent above 100
+	{
+		metadata:    map[string]string{"metricMode": "sampledCount", "table": "jobs", "samplePercent": "150", "targetQueryValue": "12"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		resolvedEnv: map[string]string{},
+		raisesError: true,
+	},
+	// metricMode sampledCount with unknown sampleMethod
+	{
+		metadata:    map[string]string{"metricMode": "sampledCount", "table": "jobs", "samplePercent": "1", "sampleMethod": "random", "targetQueryValue": "12"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		resolvedEnv: map[string]string{},
+		raisesError: true,
+	},
+	// metricMode sampledCount with query
+	{
+		metadata:    map[string]string{"metricMode": "sampledCount", "table": "jobs", "samplePercent": "1", "query": "SELECT 1", "targetQueryValue": "12"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		resolvedEnv: map[string]string{},
+		raisesError: true,
+	},
+	// metricMode sampledCount with dialect cockroach
+	{
+		metadata:    map[string]string{"metricMode": "sampledCount", "table": "jobs", "samplePercent": "1", "dialect": "cockroach", "targetQueryValue": "12"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		resolvedEnv: map[string]string{},
+		raisesError: true,
+	},
+	// samplePercent outside metricMode sampledCount
+	{
+		metadata:    map[string]string{"query": "query", "samplePercent": "1", "targetQueryValue": "12"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		resolvedEnv: map[string]string{},
+		raisesError: true,
+	},
+}
+
+func TestParsePostgreSQLSampledCountMetadata(t *testing.T) {
+	testParsePostgreSQLMetadata(t, testPostgreSQLSampledCountMetadata)
+}
+
+func TestExtrapolatePostgreSQLSample(t *testing.T) {
+	testData := []struct {
+		sampled  float64
+		percent  float64
+		expected float64
+	}{
+		{sampled: 0, percent: 1, expected: 0},
+		{sampled: 12, percent: 1, expected: 1200},
+		{sampled: 25, percent: 10, expected: 250},
+		{sampled: 3, percent: 0.5, expected: 600},
+		{sampled: 40, percent: 100, expected: 40},
+	}
+
+	for _, testData := range testData {
+		if value := extrapolatePostgreSQLSample(testData.sampled, testData.percent); value != testData.expected {
+			t.Errorf("Expected %v for %v sampled rows at %v%% but got %v", testData.expected, testData.sampled, testData.percent, value)
+		}
+	}
+}
+
+func TestBuildPostgreSQLSampledCountQuery(t *testing.T) {
+	testData := []struct {
+		table       string
+		method      string
+		filter      string
+		expected    string
+		raisesError bool
+	}{
+		{table: "jobs", method: "system", expected: `SELECT count(*) FROM "jobs" TABLESAMPLE SYSTEM ($1::real)`},
+		{table: "queue.jobs", method: "bernoulli", filter: "state = 'pending'", expected: `SELECT count(*) FROM "queue"."jobs" TABLESAMPLE BERNOULLI ($1::real) WHERE state = 'pending'`},
+		{table: "a.b.c", method: "system", raisesError: true},
+	}
+
+	for _, testData := range testData {
+		query, err := buildPostgreSQLSampledCountQuery(testData.table, testData.method, testData.filter)
+		if err != nil && !testData.raisesError {
+			t.Errorf("Expected success for table %s but got error %s", testData.table, err)
+		}
+		if err == nil && testData.raisesError {
+			t.Errorf("Expected error for table %s but got success", testData.table)
+		}
+		if err == nil && query != testData.expected {
+			t.Errorf("Expected query %s but got %s", testData.expected, query)
+		}
+	}
+}
+
+func TestPostgreSQLScalerSampledCount(t *testing.T) {
+	scaler, mock := newPostgreSQLMockScaler(t, &ScalerConfig{
+		TriggerMetadata: map[string]string{"metricMode": "sampledCount", "table": "jobs", "samplePercent": "2", "sampleFilter": "state = 'pending'", "targetQueryValue": "100"},
+		AuthParams:      map[string]string{"connection": "host=localhost"},
+	})
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "jobs" TABLESAMPLE SYSTEM ($1::real) WHERE state = 'pending'`)).
+		WithArgs(2.0).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(37))
+	value, err := scaler.getActiveNumber(context.Background())
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if value != 1850 {
+		t.Errorf("Expected extrapolated value 1850 but got %v", value)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -3,36 +3,493 @@ package scalers
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"math/rand"
+	"net"
+	"os"
+	"regexp"
+	"sort"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
 
 	"github.com/go-logr/logr"
-	// PostreSQL drive required for this scaler
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 	v2 "k8s.io/api/autoscaling/v2"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/metrics/pkg/apis/external_metrics"
 
 	kedautil "github.com/kedacore/keda/v2/pkg/util"
 )
 
+// postgreSQLTLSFileParams are the connection string parameters that reference
+// TLS files on disk, which get watched for rotation
+var postgreSQLTLSFileParams = []string{"sslrootcert", "sslcert", "sslkey"}
+
+const (
+	// postgreSQLMetricModeAbsolute reports the result of the user provided query
+	postgreSQLMetricModeAbsolute = "absolute"
+	// postgreSQLMetricModeRate reports the per-second change of the query result between readings
+	postgreSQLMetricModeRate = "rate"
+	// postgreSQLMetricModeAge reports the seconds since the timestamp returned by the query
+	postgreSQLMetricModeAge = "age"
+	// postgreSQLMetricModeQuery is the former name of postgreSQLMetricModeAbsolute, still accepted
+	postgreSQLMetricModeQuery = "query"
+	// postgreSQLMetricModeConnectionSaturation reports the fraction of max_connections in use
+	postgreSQLMetricModeConnectionSaturation = "connectionSaturation"
+	// postgreSQLMetricModeRowCount reports the number of rows returned by the query, capped by maxRows
+	postgreSQLMetricModeRowCount = "rowCount"
+	// postgreSQLMetricModeReplicationSlotLag reports the bytes of WAL retained for the replication slot slotName
+	postgreSQLMetricModeReplicationSlotLag = "replicationSlotLag"
+	// postgreSQLMetricModeWindowCount reports the rows of table whose timestampColumn is within the last windowSeconds
+	postgreSQLMetricModeWindowCount = "windowCount"
+	// postgreSQLMetricModeSampledCount reports the row count of table extrapolated from a TABLESAMPLE of samplePercent
+	postgreSQLMetricModeSampledCount = "sampledCount"
+	// postgreSQLMetricModeAnyOf reports how far the result of countQuery or the age returned by ageQuery exceed
+	// their thresholds, so the trigger activates as soon as either does
+	postgreSQLMetricModeAnyOf = "anyOf"
+	// postgreSQLMetricModeTableSize reports the bytes of tableName including its indexes, e.g. to scale maintenance workers
+	postgreSQLMetricModeTableSize = "tableSize"
+	// postgreSQLMetricModeIdleInTransaction reports the sessions idle in transaction, e.g. to scale a job cleaning up stuck clients
+	postgreSQLMetricModeIdleInTransaction = "idleInTransaction"
+	// postgreSQLMetricModeWALRate reports the bytes of WAL generated per second between readings
+	postgreSQLMetricModeWALRate = "walRate"
+	// postgreSQLMetricModeBlockedQueries reports the number of sessions waiting for a lock
+	postgreSQLMetricModeBlockedQueries = "blockedQueries"
+)
+
+const (
+	postgreSQLActivationOperatorGreater        = "gt"
+	postgreSQLActivationOperatorGreaterOrEqual = "gte"
+	postgreSQLActivationOperatorLess           = "lt"
+	postgreSQLActivationOperatorLessOrEqual    = "lte"
+)
+
+const (
+	// postgreSQLOnErrorFail returns the error of a failed read
+	postgreSQLOnErrorFail = "fail"
+	// postgreSQLOnErrorLastValue returns the last successfully read value instead of an error
+	postgreSQLOnErrorLastValue = "lastValue"
+)
+
+// maxPostgreSQLFirstQueryJitter bounds how long the first query can be delayed,
+// so a misconfigured jitter can't stall scaling decisions
+const maxPostgreSQLFirstQueryJitter = time.Minute
+
+// defaultPostgreSQLNotifyTimeout is how long a value pushed through notifyChannel is served
+// before the scaler falls back to running the query
+const defaultPostgreSQLNotifyTimeout = 30 * time.Second
+
+// postgreSQLQueryValidationTimeout bounds the query run by validateQueryOnCreate
+const postgreSQLQueryValidationTimeout = 30 * time.Second
+
+const (
+	// maxPostgreSQLConnectRetries bounds the initial ping retries, with the doubling interval
+	// a higher number would block the creation of the scaler for too long
+	maxPostgreSQLConnectRetries           = 10
+	defaultPostgreSQLConnectRetryInterval = time.Second
+	// maxPostgreSQLConnectRetryInterval caps the doubled wait before a retry
+	maxPostgreSQLConnectRetryInterval = 30 * time.Second
+	// maxPostgreSQLConnectRetryWait bounds the time waited for retries in total, connecting blocks the
+	// reconciliation of the ScaledObject and the scale loop
+	maxPostgreSQLConnectRetryWait = 2 * time.Minute
+)
+
+// types the query result is scanned as
+const (
+	postgreSQLValueTypeFloat = "float"
+	// postgreSQLValueTypeInteger keeps integers above 2^53 exact, which float64 can't represent
+	postgreSQLValueTypeInteger = "integer"
+)
+
+// dialects of the PostgreSQL wire compatible databases, they only select the built-in queries
+const (
+	postgreSQLDialectPostgres  = "postgres"
+	postgreSQLDialectCockroach = "cockroach"
+	postgreSQLDialectYugabyte  = "yugabyte"
+)
+
+// postgreSQLConnectionSaturationQuery returns the client connections in use and the max_connections setting
+const postgreSQLConnectionSaturationQuery = `SELECT (SELECT count(*) FROM pg_stat_activity WHERE backend_type = 'client backend'), current_setting('max_connections')::int`
+
+// postgreSQLEncryptionQuery returns whether the session of the connection uses SSL
+const postgreSQLEncryptionQuery = `SELECT ssl FROM pg_stat_ssl WHERE pid = pg_backend_pid()`
+
+// postgreSQLCockroachConnectionSaturationQuery returns the client sessions of the gateway node and its
+// connection limit, CockroachDB has neither backend_type nor max_connections
+const postgreSQLCockroachConnectionSaturationQuery = `SELECT (SELECT count(*) FROM crdb_internal.node_sessions WHERE application_name NOT LIKE '$ internal%'), ` +
+	`(SELECT value::INT FROM crdb_internal.cluster_settings WHERE variable = 'server.max_connections_per_gateway')`
+
+// postgreSQLConnectionSaturationQueries are the connectionSaturation queries per dialect. YugabyteDB
+// YSQL reuses the PostgreSQL catalogs, so it shares the PostgreSQL query
+var postgreSQLConnectionSaturationQueries = map[string]string{
+	postgreSQLDialectPostgres:  postgreSQLConnectionSaturationQuery,
+	postgreSQLDialectCockroach: postgreSQLCockroachConnectionSaturationQuery,
+	postgreSQLDialectYugabyte:  postgreSQLConnectionSaturationQuery,
+}
+
+// postgreSQLExplainPlan is the part of the EXPLAIN (FORMAT JSON) output used for row estimates
+type postgreSQLExplainPlan struct {
+	NodeType string                  `json:"Node Type"`
+	Strategy string                  `json:"Strategy"`
+	PlanRows float64                 `json:"Plan Rows"`
+	Plans    []postgreSQLExplainPlan `json:"Plans"`
+}
+
+// postgreSQLSQLStatePattern matches the five character SQLSTATE error codes
+var postgreSQLSQLStatePattern = regexp.MustCompile(`^[0-9A-Z]{5}$`)
+
+// postgreSQLMetricDescriptionInvalidChars matches everything which isn't allowed in a metric description
+var postgreSQLMetricDescriptionInvalidChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// postgreSQLQuerySemaphores holds the semaphores shared by all scalers querying the same database
+// with the same maxConcurrentQueries, so scrape storms don't overload the database
+var (
+	postgreSQLQuerySemaphores      = map[postgreSQLQuerySemaphoreKey]*postgreSQLQuerySemaphore{}
+	postgreSQLQuerySemaphoresMutex sync.Mutex
+)
+
+type postgreSQLQuerySemaphoreKey struct {
+	connection string
+	limit      int
+}
+
+type postgreSQLQuerySemaphore struct {
+	key   postgreSQLQuerySemaphoreKey
+	slots chan struct{}
+	refs  int
+}
+
+// postgreSQLQuerier runs queries, it's implemented by both *sql.DB and *sql.Conn
+type postgreSQLQuerier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// postgreSQLConnectionOpener opens a database handle for the connection of the metadata
+type postgreSQLConnectionOpener func(meta *postgreSQLMetadata) (*sql.DB, error)
+
+// getPostgreSQLConnectionParameter reads a connection parameter from the auth params or the metadata,
+// falling back to the environment variable named by <field>FromEnv like the password does
+func getPostgreSQLConnectionParameter(config *ScalerConfig, field string) (string, error) {
+	value, err := GetFromAuthOrMeta(config, field)
+	if err == nil {
+		return value, nil
+	}
+	if envName := config.TriggerMetadata[field+"FromEnv"]; envName != "" {
+		if value := config.ResolvedEnv[envName]; value != "" {
+			return value, nil
+		}
+		return "", fmt.Errorf("no %s given, environment variable %s of %sFromEnv is empty", field, envName, field)
+	}
+	return "", err
+}
+
+// openPostgreSQLConnection opens the database handle with the connection and dialer of getPostgreSQLDriverConnection
+func openPostgreSQLConnection(meta *postgreSQLMetadata) (*sql.DB, error) {
+	connection, dialer, err := getPostgreSQLDriverConnection(meta)
+	if err != nil {
+		return nil, err
+	}
+	if dialer == nil {
+		return sql.Open("postgres", connection)
+	}
+	connector, err := pq.NewConnector(connection)
+	if err != nil {
+		return nil, err
+	}
+	connector.Dialer(dialer)
+	return sql.OpenDB(connector), nil
+}
+
+// getPostgreSQLDriverConnection returns the connection string passed to the driver and the dialer to connect
+// with, nil for the driver's own. With sslServerName or sslRevocationCheck the TLS connection is established
+// by the dialer, so the server certificate can be verified against that hostname and checked for revocation.
+// Otherwise with sslKeyPassword the decrypted client key is passed to the driver inline
+func getPostgreSQLDriverConnection(meta *postgreSQLMetadata) (string, pq.Dialer, error) {
+	if meta.sslServerName == "" && meta.sslKeyPassword == "" && meta.sslRevocationCheck == "" {
+		return meta.connection, nil, nil
+	}
+
+	params, err := parsePostgreSQLConnectionString(meta.connection)
+	if err != nil {
+		return "", nil, err
+	}
+	if meta.sslServerName == "" && meta.sslRevocationCheck == "" {
+		if err := inlinePostgreSQLTLSFiles(params, meta.sslKeyPassword); err != nil {
+			return "", nil, err
+		}
+		return formatPostgreSQLConnectionString(params), nil, nil
+	}
+	serverName := meta.sslServerName
+	if serverName == "" {
+		serverName = params["host"]
+	}
+	tlsConfig, err := newPostgreSQLTLSConfig(params, serverName, meta.sslKeyPassword)
+	if err != nil {
+		return "", nil, err
+	}
+	if meta.sslRevocationCheck == postgreSQLRevocationCheckOCSP {
+		tlsConfig.VerifyConnection = newPostgreSQLOCSPVerifier().verifyConnection
+	}
+	for _, param := range append(postgreSQLTLSFileParams, "sslsni") {
+		delete(params, param)
+	}
+	params["sslmode"] = "disable"
+	return formatPostgreSQLConnectionString(params), &postgreSQLTLSDialer{config: tlsConfig}, nil
+}
+
 type postgreSQLScaler struct {
-	metricType v2.MetricTargetType
-	metadata   *postgreSQLMetadata
-	connection *sql.DB
-	logger     logr.Logger
+	metricType  v2.MetricTargetType
+	metadata    *postgreSQLMetadata
+	connection  *postgreSQLPooledConnection
+	connections *postgreSQLConnectionPool
+	credentials postgreSQLCredentialProvider
+	// connectionMetadata is the metadata with the connection using the current credentials
+	connectionMetadata  *postgreSQLMetadata
+	credentialsExpireAt time.Time
+	tlsFileTimes        map[string]time.Time
+	// passwordFileModTime is the modification time of the passwordFromFile the connection's password was read at
+	passwordFileModTime time.Time
+	querySemaphore      *postgreSQLQuerySemaphore
+	// pinnedConnection is the connection of the reads with pinConnection, nil without it
+	pinnedConnection *postgreSQLPinnedConnection
+	// queryFile holds the query read from the queryFile, which is reloaded when it changes
+	queryFile *postgreSQLQueryFile
+	// serverVersionCheckedFor is the database handle whose server satisfied minServerVersion
+	serverVersionCheckedFor *sql.DB
+	// firstQueryAt delays the first query to spread the load of scalers created at the same time
+	firstQueryAt time.Time
+	// listener receives the values pushed with NOTIFY when notifyChannel is set
+	listener *pq.Listener
+	// listenerDone is closed to stop the goroutines of the listener
+	listenerDone  chan struct{}
+	notifiedValue float64
+	notifiedAt    time.Time
+	// circuitBreaker stops querying after circuitBreakerThreshold consecutive failures
+	circuitBreaker *postgreSQLCircuitBreaker
+	// lastValue is the last successfully read value, used by onError lastValue
+	lastValue    float64
+	lastValueAt  time.Time
+	hasLastValue bool
+	// rateTracker keeps the previous reading in metricMode rate and walRate
+	rateTracker postgreSQLRateTracker
+	// thresholdState tells whether the previous metric was above targetQueryValue
+	thresholdState postgreSQLThresholdState
+	// liveTarget is the target read by the last query with targetFromQuery, 0 if there is none
+	liveTarget float64
+	// integerValue is the exact result of the last query with valueType integer
+	integerValue int64
+	// valueTimestamp is the time timestampedValue refers to, read with timestampFromQuery
+	valueTimestamp   time.Time
+	timestampedValue float64
+	// recorder exports the query duration, value and errors
+	recorder *postgreSQLQueryRecorder
+	// liveness tracks the producerLivenessQuery results, producerStalled is the last outcome
+	liveness        *postgreSQLLivenessTracker
+	producerStalled bool
+	// quietPeriod keeps the trigger active for quietPeriodReads inactive reads, nil if it isn't set
+	quietPeriod *postgreSQLQuietPeriod
+	// startupActivation delays the first activation for startupActivationReads active reads, nil if it isn't set
+	startupActivation *postgreSQLStartupActivation
+	// logSampler limits the logging of repeated query errors, nil if errorLogInterval isn't set
+	logSampler *postgreSQLLogSampler
+	// inMaintenance is the result of the last maintenanceQuery
+	inMaintenance bool
+	// deadTupleCheck limits how often the statistics of deadTupleWarningTable are read
+	deadTupleCheck postgreSQLDeadTupleCheck
+	// ready is set after the first successful read and stays set
+	ready bool
+	// databases are the scalers of the additional connections, aggregated with the value of this scaler
+	databases []*postgreSQLScaler
+	// sharedPoller serves the value read by one of the scalers with the same query, nil without sharedPollingInterval
+	sharedPoller       *postgreSQLSharedPoller
+	sharedSubscription *postgreSQLPollFunc
+	mutex              sync.Mutex
+	// reconnectMutex serializes the reconnects, which don't hold mutex while connecting
+	reconnectMutex sync.Mutex
+	logger         logr.Logger
 }
 
 type postgreSQLMetadata struct {
+	metricMode string
+	// dialect selects the built-in queries of the metric modes, user queries are used as they are
+	dialect string
+	// samplePercent is the share of the table counted by metricMode sampledCount
+	samplePercent float64
+	// anyOf are the conditions of metricMode anyOf
+	anyOf *postgreSQLAnyOf
+	// slotName is the replication slot of metricMode replicationSlotLag
+	slotName                   string
 	targetQueryValue           float64
 	activationTargetQueryValue float64
-	connection                 string
-	query                      string
-	metricName                 string
-	scalerIndex                int
+	// capacityQuery returns the capacity targetQueryValue is a percentage of
+	capacityQuery string
+	// producerLivenessQuery returns the latest write timestamp, which has to advance within producerStallWindow
+	producerLivenessQuery string
+	producerStallWindow   time.Duration
+	// failOnProducerStall fails the query when producers stalled instead of only reporting it
+	failOnProducerStall bool
+	// targetFromQuery makes the second column of the query the target, targetQueryValue is the fallback
+	targetFromQuery bool
+	// activationOperator compares the value with activationTargetQueryValue, gt by default
+	activationOperator string
+	// metricPrecision is the number of decimal places the reported value is rounded to, at most 3 of a milli quantity.
+	// Without it the value is truncated to a milli quantity
+	metricPrecision    int
+	hasMetricPrecision bool
+	// activationQuery returns a boolean deciding the activation instead of the value, which then only scales
+	activationQuery string
+	connection      string
+	query           string
+	metricName      string
+	// metricLabels are attached to the reported metric values
+	metricLabels map[string]string
+	scalerIndex  int
+	// queryFile is the file query was read from at queryFileModTime
+	queryFile        string
+	queryFileModTime time.Time
+	// tlsFiles are the certificate and key files referenced by the connection
+	tlsFiles []string
+	// passwordFile is the file the password of the connection was read from at passwordFileModTime
+	passwordFile        string
+	passwordFileModTime time.Time
+	// connectRetries is how often the initial ping is retried, waiting connectRetryInterval doubled on every retry
+	connectRetries       int
+	connectRetryInterval time.Duration
+	// quietPeriodReads is the number of consecutive inactive reads before the trigger reports inactive
+	quietPeriodReads int
+	// startupActivationReads is the number of consecutive active reads before a new scaler activates the first time
+	startupActivationReads int
+	// connectionAcquireTimeout bounds waiting for a connection of the pool, establishing it included
+	connectionAcquireTimeout time.Duration
+	// queryTimeout bounds running the queries once a connection was acquired
+	queryTimeout time.Duration
+	// recordValueDistribution exports the distribution of the query values as Prometheus summary
+	recordValueDistribution bool
+	// recordTargetValue exports the target the query values are compared with as Prometheus gauge
+	recordTargetValue bool
+	// errorLogInterval is how often an error with the same message is logged at most
+	errorLogInterval time.Duration
+	// idleConnectionTimeout closes connections unused for that long, they are reopened by the next query
+	idleConnectionTimeout time.Duration
+	// connectionMaxLifetime recycles connections older than that. New connections resolve the host again,
+	// so the scaler follows DNS based failovers, e.g. of RDS, instead of staying on the old server
+	connectionMaxLifetime time.Duration
+	// validateQueryOnCreate runs the query once when the scaler is created
+	validateQueryOnCreate bool
+	// constantQueryCheck warns about or rejects a query which looks like a constant, empty disables the check
+	constantQueryCheck string
+	// credentialProvider names the postgreSQLCredentialProvider supplying the password
+	credentialProvider string
+	// eagerConnect pings the database at creation, otherwise only the connection syntax is validated
+	eagerConnect bool
+	// pinConnection runs every read in the same session instead of drawing a connection from the pool
+	pinConnection bool
+	// sslServerName is the hostname the server certificate is verified against, instead of the host
+	sslServerName string
+	// sslRevocationCheck checks the server certificate for revocation during the handshake
+	sslRevocationCheck string
+	// requireEncryption fails queries on sessions which aren't encrypted, e.g. after sslmode prefer fell back to plaintext
+	requireEncryption bool
+	// cancelAbandonedQueries cancels a query on the server through another connection once its context is done
+	cancelAbandonedQueries bool
+	// minServerVersion is the oldest server_version_num the query works with, 0 doesn't check it
+	minServerVersion int
+	// sslKeyPassword decrypts an encrypted sslkey
+	sslKeyPassword string
+	// maxConcurrentQueries limits the in-flight queries against the same database, 0 means unlimited
+	maxConcurrentQueries int
+	// firstQueryJitter is the upper bound of the random delay before the first query
+	firstQueryJitter time.Duration
+	// subtractSecondColumn reports the first column minus the second, e.g. pending minus recently started rows
+	subtractSecondColumn bool
+	// timestampFromQuery reads the time the value refers to from the last column of the query
+	timestampFromQuery bool
+	// expectedRange reports values outside of expectedMin and expectedMax, nil without them
+	expectedRange *postgreSQLExpectedRange
+	// valueType is the type the query result is scanned as
+	valueType string
+	// queryComment is prepended to the queries to attribute them to the ScaledObject, empty if queryCommentTag isn't set
+	queryComment string
+	// strictSingleRow fails queries returning more than one row instead of using the first one
+	strictSingleRow bool
+	// signedRate reports a decreasing query result as negative rate in metricMode rate
+	signedRate bool
+	// resultParser converts the query result selected with resultFormat, nil takes a number or an interval
+	resultParser postgreSQLResultParser
+	// defaultValueOnNoRows is reported when the query returns no rows or NULL, 0 unless configured
+	defaultValueOnNoRows float64
+	// estimateMode reports the planner's row estimate of the query instead of running it
+	estimateMode bool
+	// maintenanceQuery returns a boolean, while it's true the scaler reports an inactive value
+	maintenanceQuery string
+	// deadTupleWarningTable is the table whose share of dead tuples is checked, the check is off if it's empty
+	deadTupleWarningTable string
+	// deadTupleWarningRatio is the share of dead tuples above which a warning is logged
+	deadTupleWarningRatio float64
+	// queryArgs are bound to the positional parameters of the query
+	queryArgs []interface{}
+	// valueExpression computes the metric from the named columns of the query result
+	valueExpression postgreSQLExpression
+	// maxRows caps the rows counted in metricMode rowCount, 0 counts all of them
+	maxRows int
+	// onMaxRows is whether a query returning more than maxRows rows fails or reports maxRows
+	onMaxRows string
+	// queries replace the query, their results are combined weighted by queryWeights
+	queries      []string
+	queryWeights []float64
+	// notifyChannel is the channel to LISTEN on for pushed metric values
+	notifyChannel string
+	// notifyTimeout is how long a pushed value is used before polling again
+	notifyTimeout time.Duration
+	// onError defines what a failed read returns
+	onError string
+	// onErrorByCategory overrides onError for the categories of getPostgreSQLErrorCategory
+	onErrorByCategory map[string]string
+	// sharedPollingInterval makes the scalers with the same query share a poller reading it in this interval
+	sharedPollingInterval time.Duration
+	// databaseConnections are all connections given with connections, the first one is connection
+	databaseConnections []string
+	// databasesAggregation combines the values of the databaseConnections
+	databasesAggregation string
+	// onDatabaseError defines whether a failed database fails the read of databaseConnections
+	onDatabaseError string
+	// databasesTimeout is the deadline shared by the queries of all databaseConnections, 0 waits for all of them
+	databasesTimeout time.Duration
+	// maxStaleness limits how old the value returned by onError lastValue may be, 0 doesn't limit it.
+	// It applies to the categories of onErrorByCategory with lastValue too
+	maxStaleness time.Duration
+	// maxChangePerRead limits the increase of the value over the previous reading, 0 doesn't limit it
+	maxChangePerRead float64
+	// circuitBreakerThreshold is the number of consecutive failures opening the circuit, 0 disables it
+	circuitBreakerThreshold int
+	// circuitBreakerCooldown is how long the circuit stays open before a probe query
+	circuitBreakerCooldown time.Duration
+	// treatErrorAsZeroSQLStates are the SQLSTATEs of query errors which report 0 instead of failing
+	treatErrorAsZeroSQLStates map[pq.ErrorCode]bool
 }
 
 // NewPostgreSQLScaler creates a new postgreSQL scaler
 func NewPostgreSQLScaler(config *ScalerConfig) (Scaler, error) {
+	return newPostgreSQLScaler(config, postgreSQLConnections)
+}
+
+// newPostgreSQLScaler creates a new postgreSQL scaler which gets its database handles from
+// connections, allowing tests to replace the driver
+func newPostgreSQLScaler(config *ScalerConfig, connections *postgreSQLConnectionPool) (*postgreSQLScaler, error) {
+	return newPostgreSQLScalerRecordedAs(config, connections, "")
+}
+
+// newPostgreSQLScalerRecordedAs creates a new postgreSQL scaler whose signals are recorded with recordedMetricName,
+// e.g. the name of a database of connections. An empty recordedMetricName records them with the name of its metric
+func newPostgreSQLScalerRecordedAs(config *ScalerConfig, connections *postgreSQLConnectionPool, recordedMetricName string) (*postgreSQLScaler, error) {
 	metricType, err := GetMetricTargetType(config)
 	if err != nil {
 		return nil, fmt.Errorf("error getting scaler metric type: %s", err)
@@ -44,76 +501,550 @@ func NewPostgreSQLScaler(config *ScalerConfig) (Scaler, error) {
 	if err != nil {
 		return nil, fmt.Errorf("error parsing postgreSQL metadata: %s", err)
 	}
+	if recordedMetricName == "" {
+		recordedMetricName = GenerateMetricNameWithIndex(meta.scalerIndex, meta.metricName)
+	}
+
+	logger.V(1).Info("Resolved postgreSQL connection", "connection", maskPostgreSQLConnectionString(meta.connection))
+	warnPostgreSQLCertificateVerification(logger, meta.connection)
+	if query, ok := findPostgreSQLConstantQuery(meta); ok && meta.constantQueryCheck == postgreSQLConstantQueryCheckWarn {
+		logger.Info("postgreSQL query doesn't read any table and always returns the same value, it's likely a placeholder", "query", query)
+	}
+
+	// without the ping a typo in the connection would only show up at the first query
+	if !meta.eagerConnect {
+		if _, err := pq.NewConnector(meta.connection); err != nil {
+			return nil, fmt.Errorf("error parsing postgreSQL connection: %s", err)
+		}
+	}
+
+	newCredentials, ok := postgreSQLCredentialProviders[meta.credentialProvider]
+	if !ok {
+		return nil, fmt.Errorf("unknown postgreSQL credentialProvider %s", meta.credentialProvider)
+	}
+	credentials, err := newCredentials(config)
+	if err != nil {
+		return nil, fmt.Errorf("error creating postgreSQL credentialProvider %s: %s", meta.credentialProvider, err)
+	}
+	connectionMeta, credentialsExpireAt, err := resolvePostgreSQLCredentials(context.Background(), credentials, meta)
+	if err != nil {
+		return nil, &postgreSQLError{reason: postgreSQLErrorReasonAuthentication, err: err}
+	}
 
-	conn, err := getConnection(meta, logger)
+	conn, err := connections.acquire(context.Background(), connectionMeta, logger)
 	if err != nil {
-		return nil, fmt.Errorf("error establishing postgreSQL connection: %s", err)
+		return nil, newPostgreSQLError(fmt.Errorf("error establishing postgreSQL connection: %w", err))
+	}
+	if meta.eagerConnect {
+		// only logged at creation, reconnects are logged at V(1) unless they fail
+		logPostgreSQLConnected(logger, meta.connection)
+	}
+	scaler := &postgreSQLScaler{
+		metricType:          metricType,
+		metadata:            meta,
+		connection:          conn,
+		connections:         connections,
+		credentials:         credentials,
+		connectionMetadata:  connectionMeta,
+		credentialsExpireAt: credentialsExpireAt,
+		tlsFileTimes:        getTLSFileModTimes(meta.tlsFiles),
+		passwordFileModTime: meta.passwordFileModTime,
+		rateTracker:         postgreSQLRateTracker{signed: meta.signedRate},
+		querySemaphore:      acquirePostgreSQLQuerySemaphore(meta.connection, meta.maxConcurrentQueries),
+		firstQueryAt:        time.Now().Add(getPostgreSQLJitter(meta.firstQueryJitter)),
+		liveness:            &postgreSQLLivenessTracker{window: meta.producerStallWindow},
+		recorder:            newPostgreSQLQueryRecorder(config, recordedMetricName),
+		logger:              logger,
+	}
+	if meta.pinConnection {
+		scaler.pinnedConnection = &postgreSQLPinnedConnection{}
+	}
+	if meta.queryFile != "" {
+		scaler.queryFile = &postgreSQLQueryFile{path: meta.queryFile, modTime: meta.queryFileModTime, query: meta.query}
+	}
+	scaler.recorder.recordDistribution = meta.recordValueDistribution
+	scaler.recorder.recordTarget = meta.recordTargetValue
+	scaler.recorder.recordReady(false)
+	scaler.recorder.recordInfo(meta.metricMode, string(metricType), meta.targetQueryValue)
+	if meta.capacityQuery == "" {
+		// with capacityQuery the target is only known after querying the capacity
+		scaler.recorder.recordTargetValue(meta.targetQueryValue)
+	}
+	if meta.quietPeriodReads > 0 {
+		scaler.quietPeriod = newPostgreSQLQuietPeriod(meta.quietPeriodReads)
+	}
+	if meta.startupActivationReads > 1 {
+		scaler.startupActivation = newPostgreSQLStartupActivation(meta.startupActivationReads)
+	}
+	if meta.errorLogInterval > 0 {
+		scaler.logSampler = newPostgreSQLLogSampler(meta.errorLogInterval)
+	}
+	if meta.circuitBreakerThreshold > 0 {
+		scaler.circuitBreaker = newPostgreSQLCircuitBreaker(meta.circuitBreakerThreshold, meta.circuitBreakerCooldown)
+	}
+	if len(meta.databaseConnections) > 1 {
+		databases, err := newPostgreSQLDatabaseScalers(config, meta, connections)
+		if err != nil {
+			scaler.Close(context.Background())
+			return nil, err
+		}
+		scaler.databases = databases
+	}
+	if meta.sharedPollingInterval > 0 {
+		key, err := getPostgreSQLSharedPollerKey(config, meta, conn.key)
+		if err != nil {
+			scaler.Close(context.Background())
+			return nil, fmt.Errorf("error hashing postgreSQL shared poller key: %s", err)
+		}
+		scaler.sharedPoller, scaler.sharedSubscription = acquirePostgreSQLSharedPoller(key, meta.sharedPollingInterval, scaler.pollValue)
+	}
+	if meta.minServerVersion > 0 && meta.eagerConnect {
+		// without eagerConnect the version is checked before the first query
+		ctx, cancel := context.WithTimeout(context.Background(), postgreSQLQueryValidationTimeout)
+		err := scaler.checkServerVersion(ctx, scaler.connection.db, scaler.connection.db)
+		cancel()
+		if err != nil {
+			scaler.Close(context.Background())
+			return nil, err
+		}
+	}
+	if meta.validateQueryOnCreate {
+		ctx, cancel := context.WithTimeout(context.Background(), postgreSQLQueryValidationTimeout)
+		defer cancel()
+		if err := scaler.validateQuery(ctx); err != nil {
+			scaler.Close(ctx)
+			return nil, fmt.Errorf("error validating postgreSQL query: %s", err)
+		}
+	}
+	if meta.notifyChannel != "" {
+		scaler.startNotificationListener()
 	}
-	return &postgreSQLScaler{
-		metricType: metricType,
-		metadata:   meta,
-		connection: conn,
-		logger:     logger,
-	}, nil
+	return scaler, nil
+}
+
+// getPostgreSQLJitter returns a random duration in [0, maxJitter]
+func getPostgreSQLJitter(maxJitter time.Duration) time.Duration {
+	if maxJitter <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(maxJitter) + 1))
+}
+
+// postgreSQLMetadataParsers parse the metadata of the features, each next to the feature it configures.
+// They run in order, as some of them depend on the settings parsed before, e.g. valueType on valueExpression
+var postgreSQLMetadataParsers = []func(config *ScalerConfig, meta *postgreSQLMetadata) error{
+	parsePostgreSQLMetricModeMetadata,
+	parsePostgreSQLQueryFileMetadata,
+	parsePostgreSQLReplicationSlotLagMetadata,
+	parsePostgreSQLWindowCountMetadata,
+	parsePostgreSQLSampledCountMetadata,
+	parsePostgreSQLAnyOfMetadata,
+	parsePostgreSQLTableSizeMetadata,
+	parsePostgreSQLIdleInTransactionMetadata,
+	parsePostgreSQLWALRateMetadata,
+	parsePostgreSQLBlockedQueriesMetadata,
+	parsePostgreSQLRowCountMetadata,
+	parsePostgreSQLQueriesMetadata,
+	parsePostgreSQLQueryOptionsMetadata,
+	parsePostgreSQLExpressionMetadata,
+	parsePostgreSQLTargetMetadata,
+	parsePostgreSQLActivationQueryMetadata,
+	parsePostgreSQLParametersMetadata,
+	parsePostgreSQLDeadTuplesMetadata,
+	parsePostgreSQLLivenessMetadata,
+	parsePostgreSQLValueMetadata,
+	parsePostgreSQLTimestampMetadata,
+	parsePostgreSQLResultFormatMetadata,
+	parsePostgreSQLQueryCommentMetadata,
+	parsePostgreSQLSingleRowMetadata,
+	parsePostgreSQLRateMetadata,
+	parsePostgreSQLPrecisionMetadata,
+	parsePostgreSQLExpectedRangeMetadata,
+	parsePostgreSQLErrorPolicyMetadata,
+	parsePostgreSQLMaxChangeMetadata,
+	parsePostgreSQLSharedPollerMetadata,
+	parsePostgreSQLCircuitBreakerMetadata,
+	parsePostgreSQLCredentialsMetadata,
+	parsePostgreSQLConnectOptionsMetadata,
+	parsePostgreSQLPinnedConnectionMetadata,
+	parsePostgreSQLQueryLintMetadata,
+	parsePostgreSQLQuietPeriodMetadata,
+	parsePostgreSQLStartupActivationMetadata,
+	parsePostgreSQLMetricsMetadata,
+	parsePostgreSQLLogSamplerMetadata,
+	parsePostgreSQLConnectionPoolMetadata,
+	parsePostgreSQLConnectionMetadata,
+	parsePostgreSQLTLSMetadata,
+	parsePostgreSQLOCSPMetadata,
+	parsePostgreSQLCancelMetadata,
+	parsePostgreSQLServerVersionMetadata,
+	parsePostgreSQLPasswordFileMetadata,
+	parsePostgreSQLAllowlistMetadata,
+	parsePostgreSQLMetricNameMetadata,
+	parsePostgreSQLDatabasesMetadata,
 }
 
 func parsePostgreSQLMetadata(config *ScalerConfig) (*postgreSQLMetadata, error) {
 	meta := postgreSQLMetadata{}
 
-	if val, ok := config.TriggerMetadata["query"]; ok {
-		meta.query = val
-	} else {
-		return nil, fmt.Errorf("no query given")
+	if err := checkPostgreSQLAuthOnlyMetadata(config); err != nil {
+		return nil, err
+	}
+
+	for _, parse := range postgreSQLMetadataParsers {
+		if err := parse(config, &meta); err != nil {
+			return nil, err
+		}
+	}
+	meta.scalerIndex = config.ScalerIndex
+	return &meta, nil
+}
+
+// isPostgreSQLQueryMetricMode returns whether the metric mode runs the query of the trigger. The other
+// metric modes build their query from their own settings
+func isPostgreSQLQueryMetricMode(metricMode string) bool {
+	switch metricMode {
+	case postgreSQLMetricModeAbsolute, postgreSQLMetricModeRate, postgreSQLMetricModeAge, postgreSQLMetricModeRowCount:
+		return true
+	}
+	return false
+}
+
+// parsePostgreSQLMetricModeMetadata parses the metricMode and the dialect, which most other settings depend on,
+// and the query of the metric modes running the query of the trigger
+func parsePostgreSQLMetricModeMetadata(config *ScalerConfig, meta *postgreSQLMetadata) error {
+	// reportRate predates metricMode rate and is kept as a shorthand for it
+	var reportRate bool
+	if val, ok := config.TriggerMetadata["reportRate"]; ok {
+		var err error
+		reportRate, err = strconv.ParseBool(val)
+		if err != nil {
+			return fmt.Errorf("reportRate parsing error %s", err.Error())
+		}
+	}
+
+	meta.metricMode = postgreSQLMetricModeAbsolute
+	if reportRate {
+		meta.metricMode = postgreSQLMetricModeRate
+	}
+	if val, ok := config.TriggerMetadata["metricMode"]; ok && val != "" {
+		if val == postgreSQLMetricModeQuery {
+			val = postgreSQLMetricModeAbsolute
+		}
+		if reportRate && val != postgreSQLMetricModeRate {
+			return fmt.Errorf("reportRate can't be used with metricMode %s", val)
+		}
+		meta.metricMode = val
+	}
+
+	meta.dialect = postgreSQLDialectPostgres
+	if val, ok := config.TriggerMetadata["dialect"]; ok && val != "" {
+		if _, ok := postgreSQLConnectionSaturationQueries[val]; !ok {
+			return fmt.Errorf("unknown dialect %s, must be one of %s, %s, %s", val,
+				postgreSQLDialectPostgres, postgreSQLDialectCockroach, postgreSQLDialectYugabyte)
+		}
+		meta.dialect = val
+	}
+
+	switch meta.metricMode {
+	case postgreSQLMetricModeAbsolute, postgreSQLMetricModeRate, postgreSQLMetricModeAge, postgreSQLMetricModeRowCount:
+		// without query the query is read from the queryFile or replaced by the queries
+		if val, ok := config.TriggerMetadata["query"]; ok {
+			meta.query = val
+		} else if config.TriggerMetadata["queryFile"] == "" && config.TriggerMetadata["queries"] == "" {
+			return fmt.Errorf("no query given")
+		}
+	case postgreSQLMetricModeConnectionSaturation:
+		meta.query = postgreSQLConnectionSaturationQueries[meta.dialect]
+	case postgreSQLMetricModeReplicationSlotLag, postgreSQLMetricModeWindowCount, postgreSQLMetricModeSampledCount, postgreSQLMetricModeAnyOf,
+		postgreSQLMetricModeTableSize, postgreSQLMetricModeIdleInTransaction, postgreSQLMetricModeWALRate, postgreSQLMetricModeBlockedQueries:
+		// the query is built from the settings of the metric mode by its parse function
+	default:
+		return fmt.Errorf("unknown metricMode %s, must be one of %s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s", meta.metricMode,
+			postgreSQLMetricModeAbsolute, postgreSQLMetricModeRate, postgreSQLMetricModeAge, postgreSQLMetricModeConnectionSaturation,
+			postgreSQLMetricModeReplicationSlotLag, postgreSQLMetricModeWindowCount, postgreSQLMetricModeSampledCount, postgreSQLMetricModeAnyOf,
+			postgreSQLMetricModeTableSize, postgreSQLMetricModeIdleInTransaction, postgreSQLMetricModeWALRate, postgreSQLMetricModeBlockedQueries,
+			postgreSQLMetricModeRowCount)
+	}
+	if _, ok := config.TriggerMetadata["query"]; ok && !isPostgreSQLQueryMetricMode(meta.metricMode) {
+		return fmt.Errorf("query can't be used with metricMode %s", meta.metricMode)
+	}
+	if _, ok := config.TriggerMetadata["table"]; ok && meta.metricMode != postgreSQLMetricModeWindowCount && meta.metricMode != postgreSQLMetricModeSampledCount {
+		return fmt.Errorf("table can only be used with metricMode %s or %s", postgreSQLMetricModeWindowCount, postgreSQLMetricModeSampledCount)
+	}
+	return nil
+}
+
+// parsePostgreSQLQueryOptionsMetadata parses how the query is run and how its result is read
+func parsePostgreSQLQueryOptionsMetadata(config *ScalerConfig, meta *postgreSQLMetadata) error {
+	if val, ok := config.TriggerMetadata["maxConcurrentQueries"]; ok {
+		maxConcurrentQueries, err := strconv.Atoi(val)
+		if err != nil {
+			return fmt.Errorf("maxConcurrentQueries parsing error %s", err.Error())
+		}
+		if maxConcurrentQueries < 0 {
+			return fmt.Errorf("maxConcurrentQueries must not be negative, got %d", maxConcurrentQueries)
+		}
+		meta.maxConcurrentQueries = maxConcurrentQueries
+	}
+
+	if val, ok := config.TriggerMetadata["firstQueryJitter"]; ok {
+		firstQueryJitter, err := parsePostgreSQLDuration("firstQueryJitter", val)
+		if err != nil {
+			return err
+		}
+		if firstQueryJitter < 0 || firstQueryJitter > maxPostgreSQLFirstQueryJitter {
+			return fmt.Errorf("firstQueryJitter must be between 0 and %s, got %s", maxPostgreSQLFirstQueryJitter, firstQueryJitter)
+		}
+		meta.firstQueryJitter = firstQueryJitter
+	}
+
+	if val, ok := config.TriggerMetadata["defaultValueOnNoRows"]; ok {
+		defaultValueOnNoRows, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			return fmt.Errorf("defaultValueOnNoRows parsing error %s", err.Error())
+		}
+		meta.defaultValueOnNoRows = defaultValueOnNoRows
+	}
+
+	if val, ok := config.TriggerMetadata["estimateMode"]; ok {
+		estimateMode, err := strconv.ParseBool(val)
+		if err != nil {
+			return fmt.Errorf("estimateMode parsing error %s", err.Error())
+		}
+		if estimateMode && meta.metricMode != postgreSQLMetricModeAbsolute && meta.metricMode != postgreSQLMetricModeRate {
+			return fmt.Errorf("estimateMode can only be used with metricMode %s or %s", postgreSQLMetricModeAbsolute, postgreSQLMetricModeRate)
+		}
+		meta.estimateMode = estimateMode
+	}
+
+	if val, ok := config.TriggerMetadata["maintenanceQuery"]; ok && val != "" {
+		meta.maintenanceQuery = val
+	}
+
+	if val, ok := config.TriggerMetadata["treatErrorAsZeroSqlStates"]; ok && val != "" {
+		meta.treatErrorAsZeroSQLStates = map[pq.ErrorCode]bool{}
+		for _, state := range strings.Split(val, ",") {
+			state = strings.ToUpper(strings.TrimSpace(state))
+			if !postgreSQLSQLStatePattern.MatchString(state) {
+				return fmt.Errorf("treatErrorAsZeroSqlStates contains invalid SQLSTATE %q", state)
+			}
+			meta.treatErrorAsZeroSQLStates[pq.ErrorCode(state)] = true
+		}
+	}
+
+	if val, ok := config.TriggerMetadata["notifyChannel"]; ok && val != "" {
+		meta.notifyChannel = val
+		meta.notifyTimeout = defaultPostgreSQLNotifyTimeout
+		if val, ok := config.TriggerMetadata["notifyTimeout"]; ok {
+			notifyTimeout, err := parsePostgreSQLDuration("notifyTimeout", val)
+			if err != nil {
+				return err
+			}
+			if notifyTimeout <= 0 {
+				return fmt.Errorf("notifyTimeout must be positive, got %s", notifyTimeout)
+			}
+			meta.notifyTimeout = notifyTimeout
+		}
 	}
+	return nil
+}
 
+// parsePostgreSQLTargetMetadata parses the target the value is compared with and when the trigger is active
+func parsePostgreSQLTargetMetadata(config *ScalerConfig, meta *postgreSQLMetadata) error {
+	meta.capacityQuery = config.TriggerMetadata["capacityQuery"]
 	if val, ok := config.TriggerMetadata["targetQueryValue"]; ok {
-		targetQueryValue, err := strconv.ParseFloat(val, 64)
+		// with capacityQuery the target is a percentage of the capacity, e.g. 80%
+		percentage := strings.HasSuffix(val, "%")
+		if percentage != (meta.capacityQuery != "") {
+			return fmt.Errorf("targetQueryValue must be a percentage such as 80%% if and only if capacityQuery is given, got %s", val)
+		}
+		targetQueryValue, err := strconv.ParseFloat(strings.TrimSuffix(val, "%"), 64)
 		if err != nil {
-			return nil, fmt.Errorf("queryValue parsing error %s", err.Error())
+			return fmt.Errorf("queryValue parsing error %s", err.Error())
+		}
+		if targetQueryValue <= 0 {
+			return fmt.Errorf("targetQueryValue must be a positive number, got %v", targetQueryValue)
+		}
+		if percentage && targetQueryValue > 100 {
+			return fmt.Errorf("targetQueryValue must be a percentage of at most 100%%, got %v%%", targetQueryValue)
 		}
 		meta.targetQueryValue = targetQueryValue
 	} else {
-		return nil, fmt.Errorf("no targetQueryValue given")
+		return fmt.Errorf("no targetQueryValue given")
 	}
 
 	meta.activationTargetQueryValue = 0
+	if meta.metricMode == postgreSQLMetricModeAnyOf {
+		// the value exceeds 1 once either threshold is exceeded
+		meta.activationTargetQueryValue = 1
+	}
 	if val, ok := config.TriggerMetadata["activationTargetQueryValue"]; ok {
 		activationTargetQueryValue, err := strconv.ParseFloat(val, 64)
 		if err != nil {
-			return nil, fmt.Errorf("activationTargetQueryValue parsing error %s", err.Error())
+			return fmt.Errorf("activationTargetQueryValue parsing error %s", err.Error())
 		}
 		meta.activationTargetQueryValue = activationTargetQueryValue
 	}
 
+	meta.activationOperator = postgreSQLActivationOperatorGreater
+	if val, ok := config.TriggerMetadata["activationOperator"]; ok && val != "" {
+		switch val {
+		case postgreSQLActivationOperatorGreater, postgreSQLActivationOperatorGreaterOrEqual, postgreSQLActivationOperatorLess, postgreSQLActivationOperatorLessOrEqual:
+			meta.activationOperator = val
+		default:
+			return fmt.Errorf("unknown activationOperator %s, must be one of %s, %s, %s, %s", val,
+				postgreSQLActivationOperatorGreater, postgreSQLActivationOperatorGreaterOrEqual, postgreSQLActivationOperatorLess, postgreSQLActivationOperatorLessOrEqual)
+		}
+	}
+
+	// targetFromQuery reads a live target from the second column of the query, saving a round-trip
+	if val, ok := config.TriggerMetadata["targetFromQuery"]; ok {
+		targetFromQuery, err := strconv.ParseBool(val)
+		if err != nil {
+			return fmt.Errorf("targetFromQuery parsing error %s", err.Error())
+		}
+		if targetFromQuery {
+			if (meta.metricMode != postgreSQLMetricModeAbsolute && meta.metricMode != postgreSQLMetricModeRate) || meta.estimateMode {
+				return fmt.Errorf("targetFromQuery can only be used with metricMode %s or %s without estimateMode", postgreSQLMetricModeAbsolute, postgreSQLMetricModeRate)
+			}
+			if meta.valueExpression != nil || meta.capacityQuery != "" || meta.notifyChannel != "" {
+				return fmt.Errorf("targetFromQuery can't be combined with valueExpression, capacityQuery or notifyChannel")
+			}
+		}
+		meta.targetFromQuery = targetFromQuery
+	}
+	return nil
+}
+
+// parsePostgreSQLConnectOptionsMetadata parses how the scaler connects and how long acquiring a connection and
+// the queries may take
+func parsePostgreSQLConnectOptionsMetadata(config *ScalerConfig, meta *postgreSQLMetadata) error {
+	meta.eagerConnect = true
+	if val, ok := config.TriggerMetadata["eagerConnect"]; ok {
+		eagerConnect, err := strconv.ParseBool(val)
+		if err != nil {
+			return fmt.Errorf("eagerConnect parsing error %s", err.Error())
+		}
+		meta.eagerConnect = eagerConnect
+	}
+
+	if val, ok := config.TriggerMetadata["validateQueryOnCreate"]; ok {
+		validateQueryOnCreate, err := strconv.ParseBool(val)
+		if err != nil {
+			return fmt.Errorf("validateQueryOnCreate parsing error %s", err.Error())
+		}
+		if validateQueryOnCreate && !meta.eagerConnect {
+			return fmt.Errorf("validateQueryOnCreate can't be used without eagerConnect")
+		}
+		meta.validateQueryOnCreate = validateQueryOnCreate
+	}
+
+	if val, ok := config.TriggerMetadata["connectRetries"]; ok {
+		connectRetries, err := strconv.Atoi(val)
+		if err != nil {
+			return fmt.Errorf("connectRetries parsing error %s", err.Error())
+		}
+		if connectRetries < 0 || connectRetries > maxPostgreSQLConnectRetries {
+			return fmt.Errorf("connectRetries must be between 0 and %d, got %d", maxPostgreSQLConnectRetries, connectRetries)
+		}
+		meta.connectRetries = connectRetries
+	}
+
+	meta.connectRetryInterval = defaultPostgreSQLConnectRetryInterval
+	if val, ok := config.TriggerMetadata["connectRetryInterval"]; ok {
+		connectRetryInterval, err := parsePostgreSQLDuration("connectRetryInterval", val)
+		if err != nil {
+			return err
+		}
+		if connectRetryInterval <= 0 || connectRetryInterval > maxPostgreSQLConnectRetryInterval {
+			return fmt.Errorf("connectRetryInterval must be positive and at most %s, got %s", maxPostgreSQLConnectRetryInterval, connectRetryInterval)
+		}
+		meta.connectRetryInterval = connectRetryInterval
+	}
+
+	for _, timeout := range []struct {
+		name  string
+		value *time.Duration
+	}{
+		{name: "connectionAcquireTimeout", value: &meta.connectionAcquireTimeout},
+		{name: "queryTimeout", value: &meta.queryTimeout},
+	} {
+		if val, ok := config.TriggerMetadata[timeout.name]; ok && val != "" {
+			duration, err := parsePostgreSQLDuration(timeout.name, val)
+			if err != nil {
+				return err
+			}
+			if duration <= 0 {
+				return fmt.Errorf("%s must be positive, got %s", timeout.name, duration)
+			}
+			*timeout.value = duration
+		}
+	}
+	return nil
+}
+
+// parsePostgreSQLConnectionMetadata builds the connection string from the connection, the parameters of the
+// connection or one of the connection sources such as connectionJSON
+func parsePostgreSQLConnectionMetadata(config *ScalerConfig, meta *postgreSQLMetadata) error {
 	switch {
 	case config.AuthParams["connection"] != "":
 		meta.connection = config.AuthParams["connection"]
+	case config.AuthParams["connectionJSON"] != "":
+		connection, err := parsePostgreSQLConnectionJSON(config.AuthParams["connectionJSON"], config.TriggerMetadata["sslmode"])
+		if err != nil {
+			return fmt.Errorf("connectionJSON parsing error %s", err.Error())
+		}
+		meta.connection = connection
+	case config.AuthParams["connectionShards"] != "":
+		// every workload consistently queries its shard of a sharded metric store
+		shards, err := parsePostgreSQLConnectionShards(config.AuthParams["connectionShards"])
+		if err != nil {
+			return fmt.Errorf("connectionShards parsing error %s", err.Error())
+		}
+		meta.connection = shards[getPostgreSQLShard(config.ScalableObjectNamespace, config.ScalableObjectName, len(shards))]
+	case config.AuthParams["connections"] != "":
+		// every database is queried and the values are aggregated
+		connections, err := parsePostgreSQLConnectionShards(config.AuthParams["connections"])
+		if err != nil {
+			return fmt.Errorf("connections parsing error %s", err.Error())
+		}
+		meta.connection = connections[0]
+		meta.databaseConnections = connections
 	case config.TriggerMetadata["connectionFromEnv"] != "":
 		meta.connection = config.ResolvedEnv[config.TriggerMetadata["connectionFromEnv"]]
 	default:
-		host, err := GetFromAuthOrMeta(config, "host")
+		host, err := getPostgreSQLConnectionParameter(config, "host")
 		if err != nil {
-			return nil, err
+			return err
 		}
 
-		port, err := GetFromAuthOrMeta(config, "port")
+		port, err := getPostgreSQLConnectionParameter(config, "port")
 		if err != nil {
-			return nil, err
+			return err
 		}
 
-		userName, err := GetFromAuthOrMeta(config, "userName")
+		userName, err := getPostgreSQLConnectionParameter(config, "userName")
 		if err != nil {
-			return nil, err
+			return err
 		}
 
-		dbName, err := GetFromAuthOrMeta(config, "dbName")
+		dbName, err := getPostgreSQLConnectionParameter(config, "dbName")
 		if err != nil {
-			return nil, err
+			return err
 		}
 
 		sslmode, err := GetFromAuthOrMeta(config, "sslmode")
 		if err != nil {
-			return nil, err
+			return err
+		}
+
+		// password stays the last parameter, an empty value would swallow the next one otherwise
+		var tlsParams string
+		for _, param := range postgreSQLTLSFileParams {
+			if val, err := GetFromAuthOrMeta(config, param); err == nil {
+				tlsParams += fmt.Sprintf(" %s=%s", param, val)
+			}
 		}
 
 		var password string
@@ -124,42 +1055,672 @@ func parsePostgreSQLMetadata(config *ScalerConfig) (*postgreSQLMetadata, error)
 		}
 
 		meta.connection = fmt.Sprintf(
-			"host=%s port=%s user=%s dbname=%s sslmode=%s password=%s",
+			"host=%s port=%s user=%s dbname=%s sslmode=%s%s password=%s",
 			host,
 			port,
 			userName,
 			dbName,
 			sslmode,
+			tlsParams,
 			password,
 		)
 	}
 
+	// the connection string is validated by the driver, so it's fine to skip
+	// watching TLS files if it can't be parsed here
+	params, paramsErr := parsePostgreSQLConnectionString(meta.connection)
+	if paramsErr == nil {
+		// the driver joins host and port itself, a bracketed IPv6 host would end up bracketed twice
+		if host := normalizePostgreSQLHost(params["host"]); host != params["host"] {
+			params["host"] = host
+			meta.connection = formatPostgreSQLConnectionString(params)
+		}
+		for _, param := range postgreSQLTLSFileParams {
+			if params[param] != "" {
+				meta.tlsFiles = append(meta.tlsFiles, params[param])
+			}
+		}
+	}
+
+	if val, ok := config.TriggerMetadata["requireEncryption"]; ok {
+		requireEncryption, err := strconv.ParseBool(val)
+		if err != nil {
+			return fmt.Errorf("requireEncryption parsing error %s", err.Error())
+		}
+		if requireEncryption && paramsErr == nil && params["sslmode"] == "disable" {
+			return fmt.Errorf("requireEncryption can't be used with sslmode disable")
+		}
+		meta.requireEncryption = requireEncryption
+	}
+
+	// statementTimeout makes the server cancel runaway queries itself, so they don't keep running
+	// after the scaler gave up on them
+	if val, ok := config.TriggerMetadata["statementTimeout"]; ok && val != "" {
+		statementTimeout, err := parsePostgreSQLDuration("statementTimeout", val)
+		if err != nil {
+			return err
+		}
+		if statementTimeout < time.Millisecond {
+			return fmt.Errorf("statementTimeout must be at least 1ms, got %s", statementTimeout)
+		}
+		if paramsErr != nil {
+			return fmt.Errorf("error parsing connection for statementTimeout: %s", paramsErr)
+		}
+		options := fmt.Sprintf("-c statement_timeout=%d", statementTimeout.Milliseconds())
+		if params["options"] != "" {
+			options = fmt.Sprintf("%s %s", params["options"], options)
+		}
+		params["options"] = options
+		meta.connection = formatPostgreSQLConnectionString(params)
+	}
+	return nil
+}
+
+// parsePostgreSQLMetricNameMetadata parses the name of the metric and the labels its values are reported with
+func parsePostgreSQLMetricNameMetadata(config *ScalerConfig, meta *postgreSQLMetadata) error {
+	metricNamePrefix := "postgresql"
+	if val, ok := config.TriggerMetadata["metricNamePrefix"]; ok {
+		metricNamePrefix = strings.Trim(kedautil.NormalizeString(strings.TrimSpace(val)), "-")
+		if metricNamePrefix == "" {
+			return fmt.Errorf("metricNamePrefix must not be empty")
+		}
+	}
 	if val, ok := config.TriggerMetadata["metricName"]; ok {
-		meta.metricName = kedautil.NormalizeString(fmt.Sprintf("postgresql-%s", val))
+		meta.metricName = kedautil.NormalizeString(fmt.Sprintf("%s-%s", metricNamePrefix, val))
 	} else {
-		meta.metricName = kedautil.NormalizeString("postgresql")
+		meta.metricName = kedautil.NormalizeString(metricNamePrefix)
 	}
-	meta.scalerIndex = config.ScalerIndex
-	return &meta, nil
+	if val, ok := config.TriggerMetadata["metricDescription"]; ok {
+		description := normalizePostgreSQLMetricDescription(val)
+		if description == "" {
+			return fmt.Errorf("metricDescription must contain at least one alphanumeric character")
+		}
+		meta.metricName = fmt.Sprintf("%s-%s", meta.metricName, description)
+	}
+	if val, ok := config.TriggerMetadata["metricLabels"]; ok && val != "" {
+		metricLabels, err := parsePostgreSQLMetricLabels(val)
+		if err != nil {
+			return fmt.Errorf("metricLabels parsing error %s", err.Error())
+		}
+		meta.metricLabels = metricLabels
+	}
+	return nil
 }
 
-func getConnection(meta *postgreSQLMetadata, logger logr.Logger) (*sql.DB, error) {
-	db, err := sql.Open("postgres", meta.connection)
+func getConnection(ctx context.Context, meta *postgreSQLMetadata, openConnection postgreSQLConnectionOpener, logger logr.Logger) (*sql.DB, error) {
+	db, err := openConnection(meta)
 	if err != nil {
 		logger.Error(err, fmt.Sprintf("Found error opening postgreSQL: %s", err))
 		return nil, err
 	}
-	err = db.Ping()
-	if err != nil {
-		logger.Error(err, fmt.Sprintf("Found error pinging postgreSQL: %s", err))
-		return nil, err
+	if meta.idleConnectionTimeout > 0 {
+		db.SetConnMaxIdleTime(meta.idleConnectionTimeout)
+	}
+	if meta.connectionMaxLifetime > 0 {
+		db.SetConnMaxLifetime(meta.connectionMaxLifetime)
 	}
-	return db, nil
+	if !meta.eagerConnect {
+		return db, nil
+	}
+	// the database may not accept connections yet, e.g. while it's deployed together with the workload.
+	// The retries stop when the caller gives up or they'd wait longer than maxPostgreSQLConnectRetryWait
+	retryInterval := meta.connectRetryInterval
+	retryDeadline := time.Now().Add(maxPostgreSQLConnectRetryWait)
+	for attempt := 0; ; attempt++ {
+		err = db.PingContext(ctx)
+		if err == nil {
+			return db, nil
+		}
+		if attempt >= meta.connectRetries || ctx.Err() != nil || time.Now().Add(retryInterval).After(retryDeadline) {
+			break
+		}
+		logger.V(1).Info("Retrying postgreSQL ping", "attempt", attempt+1, "retryInterval", retryInterval, "error", err.Error())
+		timer := time.NewTimer(retryInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			err = fmt.Errorf("%w, stopped retrying: %s", err, ctx.Err())
+		case <-timer.C:
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		retryInterval *= 2
+		if retryInterval > maxPostgreSQLConnectRetryInterval {
+			retryInterval = maxPostgreSQLConnectRetryInterval
+		}
+	}
+	logger.Error(err, fmt.Sprintf("Found error pinging postgreSQL: %s", err))
+	db.Close()
+	return nil, err
 }
 
-// Close disposes of postgres connections
+// parsePostgreSQLDuration parses the duration metadata field name. Besides Go durations such as 30s or 1m30s
+// bare integers are accepted as seconds
+func parsePostgreSQLDuration(name, value string) (time.Duration, error) {
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if seconds > int64(math.MaxInt64/time.Second) || seconds < int64(math.MinInt64/time.Second) {
+			return 0, fmt.Errorf("%s parsing error %s is out of range", name, value)
+		}
+		return time.Duration(seconds) * time.Second, nil
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s parsing error %s", name, err.Error())
+	}
+	return duration, nil
+}
+
+// normalizePostgreSQLMetricDescription turns a free text description into a lowercase, dash separated
+// metric name segment which doesn't exceed the length of a Kubernetes label value
+func normalizePostgreSQLMetricDescription(description string) string {
+	description = postgreSQLMetricDescriptionInvalidChars.ReplaceAllString(strings.ToLower(description), "-")
+	description = strings.Trim(description, "-")
+	if len(description) > validation.LabelValueMaxLength {
+		description = strings.TrimRight(description[:validation.LabelValueMaxLength], "-")
+	}
+	return description
+}
+
+// parsePostgreSQLMetricLabels parses comma separated key=value pairs, which have to be valid
+// Kubernetes label names and values
+func parsePostgreSQLMetricLabels(labels string) (map[string]string, error) {
+	result := map[string]string{}
+	for _, label := range strings.Split(labels, ",") {
+		key, value, found := strings.Cut(label, "=")
+		if !found {
+			return nil, fmt.Errorf("label %q must be in the form key=value", strings.TrimSpace(label))
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid label name %q: %s", key, strings.Join(errs, "; "))
+		}
+		if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid value of label %s: %s", key, strings.Join(errs, "; "))
+		}
+		if _, ok := result[key]; ok {
+			return nil, fmt.Errorf("duplicate label %s", key)
+		}
+		result[key] = value
+	}
+	return result, nil
+}
+
+// normalizePostgreSQLHost removes the brackets around IPv6 literals, which URLs require but the host
+// keyword doesn't accept. Each host of a comma separated list is normalized
+func normalizePostgreSQLHost(host string) string {
+	hosts := strings.Split(host, ",")
+	for i, h := range hosts {
+		if strings.HasPrefix(h, "[") && strings.HasSuffix(h, "]") {
+			h = h[1 : len(h)-1]
+		}
+		hosts[i] = h
+	}
+	return strings.Join(hosts, ",")
+}
+
+// parsePostgreSQLConnectionString splits a connection string into its keyword/value pairs,
+// following the libpq rules for quoting and escaping. URL connection strings are supported too
+func parsePostgreSQLConnectionString(connection string) (map[string]string, error) {
+	if strings.HasPrefix(connection, "postgres://") || strings.HasPrefix(connection, "postgresql://") {
+		converted, err := pq.ParseURL(connection)
+		if err != nil {
+			return nil, err
+		}
+		connection = converted
+	}
+
+	params := map[string]string{}
+	runes := []rune(connection)
+	i := 0
+	skipSpaces := func() {
+		for i < len(runes) && unicode.IsSpace(runes[i]) {
+			i++
+		}
+	}
+	for {
+		skipSpaces()
+		if i >= len(runes) {
+			return params, nil
+		}
+
+		start := i
+		for i < len(runes) && !unicode.IsSpace(runes[i]) && runes[i] != '=' {
+			i++
+		}
+		key := string(runes[start:i])
+		skipSpaces()
+		if i >= len(runes) || runes[i] != '=' {
+			return nil, fmt.Errorf("missing \"=\" after %q in connection string", key)
+		}
+		i++
+		skipSpaces()
+
+		var value []rune
+		quoted := i < len(runes) && runes[i] == '\''
+		if quoted {
+			i++
+		}
+		for ; i < len(runes); i++ {
+			r := runes[i]
+			if quoted && r == '\'' {
+				break
+			}
+			if !quoted && unicode.IsSpace(r) {
+				break
+			}
+			if r == '\\' {
+				i++
+				if i >= len(runes) {
+					return nil, fmt.Errorf("missing character after backslash in connection string")
+				}
+				r = runes[i]
+			}
+			value = append(value, r)
+		}
+		if quoted {
+			if i >= len(runes) {
+				return nil, fmt.Errorf("unterminated quoted string in connection string")
+			}
+			i++
+		}
+		params[key] = string(value)
+	}
+}
+
+// parsePostgreSQLConnectionJSON builds the connection from a JSON secret such as the ones of external
+// secret operators, e.g. {"host": "db", "port": 5432, "username": "app", "password": "...", "dbname": "jobs"}.
+// sslmode falls back to defaultSSLMode as secret managers usually don't store it
+func parsePostgreSQLConnectionJSON(connectionJSON string, defaultSSLMode string) (string, error) {
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(connectionJSON), &fields); err != nil {
+		return "", err
+	}
+
+	// the keys are matched case insensitive, user and username are both common
+	values := map[string]string{}
+	for key, value := range fields {
+		switch value := value.(type) {
+		case string:
+			values[strings.ToLower(key)] = value
+		case float64:
+			values[strings.ToLower(key)] = strconv.FormatFloat(value, 'f', -1, 64)
+		case nil:
+		default:
+			return "", fmt.Errorf("value of %s must be a string or a number", key)
+		}
+	}
+	if values["username"] == "" {
+		values["username"] = values["user"]
+	}
+	if values["sslmode"] == "" {
+		values["sslmode"] = defaultSSLMode
+	}
+
+	params := map[string]string{}
+	for _, field := range []struct{ key, param string }{
+		{key: "host", param: "host"},
+		{key: "port", param: "port"},
+		{key: "username", param: "user"},
+		{key: "dbname", param: "dbname"},
+		{key: "sslmode", param: "sslmode"},
+	} {
+		if values[field.key] == "" {
+			return "", fmt.Errorf("no %s given", field.key)
+		}
+		params[field.param] = values[field.key]
+	}
+	if values["password"] != "" {
+		params["password"] = values["password"]
+	}
+	return formatPostgreSQLConnectionString(params), nil
+}
+
+// maskPostgreSQLConnectionString returns the connection in keyword/value form with the password masked,
+// so it can be logged. A connection which can't be parsed isn't returned at all as the password
+// can't be located reliably
+func maskPostgreSQLConnectionString(connection string) string {
+	params, err := parsePostgreSQLConnectionString(connection)
+	if err != nil {
+		return fmt.Sprintf("<invalid connection: %s>", err)
+	}
+	for key := range params {
+		if strings.Contains(key, "password") {
+			params[key] = "****"
+		}
+	}
+	return formatPostgreSQLConnectionString(params)
+}
+
+// formatPostgreSQLConnectionString builds a keyword/value connection string sorted by keyword,
+// quoting and escaping every value
+func formatPostgreSQLConnectionString(params map[string]string) string {
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	escaper := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s='%s'", key, escaper.Replace(params[key])))
+	}
+	return strings.Join(pairs, " ")
+}
+
+// logPostgreSQLConnected logs that the connection was established, naming only its servers and database
+func logPostgreSQLConnected(logger logr.Logger, connection string) {
+	logger.Info("Connected to postgreSQL", "server", getPostgreSQLServerAddress(connection))
+}
+
+// getPostgreSQLServerAddress returns the host:port pairs and the database of connection, leaving out
+// the user, password and everything else
+func getPostgreSQLServerAddress(connection string) string {
+	params, err := parsePostgreSQLConnectionString(connection)
+	if err != nil {
+		return "<invalid connection>"
+	}
+	var hosts []string
+	if params["host"] != "" {
+		hosts = strings.Split(params["host"], ",")
+	}
+	ports := strings.Split(params["port"], ",")
+	for i, host := range hosts {
+		port := ports[0]
+		if i < len(ports) {
+			port = ports[i]
+		}
+		if port != "" {
+			hosts[i] = net.JoinHostPort(host, port)
+		}
+	}
+	address := strings.Join(hosts, ",")
+	if params["dbname"] != "" {
+		address += "/" + params["dbname"]
+	}
+	return address
+}
+
+// normalizePostgreSQLConnectionString returns the canonical form of connection, so connections which only
+// differ in the order of their keywords, whitespace, quoting or URL form share their pooled resources.
+// A connection which can't be parsed is returned as it is, connecting with it fails anyway
+func normalizePostgreSQLConnectionString(connection string) string {
+	params, err := parsePostgreSQLConnectionString(connection)
+	if err != nil {
+		return connection
+	}
+	return formatPostgreSQLConnectionString(params)
+}
+
+// getTLSFileModTimes returns the modification time of every readable TLS file
+func getTLSFileModTimes(files []string) map[string]time.Time {
+	modTimes := make(map[string]time.Time, len(files))
+	for _, file := range files {
+		// os.Stat follows symlinks, so secret volume updates are detected too
+		info, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+		modTimes[file] = info.ModTime()
+	}
+	return modTimes
+}
+
+// refreshExpiredCredentials gets new credentials from the provider shortly before the current ones
+// expire and switches to a connection using them
+func (s *postgreSQLScaler) refreshExpiredCredentials(ctx context.Context) error {
+	s.reconnectMutex.Lock()
+	defer s.reconnectMutex.Unlock()
+	s.mutex.Lock()
+	credentialsExpireAt := s.credentialsExpireAt
+	s.mutex.Unlock()
+	if credentialsExpireAt.IsZero() || time.Until(credentialsExpireAt) > postgreSQLCredentialRefreshMargin {
+		return nil
+	}
+
+	connectionMeta, credentialsExpireAt, err := resolvePostgreSQLCredentials(ctx, s.credentials, s.metadata)
+	if err != nil {
+		return err
+	}
+	conn, err := s.connections.acquire(ctx, connectionMeta, s.logger)
+	if err != nil {
+		return err
+	}
+	s.swapConnection(conn, func() {
+		s.connectionMetadata = connectionMeta
+		s.credentialsExpireAt = credentialsExpireAt
+	})
+	s.logger.V(1).Info("Refreshed postgreSQL credentials", "expiresAt", credentialsExpireAt)
+	return nil
+}
+
+// refreshConnectionOnTLSRotation rebuilds the connection when any of the referenced TLS files
+// was modified since the connection was established, so renewed certificates are picked up
+func (s *postgreSQLScaler) refreshConnectionOnTLSRotation(ctx context.Context) error {
+	s.reconnectMutex.Lock()
+	defer s.reconnectMutex.Unlock()
+	s.mutex.Lock()
+	connection, connectionMeta, tlsFileTimes := s.connection, s.connectionMetadata, s.tlsFileTimes
+	s.mutex.Unlock()
+	if connection == nil {
+		return nil
+	}
+
+	modTimes := getTLSFileModTimes(s.metadata.tlsFiles)
+	changed := false
+	for file, modTime := range modTimes {
+		// files which are missing in the middle of a rotation are checked again next time
+		if previous, ok := tlsFileTimes[file]; !ok || !previous.Equal(modTime) {
+			changed = true
+			break
+		}
+	}
+	if !changed {
+		return nil
+	}
+
+	s.logger.V(1).Info("TLS files of postgreSQL connection changed, reconnecting")
+	conn, err := s.connections.refresh(ctx, connection, connectionMeta, s.logger)
+	if err != nil {
+		return err
+	}
+	s.swapConnection(conn, func() {
+		s.tlsFileTimes = modTimes
+	})
+	return nil
+}
+
+// swapConnection switches to conn, which was connected without holding the lock so the reads of the notified
+// value or Close aren't blocked by the reconnect, and releases the previous connection. update records what conn
+// was connected for. The reconnects are serialized by reconnectMutex, so only Close can have changed the
+// connection meanwhile, in which case conn is released instead
+func (s *postgreSQLScaler) swapConnection(conn *postgreSQLPooledConnection, update func()) {
+	s.mutex.Lock()
+	previous := s.connection
+	if previous != nil {
+		s.connection = conn
+		update()
+	}
+	s.mutex.Unlock()
+
+	if previous == nil {
+		previous = conn
+	}
+	if err := previous.release(); err != nil {
+		s.logger.Error(err, "Error closing previous postgreSQL connection")
+	}
+}
+
+// acquirePostgreSQLQuerySemaphore returns the semaphore shared by all scalers with the same
+// connection and limit, creating it if needed. A limit of 0 disables the semaphore
+func acquirePostgreSQLQuerySemaphore(connection string, limit int) *postgreSQLQuerySemaphore {
+	if limit == 0 {
+		return nil
+	}
+
+	postgreSQLQuerySemaphoresMutex.Lock()
+	defer postgreSQLQuerySemaphoresMutex.Unlock()
+
+	key := postgreSQLQuerySemaphoreKey{connection: normalizePostgreSQLConnectionString(connection), limit: limit}
+	sem, ok := postgreSQLQuerySemaphores[key]
+	if !ok {
+		sem = &postgreSQLQuerySemaphore{key: key, slots: make(chan struct{}, limit)}
+		postgreSQLQuerySemaphores[key] = sem
+	}
+	sem.refs++
+	return sem
+}
+
+// release drops a reference to the semaphore, removing it once no scaler uses it anymore
+func (sem *postgreSQLQuerySemaphore) release() {
+	postgreSQLQuerySemaphoresMutex.Lock()
+	defer postgreSQLQuerySemaphoresMutex.Unlock()
+
+	sem.refs--
+	if sem.refs == 0 {
+		delete(postgreSQLQuerySemaphores, sem.key)
+	}
+}
+
+// wait blocks until a query slot is free or the context is done. Blocked callers
+// are served in arrival order, as goroutines waiting to send on a channel are queued
+func (sem *postgreSQLQuerySemaphore) wait(ctx context.Context) error {
+	select {
+	case sem.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// done frees a query slot taken by wait
+func (sem *postgreSQLQuerySemaphore) done() {
+	<-sem.slots
+}
+
+// startNotificationListener listens on notifyChannel in the background and caches the pushed values
+func (s *postgreSQLScaler) startNotificationListener() {
+	// the listener uses the initial credentials. Its session outlives their expiry, reconnecting
+	// afterwards fails and values are polled instead
+	connection, dialer, err := getPostgreSQLDriverConnection(s.connectionMetadata)
+	if err != nil {
+		s.logger.Error(err, "could not start postgreSQL notification listener, values are polled instead")
+		return
+	}
+	callback := func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			s.logger.Error(err, "postgreSQL notification listener error")
+		}
+	}
+	var listener *pq.Listener
+	if dialer == nil {
+		listener = pq.NewListener(connection, time.Second, time.Minute, callback)
+	} else {
+		listener = pq.NewDialListener(dialer, connection, time.Second, time.Minute, callback)
+	}
+	done := make(chan struct{})
+	s.mutex.Lock()
+	s.listener = listener
+	s.listenerDone = done
+	s.mutex.Unlock()
+
+	go func() {
+		// Listen blocks until the listener is connected, values are polled meanwhile
+		if err := listener.Listen(s.metadata.notifyChannel); err != nil {
+			select {
+			case <-done:
+			default:
+				s.logger.Error(err, fmt.Sprintf("could not listen on postgreSQL channel %s", s.metadata.notifyChannel))
+			}
+		}
+	}()
+	go s.consumeNotifications(listener.NotificationChannel(), done)
+}
+
+// consumeNotifications caches every numeric payload received until the channel is closed or done is
+func (s *postgreSQLScaler) consumeNotifications(notifications <-chan *pq.Notification, done <-chan struct{}) {
+	for {
+		var notification *pq.Notification
+		select {
+		case <-done:
+			return
+		case n, ok := <-notifications:
+			if !ok {
+				return
+			}
+			notification = n
+		}
+		// a nil notification is sent after the listener reconnected
+		if notification == nil {
+			continue
+		}
+		value, err := strconv.ParseFloat(strings.TrimSpace(notification.Extra), 64)
+		if err != nil {
+			s.logger.Error(err, fmt.Sprintf("ignoring non numeric payload on postgreSQL channel %s", notification.Channel))
+			continue
+		}
+		s.mutex.Lock()
+		s.notifiedValue = value
+		s.notifiedAt = time.Now()
+		s.mutex.Unlock()
+	}
+}
+
+// getNotifiedValue returns the last pushed value and when it arrived, if that was within notifyTimeout
+func (s *postgreSQLScaler) getNotifiedValue() (float64, time.Time, bool) {
+	if s.metadata.notifyChannel == "" {
+		return 0, time.Time{}, false
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.notifiedAt.IsZero() || time.Since(s.notifiedAt) > s.metadata.notifyTimeout {
+		return 0, time.Time{}, false
+	}
+	return s.notifiedValue, s.notifiedAt, true
+}
+
+// Close disposes of postgres connections
 func (s *postgreSQLScaler) Close(context.Context) error {
-	err := s.connection.Close()
+	s.mutex.Lock()
+	sharedPoller := s.sharedPoller
+	s.sharedPoller = nil
+	s.mutex.Unlock()
+	if sharedPoller != nil {
+		sharedPoller.release(s.sharedSubscription)
+	}
+
+	// the listener is closed without holding the lock, which is needed to consume its notifications
+	s.mutex.Lock()
+	listener, listenerDone := s.listener, s.listenerDone
+	s.listener, s.listenerDone = nil, nil
+	s.mutex.Unlock()
+	if listener != nil {
+		close(listenerDone)
+		if err := listener.Close(); err != nil {
+			s.logger.Error(err, "Error closing postgreSQL notification listener")
+		}
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	closePostgreSQLDatabaseScalers(s.databases)
+	s.databases = nil
+	s.recorder.release()
+	if s.querySemaphore != nil {
+		s.querySemaphore.release()
+		s.querySemaphore = nil
+	}
+	if s.pinnedConnection != nil {
+		if err := s.pinnedConnection.close(); err != nil {
+			s.logger.Error(err, "Error closing pinned postgreSQL connection")
+		}
+	}
+	if s.connection == nil {
+		return nil
+	}
+	err := s.connection.release()
+	s.connection = nil
 	if err != nil {
 		s.logger.Error(err, "Error closing postgreSQL connection")
 		return err
@@ -167,27 +1728,670 @@ func (s *postgreSQLScaler) Close(context.Context) error {
 	return nil
 }
 
+// queryCapacity runs the capacityQuery, which has to return a positive number
+func (s *postgreSQLScaler) queryCapacity(ctx context.Context) (capacity float64, err error) {
+	err = s.withCircuitBreaker(func() error {
+		return s.withSession(ctx, func(session *postgreSQLSession) error {
+			var value sql.NullString
+			if err := session.querier.QueryRowContext(session.ctx, s.metadata.capacityQuery).Scan(&value); err != nil {
+				return session.timeoutErr(err)
+			}
+			capacity, err = parsePostgreSQLResultValue(value, 0)
+			return err
+		})
+	})
+	if err != nil {
+		return 0, err
+	}
+	if capacity <= 0 {
+		return 0, fmt.Errorf("capacityQuery must return a positive number, got %v", capacity)
+	}
+	return capacity, nil
+}
+
+// effectiveTarget is the value targetQueryValue stands for with the given capacity. The HPA target stays
+// targetQueryValue, so the reported value is scaled by targetQueryValue / effectiveTarget instead
+func (m *postgreSQLMetadata) effectiveTarget(capacity float64) float64 {
+	return capacity * m.targetQueryValue / 100
+}
+
 // IsActive returns true if there are pending messages to be processed
 func (s *postgreSQLScaler) IsActive(ctx context.Context) (bool, error) {
-	messages, err := s.getActiveNumber(ctx)
-	if err != nil {
-		return false, fmt.Errorf("error inspecting postgreSQL: %s", err)
+	var active bool
+	if s.metadata.activationQuery != "" {
+		var err error
+		if active, err = s.queryActivation(ctx); err != nil {
+			s.logError(err, fmt.Sprintf("could not query postgreSQL activation: %s", err))
+			return false, newPostgreSQLError(fmt.Errorf("error inspecting postgreSQL: %w", err))
+		}
+	} else {
+		messages, err := s.getActiveNumber(ctx)
+		if err != nil {
+			return false, newPostgreSQLError(fmt.Errorf("error inspecting postgreSQL: %w", err))
+		}
+		active = s.metadata.isActive(messages)
+	}
+
+	if s.startupActivation != nil {
+		s.mutex.Lock()
+		active = s.startupActivation.active(active)
+		s.mutex.Unlock()
 	}
+	if s.quietPeriod != nil {
+		s.mutex.Lock()
+		active = s.quietPeriod.active(active)
+		s.mutex.Unlock()
+	}
+	return active, nil
+}
 
-	return messages > s.metadata.activationTargetQueryValue, nil
+// isActive compares the value with the activationTargetQueryValue using the activationOperator
+func (m *postgreSQLMetadata) isActive(value float64) bool {
+	switch m.activationOperator {
+	case postgreSQLActivationOperatorGreaterOrEqual:
+		return value >= m.activationTargetQueryValue
+	case postgreSQLActivationOperatorLess:
+		return value < m.activationTargetQueryValue
+	case postgreSQLActivationOperatorLessOrEqual:
+		return value <= m.activationTargetQueryValue
+	default:
+		return value > m.activationTargetQueryValue
+	}
+}
+
+// inactiveValue returns a value which doesn't activate the scaler, preferring 0 when it qualifies
+func (m *postgreSQLMetadata) inactiveValue() float64 {
+	target := m.activationTargetQueryValue
+	switch m.activationOperator {
+	case postgreSQLActivationOperatorGreaterOrEqual:
+		if target > 0 {
+			return 0
+		}
+		return target - 1
+	case postgreSQLActivationOperatorLess:
+		return math.Max(0, target)
+	case postgreSQLActivationOperatorLessOrEqual:
+		if target < 0 {
+			return 0
+		}
+		return target + 1
+	default:
+		return math.Min(0, target)
+	}
 }
 
 func (s *postgreSQLScaler) getActiveNumber(ctx context.Context) (float64, error) {
-	var id float64
-	err := s.connection.QueryRowContext(ctx, s.metadata.query).Scan(&id)
+	value, readAt, err := s.readValue(ctx)
+	if err == nil {
+		err = checkPostgreSQLFiniteValue(value)
+	}
+	if err == nil {
+		err = s.checkExpectedValue(value)
+	}
 	if err != nil {
-		s.logger.Error(err, fmt.Sprintf("could not query postgreSQL: %s", err))
-		return 0, fmt.Errorf("could not query postgreSQL: %s", err)
+		if s.metadata.onErrorFor(err) == postgreSQLOnErrorLastValue {
+			s.mutex.Lock()
+			lastValue, lastValueAt, hasLastValue := s.lastValue, s.lastValueAt, s.hasLastValue
+			s.mutex.Unlock()
+			if hasLastValue && s.metadata.maxStaleness > 0 {
+				if age := time.Since(lastValueAt); age > s.metadata.maxStaleness {
+					return 0, fmt.Errorf("last postgreSQL value is %s old, exceeding maxStaleness %s: %w", age.Round(time.Second), s.metadata.maxStaleness, err)
+				}
+			}
+			if hasLastValue {
+				s.logger.V(1).Info("returning last postgreSQL value after failed read", "error", err.Error(), "value", lastValue)
+				return lastValue, nil
+			}
+		}
+		return 0, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.metadata.metricMode == postgreSQLMetricModeRate || s.metadata.metricMode == postgreSQLMetricModeWALRate {
+		value = s.rateTracker.rate(value, readAt)
+		if err := checkPostgreSQLFiniteValue(value); err != nil {
+			return 0, err
+		}
+	}
+	if s.metadata.maxChangePerRead > 0 && s.hasLastValue {
+		if capped := capPostgreSQLValueIncrease(s.lastValue, value, s.metadata.maxChangePerRead); capped != value {
+			s.logger.V(1).Info("capping postgreSQL value increase", "value", value, "previousValue", s.lastValue, "cappedValue", capped)
+			value = capped
+		}
+	}
+	s.lastValue, s.lastValueAt, s.hasLastValue = value, time.Now(), true
+	if !s.ready {
+		s.ready = true
+		s.recorder.recordReady(true)
+		s.logger.Info("postgreSQL scaler is ready", "value", value)
+	}
+	return value, nil
+}
+
+// isReady returns true once the scaler read a value successfully
+func (s *postgreSQLScaler) isReady() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.ready
+}
+
+// readValue returns the value pushed through notifyChannel, the value of the shared poller
+// or queries the database, together with when the value was read
+func (s *postgreSQLScaler) readValue(ctx context.Context) (float64, time.Time, error) {
+	if value, notifiedAt, ok := s.getNotifiedValue(); ok {
+		return value, notifiedAt, nil
+	}
+	s.mutex.Lock()
+	sharedPoller := s.sharedPoller
+	s.mutex.Unlock()
+	if sharedPoller != nil {
+		return sharedPoller.get(ctx)
+	}
+	value, err := s.pollValue(ctx)
+	return value, time.Now(), err
+}
+
+// pollValue queries the database unless the circuit breaker is open
+func (s *postgreSQLScaler) pollValue(ctx context.Context) (value float64, err error) {
+	err = s.withCircuitBreaker(func() (err error) {
+		value, err = s.queryDatabases(ctx)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return value, nil
+}
+
+// withCircuitBreaker runs query unless the circuit breaker is open, counting its failures
+func (s *postgreSQLScaler) withCircuitBreaker(query func() error) error {
+	if s.circuitBreaker == nil {
+		return query()
+	}
+	if !s.circuitBreaker.allow(time.Now()) {
+		return s.circuitBreaker.openError()
+	}
+	if err := query(); err != nil {
+		if s.circuitBreaker.recordFailure(time.Now(), err) {
+			s.logger.Info("opening postgreSQL circuit breaker", "failures", s.circuitBreaker.consecutiveFailures(), "cooldown", s.metadata.circuitBreakerCooldown.String(),
+				"category", getPostgreSQLErrorCategory(err))
+		}
+		return err
+	}
+	s.circuitBreaker.recordSuccess()
+	return nil
+}
+
+// queryDatabase runs the query against the database
+func (s *postgreSQLScaler) queryDatabase(ctx context.Context) (value float64, err error) {
+	// values which aren't read by the query, e.g. the inactive value in maintenance, have no timestamp
+	s.setValueTimestamp(sql.NullTime{}, 0)
+	s.reloadQueryFile()
+	err = s.withSession(ctx, func(session *postgreSQLSession) (err error) {
+		value, err = s.querySession(ctx, session)
+		return err
+	})
+	return value, err
+}
+
+// querySession runs the checks and the query of a read on session
+func (s *postgreSQLScaler) querySession(ctx context.Context, session *postgreSQLSession) (float64, error) {
+	querier, queryCtx := session.querier, session.ctx
+	if s.metadata.maintenanceQuery != "" {
+		inMaintenance, err := s.queryMaintenance(queryCtx, querier)
+		if err != nil {
+			err = session.timeoutErr(err)
+			s.logError(err, fmt.Sprintf("could not query postgreSQL maintenance flag: %s", err))
+			return 0, fmt.Errorf("could not query postgreSQL maintenance flag: %w", err)
+		}
+		if inMaintenance {
+			// the workload is deactivated and HPA scales to its minimum
+			return s.metadata.inactiveValue(), nil
+		}
+	}
+
+	if s.metadata.producerLivenessQuery != "" {
+		if err := s.checkProducerLiveness(queryCtx, querier); err != nil {
+			err = session.timeoutErr(err)
+			s.logError(err, fmt.Sprintf("postgreSQL producer liveness check failed: %s", err))
+			return 0, fmt.Errorf("postgreSQL producer liveness check failed: %w", err)
+		}
+	}
+
+	if s.metadata.deadTupleWarningTable != "" {
+		s.mutex.Lock()
+		due := s.deadTupleCheck.due(time.Now())
+		s.mutex.Unlock()
+		if due {
+			if _, _, err := s.checkDeadTuples(queryCtx, querier); err != nil {
+				s.logger.V(1).Info("could not read postgreSQL dead tuple statistics", "table", s.metadata.deadTupleWarningTable, "error", err.Error())
+			}
+		}
+	}
+
+	var backendPID int
+	if s.metadata.cancelAbandonedQueries {
+		// without the process ID the query is left to the cancellation of the driver
+		var err error
+		if backendPID, err = queryPostgreSQLBackendPID(queryCtx, querier); err != nil {
+			s.logger.V(1).Info("could not query postgreSQL backend process ID", "error", err.Error())
+		}
+	}
+
+	start := time.Now()
+	id, err := s.queryValue(queryCtx, querier)
+	s.recorder.recordQuery(time.Since(start), id, err)
+	if err != nil {
+		if backendPID != 0 && queryCtx.Err() != nil {
+			s.cancelAbandonedQuery(session.db, backendPID)
+		}
+		if s.treatErrorAsZero(err) {
+			return 0, nil
+		}
+		err = session.timeoutErr(err)
+		s.logError(err, fmt.Sprintf("could not query postgreSQL: %s", err))
+		return 0, fmt.Errorf("could not query postgreSQL: %w", err)
 	}
 	return id, nil
 }
 
-// GetMetricSpecForScaling returns the MetricSpec for the Horizontal Pod Autoscaler
+// withPostgreSQLTimeout bounds ctx by timeout, 0 leaves it unbounded
+func withPostgreSQLTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// attributePostgreSQLTimeout wraps err into timeoutErr when the deadline of the phase, rather than one of
+// the caller, expired. The driver reports the cancellation in its own words, so the context is checked
+func attributePostgreSQLTimeout(phaseCtx, ctx context.Context, err, timeoutErr error, timeout time.Duration) error {
+	if errors.Is(phaseCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+		return fmt.Errorf("%w after %s: %s", timeoutErr, timeout, err)
+	}
+	return err
+}
+
+// logError logs a query error. With errorLogInterval repeated errors with the same message are only
+// logged once per interval, mentioning how many were suppressed in between
+func (s *postgreSQLScaler) logError(err error, msg string) {
+	if s.logSampler == nil {
+		s.logger.Error(err, msg)
+		return
+	}
+	if ok, suppressed := s.logSampler.allow(err.Error(), time.Now()); ok {
+		s.logger.Error(err, msg, "suppressed", suppressed)
+	}
+}
+
+// treatErrorAsZero reports whether the SQLSTATE of err is one of treatErrorAsZeroSqlStates
+func (s *postgreSQLScaler) treatErrorAsZero(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && s.metadata.treatErrorAsZeroSQLStates[pqErr.Code] {
+		s.logger.V(1).Info("treating postgreSQL query error as no load", "sqlState", string(pqErr.Code), "error", pqErr.Message)
+		return true
+	}
+	return false
+}
+
+// validateQuery runs the query once. A query which should return a single value is checked to
+// return exactly one numeric column in one row, other ones are checked by computing their value
+func (s *postgreSQLScaler) validateQuery(ctx context.Context) error {
+	connection := s.connection.db
+	if (s.metadata.metricMode != postgreSQLMetricModeAbsolute && s.metadata.metricMode != postgreSQLMetricModeRate) ||
+		s.metadata.estimateMode || s.metadata.valueExpression != nil || s.metadata.targetFromQuery || s.metadata.subtractSecondColumn ||
+		s.metadata.timestampFromQuery || len(s.metadata.queries) > 0 {
+		_, err := s.queryValue(ctx, connection)
+		return err
+	}
+
+	rows, err := connection.QueryContext(ctx, s.getQuery(), s.metadata.queryArgs...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	if len(columns) != 1 {
+		return fmt.Errorf("query must return a single column, got %d: %s", len(columns), strings.Join(columns, ", "))
+	}
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		// no rows are reported as defaultValueOnNoRows
+		return nil
+	}
+	var value sql.NullString
+	if err := rows.Scan(&value); err != nil {
+		return err
+	}
+	if _, err := parsePostgreSQLResultValue(value, 0); err != nil {
+		return fmt.Errorf("column %s: %s", columns[0], err)
+	}
+	if rows.Next() {
+		return fmt.Errorf("query must return a single row, got more")
+	}
+	return rows.Err()
+}
+
+// checkPostgreSQLEncryption verifies that the session of the connection is encrypted, as reported by the server
+func checkPostgreSQLEncryption(ctx context.Context, connection postgreSQLQuerier) error {
+	var encrypted bool
+	err := connection.QueryRowContext(ctx, postgreSQLEncryptionQuery).Scan(&encrypted)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("the server reported no SSL status for the session")
+	}
+	if err != nil {
+		return err
+	}
+	if !encrypted {
+		return fmt.Errorf("the session isn't encrypted")
+	}
+	return nil
+}
+
+// queryMaintenance runs the maintenanceQuery, logging when the maintenance mode starts and ends.
+// NULL is treated as no maintenance
+func (s *postgreSQLScaler) queryMaintenance(ctx context.Context, connection postgreSQLQuerier) (bool, error) {
+	var maintenance sql.NullBool
+	if err := connection.QueryRowContext(ctx, s.metadata.maintenanceQuery).Scan(&maintenance); err != nil {
+		return false, err
+	}
+	inMaintenance := maintenance.Valid && maintenance.Bool
+
+	s.mutex.Lock()
+	changed := inMaintenance != s.inMaintenance
+	s.inMaintenance = inMaintenance
+	s.mutex.Unlock()
+	if changed && inMaintenance {
+		s.logger.Info("postgreSQL maintenance mode started, reporting an inactive value")
+	} else if changed {
+		s.logger.Info("postgreSQL maintenance mode ended, reporting the query value")
+	}
+	return inMaintenance, nil
+}
+
+// queryValue runs the query of the configured metricMode and computes the metric from its result
+func (s *postgreSQLScaler) queryValue(ctx context.Context, connection postgreSQLQuerier) (float64, error) {
+	switch s.metadata.metricMode {
+	case postgreSQLMetricModeConnectionSaturation:
+		var used, maxConnections float64
+		if err := connection.QueryRowContext(ctx, s.metadata.query).Scan(&used, &maxConnections); err != nil {
+			return 0, err
+		}
+		return computePostgreSQLConnectionSaturation(used, maxConnections)
+	case postgreSQLMetricModeRowCount:
+		return s.queryRowCount(ctx, connection)
+	case postgreSQLMetricModeReplicationSlotLag:
+		return s.queryReplicationSlotLag(ctx, connection)
+	case postgreSQLMetricModeSampledCount:
+		return s.querySampledCount(ctx, connection)
+	case postgreSQLMetricModeTableSize:
+		return s.queryTableSize(ctx, connection)
+	case postgreSQLMetricModeIdleInTransaction:
+		return s.queryIdleInTransaction(ctx, connection)
+	case postgreSQLMetricModeWALRate:
+		return s.queryWALPosition(ctx, connection)
+	case postgreSQLMetricModeBlockedQueries:
+		return s.queryBlockedQueries(ctx, connection)
+	case postgreSQLMetricModeAge:
+		return s.queryAge(ctx, connection, s.getQuery(), s.metadata.queryArgs...)
+	case postgreSQLMetricModeAnyOf:
+		return s.queryAnyOf(ctx, connection)
+	default:
+		if len(s.metadata.queries) > 0 {
+			return s.queryWeightedValue(ctx, connection)
+		}
+		if s.metadata.estimateMode {
+			var plan string
+			if err := connection.QueryRowContext(ctx, "EXPLAIN (FORMAT JSON) "+s.getQuery(), s.metadata.queryArgs...).Scan(&plan); err != nil {
+				return 0, err
+			}
+			return parsePostgreSQLExplainRows(plan)
+		}
+		if s.metadata.valueExpression != nil {
+			return s.queryExpressionValue(ctx, connection)
+		}
+		var value, target, inFlight sql.NullString
+		var timestamp sql.NullTime
+		dest := []interface{}{&value}
+		if s.metadata.targetFromQuery {
+			dest = append(dest, &target)
+		}
+		if s.metadata.subtractSecondColumn {
+			dest = append(dest, &inFlight)
+		}
+		if s.metadata.timestampFromQuery {
+			dest = append(dest, &timestamp)
+		}
+		var err error
+		if s.metadata.strictSingleRow {
+			err = queryPostgreSQLSingleRow(ctx, connection, s.getQuery(), s.metadata.queryArgs, dest...)
+		} else {
+			err = connection.QueryRowContext(ctx, s.getQuery(), s.metadata.queryArgs...).Scan(dest...)
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			if s.metadata.targetFromQuery {
+				s.setLiveTarget(target)
+			}
+			s.setValueTimestamp(timestamp, s.metadata.defaultValueOnNoRows)
+			return s.metadata.defaultValueOnNoRows, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		nullValue := s.metadata.defaultValueOnNoRows
+		if s.metadata.valueType == postgreSQLValueTypeInteger {
+			result, err := parsePostgreSQLIntegerResultValue(value, int64(nullValue))
+			if err != nil {
+				return 0, err
+			}
+			s.mutex.Lock()
+			s.integerValue = result
+			s.mutex.Unlock()
+			s.setValueTimestamp(timestamp, float64(result))
+			return float64(result), nil
+		}
+		result, err := parsePostgreSQLFormattedResultValue(value, nullValue, s.metadata.resultParser)
+		if err != nil {
+			return 0, err
+		}
+		if s.metadata.targetFromQuery {
+			s.setLiveTarget(target)
+		}
+		if s.metadata.subtractSecondColumn {
+			// NULL, e.g. sum() over no rows being processed, subtracts nothing
+			inFlightValue, err := parsePostgreSQLResultValue(inFlight, 0)
+			if err != nil {
+				return 0, err
+			}
+			pending := computePostgreSQLPendingValue(result, inFlightValue)
+			s.setValueTimestamp(timestamp, pending)
+			return pending, nil
+		}
+		s.setValueTimestamp(timestamp, result)
+		return result, nil
+	}
+}
+
+// queryReplicationSlotLag returns the lag of the configured slot. A missing slot, e.g. before the CDC
+// connector created it, and a slot which never reserved WAL have no lag
+func (s *postgreSQLScaler) queryReplicationSlotLag(ctx context.Context, connection postgreSQLQuerier) (float64, error) {
+	var currentLSN, slotLSN sql.NullString
+	err := connection.QueryRowContext(ctx, s.metadata.query, s.metadata.queryArgs...).Scan(&currentLSN, &slotLSN)
+	if errors.Is(err, sql.ErrNoRows) {
+		s.logger.V(1).Info("Replication slot not found, reporting no lag", "slotName", s.metadata.slotName)
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if !currentLSN.Valid || !slotLSN.Valid {
+		return 0, nil
+	}
+	return computePostgreSQLReplicationSlotLag(currentLSN.String, slotLSN.String)
+}
+
+// checkProducerLiveness runs the producerLivenessQuery, reporting when the producers stalled or resumed.
+// A stall is only an error with failOnProducerStall
+func (s *postgreSQLScaler) checkProducerLiveness(ctx context.Context, connection postgreSQLQuerier) error {
+	var latest sql.NullTime
+	if err := connection.QueryRowContext(ctx, s.metadata.producerLivenessQuery).Scan(&latest); err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	stalled := s.liveness.stalled(latest.Time, time.Now())
+	changed := stalled != s.producerStalled
+	s.producerStalled = stalled
+	s.mutex.Unlock()
+	if changed {
+		s.recorder.recordProducerStalled(stalled)
+		if stalled {
+			s.logger.Info("postgreSQL producers stalled, the latest write timestamp didn't advance", "window", s.metadata.producerStallWindow.String(), "latest", latest.Time)
+		} else {
+			s.logger.Info("postgreSQL producers resumed writing", "latest", latest.Time)
+		}
+	}
+	if stalled && s.metadata.failOnProducerStall {
+		return fmt.Errorf("latest write timestamp %s didn't advance within %s", latest.Time, s.metadata.producerStallWindow)
+	}
+	return nil
+}
+
+// setLiveTarget keeps the target column of the last query. NULL, zero, negative or non numeric targets
+// fall back to the static targetQueryValue
+func (s *postgreSQLScaler) setLiveTarget(value sql.NullString) {
+	target, err := parsePostgreSQLResultValue(value, 0)
+	if err != nil || target <= 0 {
+		s.logger.V(1).Info("falling back to targetQueryValue, the query returned no usable target", "target", value.String)
+		target = 0
+	}
+	s.mutex.Lock()
+	s.liveTarget = target
+	s.mutex.Unlock()
+	if target > 0 {
+		s.recorder.recordTargetValue(target)
+	} else {
+		s.recorder.recordTargetValue(s.metadata.targetQueryValue)
+	}
+}
+
+// queryAge returns the seconds since the timestamp returned by query, e.g. the creation of the
+// oldest pending row. The query can also compute the age itself and return an interval or seconds.
+// No rows and NULL, which min() returns over no rows, count as the defaultValueOnNoRows
+func (s *postgreSQLScaler) queryAge(ctx context.Context, connection postgreSQLQuerier, query string, args ...interface{}) (float64, error) {
+	var result interface{}
+	err := connection.QueryRowContext(ctx, query, args...).Scan(&result)
+	if errors.Is(err, sql.ErrNoRows) {
+		return s.metadata.defaultValueOnNoRows, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	nullValue := s.metadata.defaultValueOnNoRows
+	var age float64
+	switch result := result.(type) {
+	case nil:
+		return nullValue, nil
+	case time.Time:
+		age = time.Since(result).Seconds()
+	case int64:
+		age = float64(result)
+	case float64:
+		age = result
+	case []byte:
+		if age, err = parsePostgreSQLResultValue(sql.NullString{String: string(result), Valid: true}, nullValue); err != nil {
+			return 0, err
+		}
+	case string:
+		if age, err = parsePostgreSQLResultValue(sql.NullString{String: result, Valid: true}, nullValue); err != nil {
+			return 0, err
+		}
+	default:
+		return 0, fmt.Errorf("query result of type %T is neither a timestamp nor an age", result)
+	}
+	// timestamps in the future, e.g. with clock skew, don't make anything older
+	return math.Max(0, age), nil
+}
+
+// queryExpressionValue evaluates the valueExpression over the columns of the first row of the query.
+// Columns are converted like single value results, so NULL and no rows count as the defaultValueOnNoRows
+func (s *postgreSQLScaler) queryExpressionValue(ctx context.Context, connection postgreSQLQuerier) (float64, error) {
+	rows, err := connection.QueryContext(ctx, s.getQuery(), s.metadata.queryArgs...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	names, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return 0, err
+		}
+		return s.metadata.defaultValueOnNoRows, nil
+	}
+
+	values := make([]sql.NullString, len(names))
+	pointers := make([]interface{}, len(names))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+	if err := rows.Scan(pointers...); err != nil {
+		return 0, err
+	}
+
+	nullValue := s.metadata.defaultValueOnNoRows
+	columns := make(map[string]float64, len(names))
+	for i, name := range names {
+		value, err := parsePostgreSQLResultValue(values[i], nullValue)
+		if err != nil {
+			return 0, fmt.Errorf("column %s: %s", name, err)
+		}
+		columns[name] = value
+	}
+	return s.metadata.valueExpression.evaluate(columns)
+}
+
+// parsePostgreSQLExplainRows returns the estimated rows of an EXPLAIN (FORMAT JSON) output. For a plain
+// aggregate such as count(*), the estimate of its input is returned since the aggregate itself yields one row
+func parsePostgreSQLExplainRows(explain string) (float64, error) {
+	var plans []struct {
+		Plan postgreSQLExplainPlan `json:"Plan"`
+	}
+	if err := json.Unmarshal([]byte(explain), &plans); err != nil {
+		return 0, fmt.Errorf("error parsing query plan: %s", err)
+	}
+	if len(plans) == 0 {
+		return 0, fmt.Errorf("query plan is empty")
+	}
+
+	plan := plans[0].Plan
+	for plan.NodeType == "Aggregate" && plan.Strategy == "Plain" && len(plan.Plans) == 1 {
+		plan = plan.Plans[0]
+	}
+	return plan.PlanRows, nil
+}
+
+// computePostgreSQLConnectionSaturation returns the fraction of the available connections which are in use
+func computePostgreSQLConnectionSaturation(used, maxConnections float64) (float64, error) {
+	if maxConnections <= 0 {
+		return 0, fmt.Errorf("max_connections must be positive, got %v", maxConnections)
+	}
+	return used / maxConnections, nil
+}
+
+// GetMetricSpecForScaling returns the MetricSpec for the Horizontal Pod Autoscaler. It's derived from the
+// metadata only, so the spec doesn't change while the database is unreachable
 func (s *postgreSQLScaler) GetMetricSpecForScaling(context.Context) []v2.MetricSpec {
 	externalMetric := &v2.ExternalMetricSource{
 		Metric: v2.MetricIdentifier{
@@ -205,10 +2409,53 @@ func (s *postgreSQLScaler) GetMetricSpecForScaling(context.Context) []v2.MetricS
 func (s *postgreSQLScaler) GetMetrics(ctx context.Context, metricName string) ([]external_metrics.ExternalMetricValue, error) {
 	num, err := s.getActiveNumber(ctx)
 	if err != nil {
-		return []external_metrics.ExternalMetricValue{}, fmt.Errorf("error inspecting postgreSQL: %s", err)
+		return []external_metrics.ExternalMetricValue{}, newPostgreSQLError(fmt.Errorf("error inspecting postgreSQL: %w", err))
+	}
+	value := num
+
+	if s.metadata.capacityQuery != "" {
+		capacity, err := s.queryCapacity(ctx)
+		if err != nil {
+			return []external_metrics.ExternalMetricValue{}, newPostgreSQLError(fmt.Errorf("error inspecting postgreSQL capacity: %w", err))
+		}
+		target := s.metadata.effectiveTarget(capacity)
+		s.logger.V(1).Info("computed postgreSQL target from capacity", "capacity", capacity, "target", target)
+		s.recorder.recordTargetValue(target)
+		num = num / target * s.metadata.targetQueryValue
+	}
+	if s.metadata.targetFromQuery {
+		// the HPA target stays targetQueryValue, the value is scaled by how much the live target differs
+		s.mutex.Lock()
+		liveTarget := s.liveTarget
+		s.mutex.Unlock()
+		if liveTarget > 0 {
+			num = num / liveTarget * s.metadata.targetQueryValue
+		}
+	}
+
+	// num is relative to targetQueryValue now, even with a target from the capacity or the query
+	s.mutex.Lock()
+	crossing := s.thresholdState.update(num, s.metadata.targetQueryValue)
+	s.mutex.Unlock()
+	if crossing != "" {
+		s.recorder.recordThresholdCrossing(crossing)
 	}
 
 	metric := GenerateMetricInMili(metricName, num)
+	if s.metadata.hasMetricPrecision {
+		metric.Value = postgreSQLMilliQuantity(num, s.metadata.metricPrecision)
+	}
+	if s.metadata.valueType == postgreSQLValueTypeInteger {
+		// num is the float64 of the integer unless a fallback such as the inactive value was reported
+		s.mutex.Lock()
+		integerValue := s.integerValue
+		s.mutex.Unlock()
+		if float64(integerValue) == num {
+			metric.Value = *resource.NewQuantity(integerValue, resource.DecimalSI)
+		}
+	}
+	metric.MetricLabels = s.metadata.metricLabels
+	s.applyValueTimestamp(&metric, value)
 
 	return append([]external_metrics.ExternalMetricValue{}, metric), nil
 }
@@ -0,0 +1,85 @@
+package scalers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parsePostgreSQLServerVersionMetadata parses the minServerVersion the query works with
+func parsePostgreSQLServerVersionMetadata(config *ScalerConfig, meta *postgreSQLMetadata) error {
+	if val, ok := config.TriggerMetadata["minServerVersion"]; ok && val != "" {
+		minServerVersion, err := parsePostgreSQLServerVersion(val)
+		if err != nil {
+			return fmt.Errorf("minServerVersion parsing error %s", err.Error())
+		}
+		meta.minServerVersion = minServerVersion
+	}
+	return nil
+}
+
+// parsePostgreSQLServerVersion converts a version such as 14, 12.4 or 9.6.3 into the form of server_version_num,
+// e.g. 140000, 120004 and 90603. A number of at least 10000 is taken as server_version_num already
+func parsePostgreSQLServerVersion(version string) (int, error) {
+	parts := strings.Split(strings.TrimSpace(version), ".")
+	numbers := make([]int, len(parts))
+	for i, part := range parts {
+		number, err := strconv.Atoi(part)
+		if err != nil || number < 0 {
+			return 0, fmt.Errorf("invalid version %q", version)
+		}
+		numbers[i] = number
+	}
+
+	switch {
+	case len(numbers) == 1 && numbers[0] >= 10000:
+		return numbers[0], nil
+	case numbers[0] == 0:
+		return 0, fmt.Errorf("invalid version %q", version)
+	case numbers[0] >= 10 && len(numbers) <= 2:
+		// since PostgreSQL 10 the second number is the minor version
+		minor := 0
+		if len(numbers) == 2 {
+			minor = numbers[1]
+		}
+		return numbers[0]*10000 + minor, nil
+	case numbers[0] < 10 && len(numbers) <= 3:
+		// before PostgreSQL 10 the first two numbers are the major version
+		result := numbers[0] * 10000
+		for i, number := range numbers[1:] {
+			if number > 99 {
+				return 0, fmt.Errorf("invalid version %q", version)
+			}
+			result += number * []int{100, 1}[i]
+		}
+		return result, nil
+	default:
+		return 0, fmt.Errorf("invalid version %q", version)
+	}
+}
+
+// checkPostgreSQLServerVersion fails when the server is older than minServerVersion
+func checkPostgreSQLServerVersion(ctx context.Context, connection postgreSQLQuerier, minServerVersion int) error {
+	var serverVersion int
+	if err := connection.QueryRowContext(ctx, "SHOW server_version_num").Scan(&serverVersion); err != nil {
+		return fmt.Errorf("could not query the server version: %w", err)
+	}
+	if serverVersion < minServerVersion {
+		return fmt.Errorf("postgreSQL server version %d is older than minServerVersion %d", serverVersion, minServerVersion)
+	}
+	return nil
+}
+
+// checkServerVersion checks the server against minServerVersion through connection, a connection of db.
+// A satisfied check is remembered until db is replaced, e.g. after the TLS files were rotated
+func (s *postgreSQLScaler) checkServerVersion(ctx context.Context, connection postgreSQLQuerier, db *sql.DB) error {
+	if err := checkPostgreSQLServerVersion(ctx, connection, s.metadata.minServerVersion); err != nil {
+		return err
+	}
+	s.mutex.Lock()
+	s.serverVersionCheckedFor = db
+	s.mutex.Unlock()
+	return nil
+}
@@ -0,0 +1,102 @@
+package scalers
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestParsePostgreSQLServerVersion(t *testing.T) {
+	testData := []struct {
+		version     string
+		expected    int
+		raisesError bool
+	}{
+		{version: "14", expected: 140000},
+		{version: "12.4", expected: 120004},
+		{version: " 16.1 ", expected: 160001},
+		{version: "9.6", expected: 90600},
+		{version: "9.6.3", expected: 90603},
+		{version: "120004", expected: 120004},
+		{version: "12.4.1", raisesError: true},
+		{version: "9.6.3.1", raisesError: true},
+		{version: "9.100", raisesError: true},
+		{version: "0", raisesError: true},
+		{version: "-12", raisesError: true},
+		{version: "v14", raisesError: true},
+		{version: "14.", raisesError: true},
+	}
+
+	for _, testData := range testData {
+		version, err := parsePostgreSQLServerVersion(testData.version)
+		if err != nil && !testData.raisesError {
+			t.Errorf("%q: expected success but got error %s", testData.version, err)
+		}
+		if err == nil && testData.raisesError {
+			t.Errorf("%q: expected error but got %d", testData.version, version)
+		}
+		if err == nil && version != testData.expected {
+			t.Errorf("%q: expected %d but got %d", testData.version, testData.expected, version)
+		}
+	}
+}
+
+func TestPostgreSQLMinServerVersion(t *testing.T) {
+	testData := []struct {
+		name          string
+		eagerConnect  string
+		serverVersion string
+		raisesError   bool
+	}{
+		{name: "satisfied", eagerConnect: "true", serverVersion: "150002"},
+		{name: "equal", eagerConnect: "true", serverVersion: "140000"},
+		{name: "too old", eagerConnect: "true", serverVersion: "130011", raisesError: true},
+		{name: "satisfied without eagerConnect", eagerConnect: "false", serverVersion: "150002"},
+		{name: "too old without eagerConnect", eagerConnect: "false", serverVersion: "90624", raisesError: true},
+	}
+
+	for _, testData := range testData {
+		t.Run(testData.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+			if err != nil {
+				t.Fatal("Could not create sqlmock:", err)
+			}
+			if testData.eagerConnect == "true" {
+				mock.ExpectPing()
+			}
+			mock.ExpectQuery("SHOW server_version_num").WillReturnRows(sqlmock.NewRows([]string{"server_version_num"}).AddRow(testData.serverVersion))
+			if !testData.raisesError {
+				// the version is checked once per connection
+				mock.ExpectQuery("SELECT count").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+				mock.ExpectQuery("SELECT count").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(4))
+			}
+			if testData.raisesError && testData.eagerConnect == "true" {
+				mock.ExpectClose()
+			}
+
+			scaler, err := newPostgreSQLScaler(&ScalerConfig{
+				TriggerMetadata: map[string]string{"query": "SELECT count(*) FROM pg_stat_slru", "targetQueryValue": "5", "minServerVersion": "14", "eagerConnect": testData.eagerConnect},
+				AuthParams:      map[string]string{"connection": "host=localhost"},
+			}, newPostgreSQLConnectionPool(func(*postgreSQLMetadata) (*sql.DB, error) {
+				return db, nil
+			}, 0))
+			if err == nil {
+				for i := 0; i < 2 && err == nil; i++ {
+					_, err = scaler.getActiveNumber(context.Background())
+				}
+			}
+			if err != nil && !testData.raisesError {
+				t.Error("Expected success but got error", err)
+			}
+			if testData.raisesError && (err == nil || !strings.Contains(err.Error(), "older than minServerVersion 140000")) {
+				t.Errorf("Expected a server version error but got %v", err)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
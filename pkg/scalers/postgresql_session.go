@@ -0,0 +1,162 @@
+package scalers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"runtime/debug"
+	"time"
+)
+
+// postgreSQLSession is the connection the queries of a read run on, e.g. the query, the activationQuery
+// or the capacityQuery. It's set up the same way for all of them, so none of them skips the credential
+// refresh, the query slots, the pinned connection, the encryption check or the comment of queryCommentTag
+type postgreSQLSession struct {
+	// ctx bounds the queries of the session by queryTimeout
+	ctx    context.Context
+	cancel context.CancelFunc
+	// parent is the context of the read, telling a queryTimeout from a deadline of the caller
+	parent  context.Context
+	timeout time.Duration
+	querier postgreSQLQuerier
+	// db is the database handle conn was acquired from
+	db   *sql.DB
+	conn *sql.Conn
+	sem  *postgreSQLQuerySemaphore
+}
+
+// timeoutErr attributes err to the queryTimeout if it expired
+func (q *postgreSQLSession) timeoutErr(err error) error {
+	return attributePostgreSQLTimeout(q.ctx, q.parent, err, errPostgreSQLQueryTimeout, q.timeout)
+}
+
+// withSession runs queries on a session. A failed connection whose SQLSTATE is one of treatErrorAsZeroSqlStates
+// doesn't run them, so their results stay zero. The driver may panic on malformed responses, e.g. when something
+// else than PostgreSQL answers on the port, which is turned into an error instead of crashing the operator
+func (s *postgreSQLScaler) withSession(ctx context.Context, queries func(session *postgreSQLSession) error) (err error) {
+	defer s.recoverPanic(&err)
+	session, err := s.openSession(ctx)
+	if err != nil || session == nil {
+		return err
+	}
+	defer func() {
+		// database/sql keeps the connection locked after a panic of the driver, closing it would block forever
+		if r := recover(); r != nil {
+			s.closeSession(session, err, true)
+			panic(r)
+		}
+		s.closeSession(session, err, false)
+	}()
+	return queries(session)
+}
+
+// recoverPanic turns a panic into err, it must be deferred
+func (s *postgreSQLScaler) recoverPanic(err *error) {
+	if r := recover(); r != nil {
+		*err = fmt.Errorf("recovered from panic querying postgreSQL: %v", r)
+		s.logger.Error(*err, "panic querying postgreSQL", "stack", string(debug.Stack()))
+	}
+}
+
+// openSession acquires the connection of a session, reconnecting first if the credentials expired or the
+// TLS files or the passwordFromFile changed. It returns no session if connecting failed with an error
+// which is treated as zero
+func (s *postgreSQLScaler) openSession(ctx context.Context) (*postgreSQLSession, error) {
+	if err := s.refreshExpiredCredentials(ctx); err != nil {
+		return nil, &postgreSQLError{reason: postgreSQLErrorReasonAuthentication, err: fmt.Errorf("error refreshing postgreSQL credentials: %s", err)}
+	}
+	if err := s.refreshConnectionOnTLSRotation(ctx); err != nil {
+		return nil, fmt.Errorf("error reconnecting postgreSQL after TLS files changed: %w", err)
+	}
+	if err := s.refreshConnectionOnPasswordFileChange(ctx); err != nil {
+		return nil, fmt.Errorf("error reconnecting postgreSQL after passwordFromFile changed: %w", err)
+	}
+
+	s.mutex.Lock()
+	connection := s.connection.db
+	serverVersionChecked := s.serverVersionCheckedFor == connection
+	sem := s.querySemaphore
+	firstQueryAt := s.firstQueryAt
+	s.mutex.Unlock()
+
+	if delay := time.Until(firstQueryAt); delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, fmt.Errorf("error waiting for the first postgreSQL query: %s", ctx.Err())
+		}
+	}
+
+	if sem != nil {
+		if err := sem.wait(ctx); err != nil {
+			return nil, fmt.Errorf("error waiting for a free postgreSQL query slot: %s", err)
+		}
+	}
+
+	// the connection is acquired explicitly, so establishing it isn't counted as query time
+	start := time.Now()
+	acquireCtx, cancelAcquire := withPostgreSQLTimeout(ctx, s.metadata.connectionAcquireTimeout)
+	var conn *sql.Conn
+	var err error
+	if s.pinnedConnection != nil {
+		conn, err = s.pinnedConnection.acquire(acquireCtx, connection)
+	} else {
+		conn, err = connection.Conn(acquireCtx)
+	}
+	cancelAcquire()
+	s.recorder.recordConnection(time.Since(start))
+	if err != nil {
+		if sem != nil {
+			sem.done()
+		}
+		if s.treatErrorAsZero(err) {
+			return nil, nil
+		}
+		err = attributePostgreSQLTimeout(acquireCtx, ctx, err, errPostgreSQLConnectionAcquireTimeout, s.metadata.connectionAcquireTimeout)
+		s.logError(err, fmt.Sprintf("could not connect to postgreSQL: %s", err))
+		return nil, fmt.Errorf("could not connect to postgreSQL: %w", err)
+	}
+
+	session := &postgreSQLSession{parent: ctx, timeout: s.metadata.queryTimeout, querier: conn, db: connection, conn: conn, sem: sem}
+	if s.metadata.queryComment != "" {
+		session.querier = &postgreSQLCommentedQuerier{querier: conn, comment: s.metadata.queryComment}
+	}
+	session.ctx, session.cancel = withPostgreSQLTimeout(ctx, s.metadata.queryTimeout)
+
+	if s.metadata.requireEncryption {
+		if err := checkPostgreSQLEncryption(session.ctx, session.querier); err != nil {
+			err = session.timeoutErr(err)
+			s.logError(err, fmt.Sprintf("postgreSQL encryption check failed: %s", err))
+			err = &postgreSQLError{reason: postgreSQLErrorReasonConnection, err: fmt.Errorf("postgreSQL encryption check failed: %w", err)}
+			s.closeSession(session, err, false)
+			return nil, err
+		}
+	}
+
+	if s.metadata.minServerVersion > 0 && !serverVersionChecked {
+		if err := s.checkServerVersion(session.ctx, session.querier, connection); err != nil {
+			err = session.timeoutErr(err)
+			s.logError(err, err.Error())
+			s.closeSession(session, err, false)
+			return nil, err
+		}
+	}
+	return session, nil
+}
+
+// closeSession hands the connection of the session back after its queries failed with err. After a panic
+// of the driver the connection is only dropped
+func (s *postgreSQLScaler) closeSession(session *postgreSQLSession, err error, panicked bool) {
+	session.cancel()
+	if session.sem != nil {
+		session.sem.done()
+	}
+	switch {
+	case s.pinnedConnection != nil && panicked:
+		s.pinnedConnection.discard()
+	case s.pinnedConnection != nil:
+		s.pinnedConnection.release(err)
+	case !panicked:
+		session.conn.Close()
+	}
+}
@@ -0,0 +1,32 @@
+package scalers
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+)
+
+// parsePostgreSQLConnectionShards parses connectionShards, a JSON array of connection strings
+func parsePostgreSQLConnectionShards(shards string) ([]string, error) {
+	var connections []string
+	if err := json.Unmarshal([]byte(shards), &connections); err != nil {
+		return nil, fmt.Errorf("must be a JSON array of connection strings: %s", err)
+	}
+	if len(connections) == 0 {
+		return nil, fmt.Errorf("must contain at least one connection")
+	}
+	for i, connection := range connections {
+		if connection == "" {
+			return nil, fmt.Errorf("connection %d is empty", i)
+		}
+	}
+	return connections, nil
+}
+
+// getPostgreSQLShard returns the index of the shard of a workload: the FNV-1a hash of namespace/name
+// modulo the number of shards. The mapping only changes when shards are added or removed
+func getPostgreSQLShard(namespace, name string, shards int) int {
+	hash := fnv.New64a()
+	hash.Write([]byte(namespace + "/" + name))
+	return int(hash.Sum64() % uint64(shards))
+}
@@ -0,0 +1,68 @@
+package scalers
+
+import "testing"
+
+func TestPostgreSQLShard(t *testing.T) {
+	testData := []struct {
+		namespace string
+		name      string
+		shards    int
+		expected  int
+	}{
+		{namespace: "default", name: "orders-worker", shards: 3, expected: 2},
+		{namespace: "default", name: "payments-worker", shards: 3, expected: 0},
+		{namespace: "default", name: "emails-worker", shards: 3, expected: 0},
+		{namespace: "default", name: "reports-worker", shards: 3, expected: 1},
+		// the namespace is part of the workload identity
+		{namespace: "staging", name: "orders-worker", shards: 3, expected: 1},
+		{namespace: "default", name: "orders-worker", shards: 4, expected: 3},
+		{namespace: "default", name: "orders-worker", shards: 1, expected: 0},
+	}
+
+	for _, testData := range testData {
+		// the mapping has to be stable across calls and restarts
+		for i := 0; i < 2; i++ {
+			if shard := getPostgreSQLShard(testData.namespace, testData.name, testData.shards); shard != testData.expected {
+				t.Errorf("Expected %s/%s to map to shard %d of %d but got %d", testData.namespace, testData.name, testData.expected, testData.shards, shard)
+			}
+		}
+	}
+}
+
+func TestPostgreSQLConnectionShards(t *testing.T) {
+	testData := []struct {
+		name        string
+		shards      string
+		expected    string
+		raisesError bool
+	}{
+		{name: "orders-worker", shards: `["host=shard0", "host=shard1", "host=shard2"]`, expected: "host=shard2"},
+		{name: "payments-worker", shards: `["host=shard0", "host=shard1", "host=shard2"]`, expected: "host=shard0"},
+		{name: "orders-worker", shards: `["host=shard0"]`, expected: "host=shard0"},
+		{name: "orders-worker", shards: `[]`, raisesError: true},
+		{name: "orders-worker", shards: `["host=shard0", ""]`, raisesError: true},
+		{name: "orders-worker", shards: `host=shard0`, raisesError: true},
+	}
+
+	for _, testData := range testData {
+		meta, err := parsePostgreSQLMetadata(&ScalerConfig{
+			TriggerMetadata:         map[string]string{"query": "SELECT count(*) FROM jobs", "targetQueryValue": "5"},
+			AuthParams:              map[string]string{"connectionShards": testData.shards},
+			ScalableObjectNamespace: "default",
+			ScalableObjectName:      testData.name,
+		})
+		if testData.raisesError {
+			if err == nil {
+				t.Errorf("Expected error for %s but got success", testData.shards)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Unexpected error for %s: %s", testData.shards, err)
+			continue
+		}
+		if meta.connection != testData.expected {
+			t.Errorf("Expected %s to use %s but got %s", testData.name, testData.expected, meta.connection)
+		}
+	}
+}
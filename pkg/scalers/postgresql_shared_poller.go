@@ -0,0 +1,177 @@
+package scalers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mitchellh/hashstructure"
+)
+
+// postgreSQLSharedPollerScalerKeys don't change the value read from the database, so scalers which only
+// differ in them share a poller
+var postgreSQLSharedPollerScalerKeys = []string{"targetQueryValue", "activationTargetQueryValue", "metricNamePrefix", "metricName", "metricDescription", "metricLabels", "metricPrecision"}
+
+// postgreSQLSharedPollers holds the pollers shared by all scalers with sharedPollingInterval reading the
+// same value from the same database
+var (
+	postgreSQLSharedPollers      = map[uint64]*postgreSQLSharedPoller{}
+	postgreSQLSharedPollersMutex sync.Mutex
+)
+
+// parsePostgreSQLSharedPollerMetadata parses the sharedPollingInterval of the poller shared by scalers with the same query
+func parsePostgreSQLSharedPollerMetadata(config *ScalerConfig, meta *postgreSQLMetadata) error {
+	if val, ok := config.TriggerMetadata["sharedPollingInterval"]; ok && val != "" {
+		sharedPollingInterval, err := parsePostgreSQLDuration("sharedPollingInterval", val)
+		if err != nil {
+			return err
+		}
+		if sharedPollingInterval <= 0 {
+			return fmt.Errorf("sharedPollingInterval must be positive, got %s", sharedPollingInterval)
+		}
+		meta.sharedPollingInterval = sharedPollingInterval
+	}
+	return nil
+}
+
+// postgreSQLPollFunc reads the value of a subscriber from the database
+type postgreSQLPollFunc func(ctx context.Context) (float64, error)
+
+// postgreSQLSharedPoller runs the query once per interval in the background and serves the last result to
+// all its subscribers. The query of the oldest subscriber is used, so it moves on when that one is closed
+type postgreSQLSharedPoller struct {
+	key      uint64
+	interval time.Duration
+	// subscribers are the poll functions in subscription order, guarded by postgreSQLSharedPollersMutex
+	subscribers []*postgreSQLPollFunc
+	stop        chan struct{}
+
+	mutex sync.Mutex
+	// polled is closed after the first poll
+	polled chan struct{}
+	value  float64
+	err    error
+	// polledAt is when the value was read, which the rate of metricMode rate and walRate is computed for
+	polledAt time.Time
+}
+
+// getPostgreSQLSharedPollerKey hashes the trigger metadata which affects the value, the arguments bound to the
+// query and the key of the connection pool, so scalers only share a poller if they share the database handle as
+// well. The arguments separate the scalers whose query binds their workload with bindWorkloadParameters
+func getPostgreSQLSharedPollerKey(config *ScalerConfig, meta *postgreSQLMetadata, connectionKey postgreSQLConnectionPoolKey) (uint64, error) {
+	metadata := map[string]string{}
+	for key, value := range config.TriggerMetadata {
+		metadata[key] = value
+	}
+	for _, key := range postgreSQLSharedPollerScalerKeys {
+		delete(metadata, key)
+	}
+	return hashstructure.Hash(struct {
+		Connection string
+		Metadata   map[string]string
+		QueryArgs  string
+	}{Connection: fmt.Sprintf("%#v", connectionKey), Metadata: metadata, QueryArgs: fmt.Sprintf("%#v", meta.queryArgs)}, nil)
+}
+
+// acquirePostgreSQLSharedPoller subscribes poll to the poller of key, starting it for the first subscriber
+func acquirePostgreSQLSharedPoller(key uint64, interval time.Duration, poll postgreSQLPollFunc) (*postgreSQLSharedPoller, *postgreSQLPollFunc) {
+	postgreSQLSharedPollersMutex.Lock()
+	defer postgreSQLSharedPollersMutex.Unlock()
+
+	subscription := &poll
+	poller, ok := postgreSQLSharedPollers[key]
+	if ok {
+		poller.subscribers = append(poller.subscribers, subscription)
+		return poller, subscription
+	}
+	poller = &postgreSQLSharedPoller{
+		key:         key,
+		interval:    interval,
+		subscribers: []*postgreSQLPollFunc{subscription},
+		stop:        make(chan struct{}),
+		polled:      make(chan struct{}),
+	}
+	postgreSQLSharedPollers[key] = poller
+	go poller.run()
+	return poller, subscription
+}
+
+// release unsubscribes, the last subscriber stops the poller
+func (p *postgreSQLSharedPoller) release(subscription *postgreSQLPollFunc) {
+	postgreSQLSharedPollersMutex.Lock()
+	defer postgreSQLSharedPollersMutex.Unlock()
+
+	for i, subscriber := range p.subscribers {
+		if subscriber == subscription {
+			p.subscribers = append(p.subscribers[:i], p.subscribers[i+1:]...)
+			break
+		}
+	}
+	if len(p.subscribers) == 0 {
+		delete(postgreSQLSharedPollers, p.key)
+		close(p.stop)
+	}
+}
+
+// pollFunc returns the poll function of the oldest subscriber, nil once all of them are gone
+func (p *postgreSQLSharedPoller) pollFunc() postgreSQLPollFunc {
+	postgreSQLSharedPollersMutex.Lock()
+	defer postgreSQLSharedPollersMutex.Unlock()
+	if len(p.subscribers) == 0 {
+		return nil
+	}
+	return *p.subscribers[0]
+}
+
+func (p *postgreSQLSharedPoller) run() {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		p.poll()
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll runs the query, a poll may take at most an interval
+func (p *postgreSQLSharedPoller) poll() {
+	poll := p.pollFunc()
+	if poll == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), p.interval)
+	defer cancel()
+	go func() {
+		select {
+		case <-p.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	value, err := poll(ctx)
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.value, p.err, p.polledAt = value, err, time.Now()
+	select {
+	case <-p.polled:
+	default:
+		close(p.polled)
+	}
+}
+
+// get returns the result of the last poll and when it was read, waiting for the first one
+func (p *postgreSQLSharedPoller) get(ctx context.Context) (float64, time.Time, error) {
+	select {
+	case <-p.polled:
+	case <-ctx.Done():
+		return 0, time.Time{}, fmt.Errorf("error waiting for the first shared postgreSQL poll: %s", ctx.Err())
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.value, p.polledAt, p.err
+}
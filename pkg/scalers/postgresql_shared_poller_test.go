@@ -0,0 +1,261 @@
+package scalers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+var testPostgreSQLSharedPollerMetadata = []parsePostgresMetadataTestData{
+	// sharedPollingInterval
+	{
+		metadata:    map[string]string{"query": "query", "targetQueryValue": "12", "sharedPollingInterval": "30s"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		resolvedEnv: map[string]string{},
+		raisesError: false,
+	},
+	// negative sharedPollingInterval
+	{
+		metadata:    map[string]string{"query": "query", "targetQueryValue": "12", "sharedPollingInterval": "-30s"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		resolvedEnv: map[string]string{},
+		raisesError: true,
+	},
+}
+
+func TestParsePostgreSQLSharedPollerMetadata(t *testing.T) {
+	testParsePostgreSQLMetadata(t, testPostgreSQLSharedPollerMetadata)
+}
+
+func TestGetPostgreSQLSharedPollerKey(t *testing.T) {
+	base := map[string]string{"query": "SELECT count(*) FROM jobs", "targetQueryValue": "5", "sharedPollingInterval": "10s"}
+	key := func(connection string, changes map[string]string, authParams ...map[string]string) uint64 {
+		metadata := map[string]string{}
+		for k, v := range base {
+			metadata[k] = v
+		}
+		for k, v := range changes {
+			metadata[k] = v
+		}
+		meta := &postgreSQLMetadata{connection: connection}
+		for _, params := range authParams {
+			meta.sslServerName, meta.sslKeyPassword, meta.sslRevocationCheck = params["sslServerName"], params["sslKeyPassword"], params["sslRevocationCheck"]
+		}
+		key, err := getPostgreSQLSharedPollerKey(&ScalerConfig{TriggerMetadata: metadata}, meta, getPostgreSQLConnectionPoolKey(meta))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return key
+	}
+
+	shared := key("host=localhost", nil)
+	if key("host=localhost", map[string]string{"targetQueryValue": "50", "activationTargetQueryValue": "2", "metricName": "other"}) != shared {
+		t.Error("Expected scalers only differing in their targets to share a poller")
+	}
+	if key("host=other", nil) == shared {
+		t.Error("Expected scalers of different connections not to share a poller")
+	}
+	// the auth params changing how the connection is made separate the pollers as they separate the database handles
+	for _, authParams := range []map[string]string{{"sslServerName": "db.internal"}, {"sslKeyPassword": "secret"}, {"sslRevocationCheck": "ocsp"}} {
+		if key("host=localhost", nil, authParams) == shared {
+			t.Errorf("Expected scalers with different %v not to share a poller", authParams)
+		}
+	}
+	if key("host=localhost", map[string]string{"query": "SELECT count(*) FROM other_jobs"}) == shared {
+		t.Error("Expected scalers with different queries not to share a poller")
+	}
+	if key("host=localhost", map[string]string{"valueExpression": "pending / 2"}) == shared {
+		t.Error("Expected scalers computing different values not to share a poller")
+	}
+}
+
+func TestPostgreSQLSharedPollerFanOut(t *testing.T) {
+	var firstCalls, secondCalls int32
+	first := func(context.Context) (float64, error) { return float64(atomic.AddInt32(&firstCalls, 1)), nil }
+	second := func(context.Context) (float64, error) {
+		atomic.AddInt32(&secondCalls, 1)
+		return 42, nil
+	}
+
+	poller, firstSubscription := acquirePostgreSQLSharedPoller(1, time.Hour, first)
+	samePoller, secondSubscription := acquirePostgreSQLSharedPoller(1, time.Hour, second)
+	if samePoller != poller {
+		t.Fatal("Expected subscribers of the same key to share the poller")
+	}
+
+	// all subscribers get the value of a single poll
+	for i := 0; i < 3; i++ {
+		if value, _, err := poller.get(context.Background()); err != nil || value != 1 {
+			t.Errorf("Expected shared value 1 but got %v (%v)", value, err)
+		}
+	}
+	if calls := atomic.LoadInt32(&firstCalls); calls != 1 {
+		t.Errorf("Expected a single poll but got %d", calls)
+	}
+
+	// the poller moves on to the next subscriber's query when the first one leaves
+	poller.release(firstSubscription)
+	poller.poll()
+	if value, _, err := poller.get(context.Background()); err != nil || value != 42 {
+		t.Errorf("Expected value 42 of the remaining subscriber but got %v (%v)", value, err)
+	}
+
+	// the last subscriber stops the poller
+	poller.release(secondSubscription)
+	postgreSQLSharedPollersMutex.Lock()
+	_, ok := postgreSQLSharedPollers[1]
+	postgreSQLSharedPollersMutex.Unlock()
+	if ok {
+		t.Error("Expected the poller to be removed after the last subscriber left")
+	}
+	select {
+	case <-poller.stop:
+	default:
+		t.Error("Expected the poller to be stopped after the last subscriber left")
+	}
+}
+
+func TestPostgreSQLSharedPollerErrors(t *testing.T) {
+	poller, subscription := acquirePostgreSQLSharedPoller(2, time.Hour, func(context.Context) (float64, error) {
+		return 0, errors.New("connection refused")
+	})
+	defer poller.release(subscription)
+
+	if _, _, err := poller.get(context.Background()); err == nil {
+		t.Error("Expected the error of the poll but got success")
+	}
+}
+
+func TestPostgreSQLSharedPollerWaitsForFirstPoll(t *testing.T) {
+	block := make(chan struct{})
+	poller, subscription := acquirePostgreSQLSharedPoller(3, time.Hour, func(ctx context.Context) (float64, error) {
+		select {
+		case <-block:
+		case <-ctx.Done():
+		}
+		return 1, nil
+	})
+	defer poller.release(subscription)
+	defer close(block)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, _, err := poller.get(ctx); err == nil {
+		t.Error("Expected error waiting for the first poll but got success")
+	}
+}
+
+func TestPostgreSQLScalerSharedPolling(t *testing.T) {
+	config := func(target string) *ScalerConfig {
+		return &ScalerConfig{
+			TriggerMetadata: map[string]string{"query": "SELECT count(*) FROM shared_jobs", "targetQueryValue": target, "sharedPollingInterval": "1h"},
+			AuthParams:      map[string]string{"connection": "host=localhost"},
+		}
+	}
+	first, firstMock := newPostgreSQLMockScaler(t, config("5"))
+	second, secondMock := newPostgreSQLMockScaler(t, config("10"))
+
+	// only the poller queries the database
+	firstMock.ExpectQuery("SELECT count").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(7))
+	for _, scaler := range []*postgreSQLScaler{first, second, first, second} {
+		if value, err := scaler.getActiveNumber(context.Background()); err != nil || value != 7 {
+			t.Errorf("Expected shared value 7 but got %v (%v)", value, err)
+		}
+	}
+	if first.sharedPoller != second.sharedPoller {
+		t.Error("Expected both scalers to share the poller")
+	}
+
+	firstMock.ExpectClose()
+	secondMock.ExpectClose()
+	for _, scaler := range []*postgreSQLScaler{first, second} {
+		if err := scaler.Close(context.Background()); err != nil {
+			t.Error("Unexpected error closing scaler:", err)
+		}
+	}
+	for _, mock := range []sqlmock.Sqlmock{firstMock, secondMock} {
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestPostgreSQLScalerSharedPollingWorkloadParameters(t *testing.T) {
+	// the poller queries right away, so the expectations are set before the scalers are created
+	newScaler := func(namespace string, value int) (*postgreSQLScaler, sqlmock.Sqlmock) {
+		db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+		if err != nil {
+			t.Fatal("Could not create sqlmock:", err)
+		}
+		mock.ExpectPing()
+		mock.ExpectQuery("namespaced_jobs").WithArgs(namespace).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(value))
+		scaler, err := newPostgreSQLScaler(&ScalerConfig{
+			TriggerMetadata: map[string]string{"query": "SELECT count(*) FROM namespaced_jobs WHERE namespace = $namespace", "targetQueryValue": "5",
+				"bindWorkloadParameters": "true", "sharedPollingInterval": "1h"},
+			AuthParams:              map[string]string{"connection": "host=localhost"},
+			ScalableObjectNamespace: namespace,
+		}, newPostgreSQLConnectionPool(func(*postgreSQLMetadata) (*sql.DB, error) {
+			return db, nil
+		}, 0))
+		if err != nil {
+			t.Fatal("Could not create scaler:", err)
+		}
+		t.Cleanup(func() { scaler.Close(context.Background()) })
+		return scaler, mock
+	}
+	first, firstMock := newScaler("team-a", 3)
+	second, secondMock := newScaler("team-b", 8)
+	if first.sharedPoller == second.sharedPoller {
+		t.Fatal("Expected scalers binding different namespaces not to share a poller")
+	}
+
+	// each scaler reads the value of its own namespace
+	for scaler, expected := range map[*postgreSQLScaler]float64{first: 3, second: 8} {
+		if value, err := scaler.getActiveNumber(context.Background()); err != nil || value != expected {
+			t.Errorf("Expected value %v of the scaler's namespace but got %v (%v)", expected, value, err)
+		}
+	}
+	for _, mock := range []sqlmock.Sqlmock{firstMock, secondMock} {
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestPostgreSQLScalerSharedPollingRate(t *testing.T) {
+	scaler, mock := newPostgreSQLMockScaler(t, &ScalerConfig{
+		TriggerMetadata: map[string]string{"query": "SELECT count(*) FROM rated_jobs", "targetQueryValue": "5", "metricMode": "rate", "sharedPollingInterval": "1h"},
+		AuthParams:      map[string]string{"connection": "host=localhost"},
+	})
+	defer scaler.Close(context.Background())
+	getMetrics := func() int64 {
+		t.Helper()
+		metrics, err := scaler.GetMetrics(context.Background(), "s0-postgresql")
+		if err != nil {
+			t.Fatal("Unexpected error getting metrics:", err)
+		}
+		return metrics[0].Value.MilliValue()
+	}
+
+	mock.ExpectQuery("rated_jobs").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(100))
+	if value := getMetrics(); value != 0 {
+		t.Errorf("Expected rate 0 for the first poll but got %dm", value)
+	}
+	time.Sleep(10 * time.Millisecond)
+	mock.ExpectQuery("rated_jobs").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(160))
+	scaler.sharedPoller.poll()
+
+	// the reads within a poll interval report the rate of that poll instead of 0
+	first, second := getMetrics(), getMetrics()
+	if first <= 0 || second != first {
+		t.Errorf("Expected both reads of a poll to report its rate but got %dm and %dm", first, second)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
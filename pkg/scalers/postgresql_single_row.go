@@ -0,0 +1,51 @@
+package scalers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// errPostgreSQLMultipleRows is returned by strictSingleRow queries returning more than one row
+var errPostgreSQLMultipleRows = errors.New("query returned more than one row, an aggregate or GROUP BY may be missing")
+
+// parsePostgreSQLSingleRowMetadata parses strictSingleRow, which fails queries returning more than one row
+func parsePostgreSQLSingleRowMetadata(config *ScalerConfig, meta *postgreSQLMetadata) error {
+	if val, ok := config.TriggerMetadata["strictSingleRow"]; ok {
+		strictSingleRow, err := strconv.ParseBool(val)
+		if err != nil {
+			return fmt.Errorf("strictSingleRow parsing error %s", err.Error())
+		}
+		if strictSingleRow && ((meta.metricMode != postgreSQLMetricModeAbsolute && meta.metricMode != postgreSQLMetricModeRate) || meta.estimateMode || meta.valueExpression != nil) {
+			return fmt.Errorf("strictSingleRow can only be used with metricMode %s or %s without estimateMode or valueExpression", postgreSQLMetricModeAbsolute, postgreSQLMetricModeRate)
+		}
+		meta.strictSingleRow = strictSingleRow
+	}
+	return nil
+}
+
+// queryPostgreSQLSingleRow scans the only row of the query into dest like QueryRowContext, which silently
+// takes the first of many rows instead. No rows are reported as sql.ErrNoRows
+func queryPostgreSQLSingleRow(ctx context.Context, connection postgreSQLQuerier, query string, args []interface{}, dest ...interface{}) error {
+	rows, err := connection.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		return sql.ErrNoRows
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return err
+	}
+	if rows.Next() {
+		return errPostgreSQLMultipleRows
+	}
+	return rows.Err()
+}
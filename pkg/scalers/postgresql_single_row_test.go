@@ -0,0 +1,82 @@
+package scalers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+var testPostgreSQLSingleRowMetadata = []parsePostgresMetadataTestData{
+	// strictSingleRow
+	{
+		metadata:   map[string]string{"query": "select 1", "targetQueryValue": "1", "strictSingleRow": "true"},
+		authParams: map[string]string{"connection": "test_connection_string"},
+	},
+	// strictSingleRow invalid
+	{
+		metadata:    map[string]string{"query": "select 1", "targetQueryValue": "1", "strictSingleRow": "always"},
+		authParams:  map[string]string{"connection": "test_connection_string"},
+		raisesError: true,
+	},
+	// strictSingleRow with estimateMode
+	{
+		metadata:    map[string]string{"query": "select 1", "targetQueryValue": "1", "strictSingleRow": "true", "estimateMode": "true"},
+		authParams:  map[string]string{"connection": "test_connection_string"},
+		raisesError: true,
+	},
+}
+
+func TestParsePostgreSQLSingleRowMetadata(t *testing.T) {
+	testParsePostgreSQLMetadata(t, testPostgreSQLSingleRowMetadata)
+}
+
+func TestPostgreSQLStrictSingleRow(t *testing.T) {
+	testData := []struct {
+		name        string
+		metadata    map[string]string
+		rows        []interface{}
+		expected    float64
+		raisesError bool
+	}{
+		{name: "single row", metadata: map[string]string{"strictSingleRow": "true"}, rows: []interface{}{7}, expected: 7},
+		{name: "multiple rows", metadata: map[string]string{"strictSingleRow": "true"}, rows: []interface{}{7, 3}, raisesError: true},
+		{name: "no rows", metadata: map[string]string{"strictSingleRow": "true"}, expected: 0},
+		{name: "no rows with defaultValueOnNoRows", metadata: map[string]string{"strictSingleRow": "true", "defaultValueOnNoRows": "2"}, expected: 2},
+		// without the flag the first row is used
+		{name: "multiple rows without strictSingleRow", rows: []interface{}{7, 3}, expected: 7},
+	}
+
+	for _, testData := range testData {
+		t.Run(testData.name, func(t *testing.T) {
+			metadata := map[string]string{"query": "SELECT count(*) FROM jobs", "targetQueryValue": "5"}
+			for key, value := range testData.metadata {
+				metadata[key] = value
+			}
+			scaler, mock := newPostgreSQLMockScaler(t, &ScalerConfig{
+				TriggerMetadata: metadata,
+				AuthParams:      map[string]string{"connection": "host=localhost"},
+			})
+			rows := sqlmock.NewRows([]string{"count"})
+			for _, row := range testData.rows {
+				rows.AddRow(row)
+			}
+			mock.ExpectQuery("SELECT count").WillReturnRows(rows)
+
+			value, err := scaler.getActiveNumber(context.Background())
+			if err != nil && !testData.raisesError {
+				t.Fatal("Expected success but got error", err)
+			}
+			if err == nil && testData.raisesError {
+				t.Fatal("Expected error but got success")
+			}
+			if err == nil && value != testData.expected {
+				t.Errorf("Expected %v but got %v", testData.expected, value)
+			}
+			if len(testData.rows) > 1 && err != nil && !errors.Is(err, errPostgreSQLMultipleRows) {
+				t.Errorf("Expected errPostgreSQLMultipleRows but got %s", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package scalers
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// parsePostgreSQLStartupActivationMetadata parses the startupActivationReads before a new scaler activates
+func parsePostgreSQLStartupActivationMetadata(config *ScalerConfig, meta *postgreSQLMetadata) error {
+	if val, ok := config.TriggerMetadata["startupActivationReads"]; ok && val != "" {
+		startupActivationReads, err := strconv.Atoi(val)
+		if err != nil {
+			return fmt.Errorf("startupActivationReads parsing error %s", err.Error())
+		}
+		if startupActivationReads < 1 {
+			return fmt.Errorf("startupActivationReads must be at least 1, got %d", startupActivationReads)
+		}
+		meta.startupActivationReads = startupActivationReads
+	}
+	return nil
+}
+
+// postgreSQLStartupActivation requires a number of consecutive active reads before a new scaler activates
+// the first time, so a transient reading of a scaler recreated with the ScaledObject doesn't wake a workload
+// scaled to zero. Once active it has no effect anymore
+type postgreSQLStartupActivation struct {
+	reads       int
+	activeReads int
+	activated   bool
+}
+
+func newPostgreSQLStartupActivation(reads int) *postgreSQLStartupActivation {
+	return &postgreSQLStartupActivation{reads: reads}
+}
+
+// active records a read and returns whether the trigger is active: only after reads consecutive active
+// reads until the first activation, as read afterwards
+func (a *postgreSQLStartupActivation) active(active bool) bool {
+	if a.activated {
+		return active
+	}
+	if !active {
+		a.activeReads = 0
+		return false
+	}
+	a.activeReads++
+	a.activated = a.activeReads >= a.reads
+	return a.activated
+}
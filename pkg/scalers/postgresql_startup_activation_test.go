@@ -0,0 +1,85 @@
+package scalers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+var testPostgreSQLStartupActivationMetadata = []parsePostgresMetadataTestData{
+	// startupActivationReads
+	{
+		metadata:   map[string]string{"query": "select 1", "targetQueryValue": "1", "startupActivationReads": "2"},
+		authParams: map[string]string{"connection": "test_connection_string"},
+	},
+	// startupActivationReads below 1
+	{
+		metadata:    map[string]string{"query": "select 1", "targetQueryValue": "1", "startupActivationReads": "0"},
+		authParams:  map[string]string{"connection": "test_connection_string"},
+		raisesError: true,
+	},
+	// startupActivationReads invalid
+	{
+		metadata:    map[string]string{"query": "select 1", "targetQueryValue": "1", "startupActivationReads": "two"},
+		authParams:  map[string]string{"connection": "test_connection_string"},
+		raisesError: true,
+	},
+}
+
+func TestParsePostgreSQLStartupActivationMetadata(t *testing.T) {
+	testParsePostgreSQLMetadata(t, testPostgreSQLStartupActivationMetadata)
+}
+
+func TestPostgreSQLStartupActivation(t *testing.T) {
+	startupActivation := newPostgreSQLStartupActivation(2)
+	testData := []struct {
+		name     string
+		read     bool
+		expected bool
+	}{
+		{name: "first read suppressed", read: true, expected: false},
+		{name: "inactive read resets the count", read: false, expected: false},
+		{name: "first active read again", read: true, expected: false},
+		{name: "second consecutive active read", read: true, expected: true},
+		{name: "inactive after activation", read: false, expected: false},
+		{name: "single active read after activation", read: true, expected: true},
+	}
+
+	for _, testData := range testData {
+		if active := startupActivation.active(testData.read); active != testData.expected {
+			t.Errorf("%s: expected active %v but got %v", testData.name, testData.expected, active)
+		}
+	}
+}
+
+func TestPostgreSQLStartupActivationReads(t *testing.T) {
+	scaler, mock := newPostgreSQLMockScaler(t, &ScalerConfig{
+		TriggerMetadata: map[string]string{"query": "SELECT count(*) FROM jobs", "targetQueryValue": "5", "startupActivationReads": "2"},
+		AuthParams:      map[string]string{"connection": "host=localhost"},
+	})
+	reads := []struct {
+		count  int
+		active bool
+	}{
+		// the first scrape of the new scaler doesn't activate
+		{count: 40, active: false},
+		{count: 40, active: true},
+		{count: 0, active: false},
+		{count: 3, active: true},
+	}
+
+	for i, read := range reads {
+		mock.ExpectQuery("SELECT count").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(read.count))
+		active, err := scaler.IsActive(context.Background())
+		if err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+		if active != read.active {
+			t.Errorf("read %d: expected active %v but got %v", i, read.active, active)
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
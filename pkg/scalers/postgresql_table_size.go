@@ -0,0 +1,54 @@
+package scalers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// postgreSQLTableSizeQuery returns the bytes of the table $1 including its indexes and TOAST data,
+// NULL if there is no such table
+const postgreSQLTableSizeQuery = `SELECT pg_total_relation_size(to_regclass($1))`
+
+// parsePostgreSQLTableSizeMetadata parses the table metricMode tableSize reports the size of
+func parsePostgreSQLTableSizeMetadata(config *ScalerConfig, meta *postgreSQLMetadata) error {
+	if meta.metricMode != postgreSQLMetricModeTableSize {
+		if _, ok := config.TriggerMetadata["tableName"]; ok {
+			return fmt.Errorf("tableName can only be used with metricMode %s", postgreSQLMetricModeTableSize)
+		}
+		return nil
+	}
+	if meta.dialect == postgreSQLDialectCockroach {
+		return fmt.Errorf("metricMode %s can't be used with dialect %s", meta.metricMode, meta.dialect)
+	}
+	val, ok := config.TriggerMetadata["tableName"]
+	if !ok || strings.TrimSpace(val) == "" {
+		return fmt.Errorf("no tableName given")
+	}
+	meta.query = postgreSQLTableSizeQuery
+	meta.queryArgs = []interface{}{strings.TrimSpace(val)}
+	return nil
+}
+
+// parsePostgreSQLTableSize converts the result of the postgreSQLTableSizeQuery, false for a missing table
+func parsePostgreSQLTableSize(size sql.NullInt64) (float64, bool) {
+	if !size.Valid {
+		return 0, false
+	}
+	return float64(size.Int64), true
+}
+
+// queryTableSize returns the size of the configured table. A missing table, e.g. before the migration
+// creating it ran, has no size
+func (s *postgreSQLScaler) queryTableSize(ctx context.Context, connection postgreSQLQuerier) (float64, error) {
+	var size sql.NullInt64
+	if err := connection.QueryRowContext(ctx, s.metadata.query, s.metadata.queryArgs...).Scan(&size); err != nil {
+		return 0, err
+	}
+	value, found := parsePostgreSQLTableSize(size)
+	if !found {
+		s.logger.V(1).Info("Table not found, reporting no size", "tableName", s.metadata.queryArgs[0])
+	}
+	return value, nil
+}
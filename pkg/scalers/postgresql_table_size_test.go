@@ -0,0 +1,68 @@
+package scalers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+var testPostgreSQLTableSizeMetadata = []parsePostgresMetadataTestData{
+	// metricMode tableSize
+	{
+		metadata:   map[string]string{"metricMode": "tableSize", "tableName": "public.events", "targetQueryValue": "1073741824"},
+		authParams: map[string]string{"connection": "test_connection_string"},
+	},
+	// metricMode tableSize without tableName
+	{
+		metadata:    map[string]string{"metricMode": "tableSize", "targetQueryValue": "1073741824"},
+		authParams:  map[string]string{"connection": "test_connection_string"},
+		raisesError: true,
+	},
+	// metricMode tableSize with its own query
+	{
+		metadata:    map[string]string{"metricMode": "tableSize", "tableName": "events", "query": "select 1", "targetQueryValue": "1"},
+		authParams:  map[string]string{"connection": "test_connection_string"},
+		raisesError: true,
+	},
+	// tableName without metricMode tableSize
+	{
+		metadata:    map[string]string{"query": "select 1", "tableName": "events", "targetQueryValue": "1"},
+		authParams:  map[string]string{"connection": "test_connection_string"},
+		raisesError: true,
+	},
+}
+
+func TestParsePostgreSQLTableSizeMetadata(t *testing.T) {
+	testParsePostgreSQLMetadata(t, testPostgreSQLTableSizeMetadata)
+}
+
+func TestPostgreSQLTableSizeQuery(t *testing.T) {
+	scaler, mock := newPostgreSQLMockScaler(t, &ScalerConfig{
+		TriggerMetadata: map[string]string{"metricMode": "tableSize", "tableName": "public.events", "targetQueryValue": "1073741824"},
+		AuthParams:      map[string]string{"connection": "host=localhost"},
+	})
+	columns := []string{"pg_total_relation_size"}
+	mock.ExpectQuery("pg_total_relation_size").WithArgs("public.events").WillReturnRows(sqlmock.NewRows(columns).AddRow(2147483648))
+	// the table was dropped or not created yet
+	mock.ExpectQuery("pg_total_relation_size").WithArgs("public.events").WillReturnRows(sqlmock.NewRows(columns).AddRow(nil))
+
+	for _, expected := range []int64{2147483648, 0} {
+		metrics, err := scaler.GetMetrics(context.Background(), "s0-postgresql")
+		if err != nil {
+			t.Fatal("Unexpected error getting metrics:", err)
+		}
+		if metrics[0].Value.Value() != expected {
+			t.Errorf("Expected metric value %d but got %d", expected, metrics[0].Value.Value())
+		}
+	}
+
+	mock.ExpectQuery("pg_total_relation_size").WithArgs("public.events").WillReturnError(errors.New("permission denied for table events"))
+	if _, err := scaler.GetMetrics(context.Background(), "s0-postgresql"); err == nil {
+		t.Error("Expected error for a failed query but got success")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
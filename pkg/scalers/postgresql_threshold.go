@@ -0,0 +1,32 @@
+package scalers
+
+// directions of the crossings counted by postgreSQLThresholdCrossings
+const (
+	// postgreSQLThresholdBreach is a metric rising above targetQueryValue
+	postgreSQLThresholdBreach = "breach"
+	// postgreSQLThresholdClear is a metric falling back to targetQueryValue or below
+	postgreSQLThresholdClear = "clear"
+)
+
+// postgreSQLThresholdState remembers on which side of the target the previous metric was
+type postgreSQLThresholdState struct {
+	above bool
+	known bool
+}
+
+// update returns the direction in which value crossed target since the previous metric, "" if it stayed on
+// the same side. The first metric only sets the state, the scaler is recreated with every change of the
+// ScaledObject and would count a breach again which happened before
+func (t *postgreSQLThresholdState) update(value, target float64) string {
+	above, known := value > target, t.known
+	previous := t.above
+	t.above, t.known = above, true
+	switch {
+	case !known || above == previous:
+		return ""
+	case above:
+		return postgreSQLThresholdBreach
+	default:
+		return postgreSQLThresholdClear
+	}
+}
@@ -0,0 +1,27 @@
+package scalers
+
+import "testing"
+
+type postgreSQLThresholdTestData struct {
+	name      string
+	value     float64
+	direction string
+}
+
+var testPostgreSQLThresholdCrossings = []postgreSQLThresholdTestData{
+	{name: "first metric above the target", value: 20, direction: ""},
+	{name: "staying above", value: 15, direction: ""},
+	{name: "falling to the target", value: 10, direction: postgreSQLThresholdClear},
+	{name: "staying below", value: 3, direction: ""},
+	{name: "rising above", value: 10.5, direction: postgreSQLThresholdBreach},
+	{name: "falling below", value: 0, direction: postgreSQLThresholdClear},
+}
+
+func TestPostgreSQLThresholdState(t *testing.T) {
+	state := postgreSQLThresholdState{}
+	for _, testData := range testPostgreSQLThresholdCrossings {
+		if direction := state.update(testData.value, 10); direction != testData.direction {
+			t.Errorf("%s: expected crossing %q but got %q", testData.name, testData.direction, direction)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package scalers
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/metrics/pkg/apis/external_metrics"
+)
+
+// parsePostgreSQLTimestampMetadata parses timestampFromQuery, which reads the time of the value from the query
+func parsePostgreSQLTimestampMetadata(config *ScalerConfig, meta *postgreSQLMetadata) error {
+	if val, ok := config.TriggerMetadata["timestampFromQuery"]; ok {
+		timestampFromQuery, err := strconv.ParseBool(val)
+		if err != nil {
+			return fmt.Errorf("timestampFromQuery parsing error %s", err.Error())
+		}
+		if timestampFromQuery && (meta.metricMode != postgreSQLMetricModeAbsolute || meta.estimateMode || meta.valueExpression != nil) {
+			return fmt.Errorf("timestampFromQuery can only be used with metricMode %s without estimateMode or valueExpression", postgreSQLMetricModeAbsolute)
+		}
+		meta.timestampFromQuery = timestampFromQuery
+	}
+	return nil
+}
+
+// setValueTimestamp keeps the timestamp column read with timestampFromQuery together with the value it
+// belongs to. NULL and timestamps in the future, e.g. with clock skew, fall back to the time of the read
+func (s *postgreSQLScaler) setValueTimestamp(timestamp sql.NullTime, value float64) {
+	if !s.metadata.timestampFromQuery {
+		return
+	}
+	if !timestamp.Valid || timestamp.Time.After(time.Now()) {
+		timestamp.Time = time.Time{}
+	}
+	s.mutex.Lock()
+	s.valueTimestamp, s.timestampedValue = timestamp.Time, value
+	s.mutex.Unlock()
+}
+
+// applyValueTimestamp sets the timestamp read with timestampFromQuery on metric, value is what getActiveNumber
+// returned. Values which weren't read with a timestamp, e.g. the inactive value in maintenance, keep theirs
+func (s *postgreSQLScaler) applyValueTimestamp(metric *external_metrics.ExternalMetricValue, value float64) {
+	if !s.metadata.timestampFromQuery {
+		return
+	}
+	s.mutex.Lock()
+	timestamp, timestampedValue := s.valueTimestamp, s.timestampedValue
+	s.mutex.Unlock()
+	if !timestamp.IsZero() && timestampedValue == value {
+		metric.Timestamp = metav1.NewTime(timestamp)
+	}
+}
@@ -0,0 +1,131 @@
+package scalers
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestParsePostgreSQLMetadataTimestampFromQuery(t *testing.T) {
+	testData := []struct {
+		metadata    map[string]string
+		raisesError bool
+	}{
+		{metadata: map[string]string{"timestampFromQuery": "true"}},
+		{metadata: map[string]string{"timestampFromQuery": "true", "targetFromQuery": "true"}},
+		{metadata: map[string]string{"timestampFromQuery": "true", "valueType": postgreSQLValueTypeInteger}},
+		{metadata: map[string]string{"timestampFromQuery": "yes"}, raisesError: true},
+		{metadata: map[string]string{"timestampFromQuery": "true", "metricMode": postgreSQLMetricModeRate}, raisesError: true},
+		{metadata: map[string]string{"timestampFromQuery": "true", "estimateMode": "true"}, raisesError: true},
+		{metadata: map[string]string{"timestampFromQuery": "true", "valueExpression": "a / b"}, raisesError: true},
+	}
+
+	for _, testData := range testData {
+		metadata := map[string]string{"query": "SELECT count(*), max(updated_at) FROM jobs", "targetQueryValue": "5"}
+		for key, value := range testData.metadata {
+			metadata[key] = value
+		}
+		_, err := parsePostgreSQLMetadata(&ScalerConfig{TriggerMetadata: metadata, AuthParams: map[string]string{"connection": "host=localhost"}})
+		if err != nil && !testData.raisesError {
+			t.Errorf("%v: expected success but got error %s", testData.metadata, err)
+		}
+		if err == nil && testData.raisesError {
+			t.Errorf("%v: expected error but got success", testData.metadata)
+		}
+	}
+}
+
+func TestPostgreSQLTimestampFromQuery(t *testing.T) {
+	backdated := time.Now().Add(-10 * time.Minute).Truncate(time.Second)
+	testData := []struct {
+		name      string
+		metadata  map[string]string
+		columns   []string
+		row       []interface{}
+		timestamp time.Time
+	}{
+		{name: "timestamp", columns: []string{"count", "at"}, row: []interface{}{7, backdated}, timestamp: backdated},
+		{name: "NULL timestamp", columns: []string{"count", "at"}, row: []interface{}{7, nil}},
+		{name: "future timestamp", columns: []string{"count", "at"}, row: []interface{}{7, time.Now().Add(time.Hour)}},
+		{
+			name:      "timestamp after the target column",
+			metadata:  map[string]string{"targetFromQuery": "true"},
+			columns:   []string{"count", "target", "at"},
+			row:       []interface{}{20, 10, backdated},
+			timestamp: backdated,
+		},
+		{
+			name:      "timestamp with valueType integer",
+			metadata:  map[string]string{"valueType": postgreSQLValueTypeInteger},
+			columns:   []string{"count", "at"},
+			row:       []interface{}{7, backdated},
+			timestamp: backdated,
+		},
+	}
+
+	for _, testData := range testData {
+		t.Run(testData.name, func(t *testing.T) {
+			metadata := map[string]string{"query": "SELECT count(*), max(updated_at) FROM jobs", "targetQueryValue": "5", "timestampFromQuery": "true"}
+			for key, value := range testData.metadata {
+				metadata[key] = value
+			}
+			scaler, mock := newPostgreSQLMockScaler(t, &ScalerConfig{TriggerMetadata: metadata, AuthParams: map[string]string{"connection": "host=localhost"}})
+			row := make([]driver.Value, len(testData.row))
+			for i, value := range testData.row {
+				row[i] = value
+			}
+			mock.ExpectQuery("SELECT count").WillReturnRows(sqlmock.NewRows(testData.columns).AddRow(row...))
+
+			before := time.Now().Add(-time.Second)
+			metrics, err := scaler.GetMetrics(context.Background(), "s0-postgresql")
+			if err != nil {
+				t.Fatal("Unexpected error:", err)
+			}
+			timestamp := metrics[0].Timestamp.Time
+			if !testData.timestamp.IsZero() && !timestamp.Equal(testData.timestamp) {
+				t.Errorf("Expected timestamp %s but got %s", testData.timestamp, timestamp)
+			}
+			if testData.timestamp.IsZero() && timestamp.Before(before) {
+				t.Errorf("Expected the timestamp to fall back to now but got %s", timestamp)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestPostgreSQLTimestampFromQueryNotAppliedToOtherValues(t *testing.T) {
+	backdated := time.Now().Add(-10 * time.Minute).Truncate(time.Second)
+	scaler, mock := newPostgreSQLMockScaler(t, &ScalerConfig{
+		TriggerMetadata: map[string]string{"query": "SELECT count(*), max(updated_at) FROM jobs", "targetQueryValue": "5", "timestampFromQuery": "true",
+			"maintenanceQuery": "SELECT paused FROM settings"},
+		AuthParams: map[string]string{"connection": "host=localhost"},
+	})
+	mock.ExpectQuery("SELECT paused").WillReturnRows(sqlmock.NewRows([]string{"paused"}).AddRow(false))
+	mock.ExpectQuery("SELECT count").WillReturnRows(sqlmock.NewRows([]string{"count", "at"}).AddRow(0, backdated))
+	mock.ExpectQuery("SELECT paused").WillReturnRows(sqlmock.NewRows([]string{"paused"}).AddRow(true))
+
+	metrics, err := scaler.GetMetrics(context.Background(), "s0-postgresql")
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if !metrics[0].Timestamp.Time.Equal(backdated) {
+		t.Errorf("Expected timestamp %s but got %s", backdated, metrics[0].Timestamp.Time)
+	}
+
+	before := time.Now().Add(-time.Second)
+	metrics, err = scaler.GetMetrics(context.Background(), "s0-postgresql")
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if metrics[0].Timestamp.Time.Before(before) {
+		t.Errorf("Expected the inactive value in maintenance to be timestamped now but got %s", metrics[0].Timestamp.Time)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,267 @@
+package scalers
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/youmark/pkcs8"
+)
+
+// postgreSQLSSLRequestCode asks the server to upgrade the connection to TLS,
+// see https://www.postgresql.org/docs/current/protocol-flow.html#id-1.10.6.7.12
+const postgreSQLSSLRequestCode = 80877103
+
+// parsePostgreSQLTLSMetadata parses the settings of verifying the server certificate and of decrypting the sslkey
+func parsePostgreSQLTLSMetadata(config *ScalerConfig, meta *postgreSQLMetadata) error {
+	params, paramsErr := parsePostgreSQLConnectionString(meta.connection)
+
+	// sslServerName allows verify-full when connecting through an IP address or an alias which isn't
+	// part of the certificate. It only makes sense with full verification, so sslmode must be verify-full
+	if val, err := GetFromAuthOrMeta(config, "sslServerName"); err == nil {
+		if paramsErr != nil {
+			return fmt.Errorf("error parsing connection for sslServerName: %s", paramsErr)
+		}
+		if params["sslmode"] != "verify-full" {
+			return fmt.Errorf("sslServerName requires sslmode verify-full, got %q", params["sslmode"])
+		}
+		meta.sslServerName = val
+	}
+
+	// sslkeyPassword is a secret, so it's only read from the authentication parameters
+	if val := config.AuthParams["sslkeyPassword"]; val != "" {
+		if paramsErr != nil {
+			return fmt.Errorf("error parsing connection for sslkeyPassword: %s", paramsErr)
+		}
+		if params["sslkey"] == "" {
+			return fmt.Errorf("sslkeyPassword requires sslkey")
+		}
+		if params["sslmode"] == "disable" {
+			return fmt.Errorf("sslkeyPassword can't be used with sslmode disable")
+		}
+		meta.sslKeyPassword = val
+	}
+
+	// requireCertVerification rejects connections which don't verify the server certificate
+	if val, ok := config.TriggerMetadata["requireCertVerification"]; ok {
+		requireCertVerification, err := strconv.ParseBool(val)
+		if err != nil {
+			return fmt.Errorf("requireCertVerification parsing error %s", err.Error())
+		}
+		if requireCertVerification {
+			if paramsErr != nil {
+				return fmt.Errorf("error parsing connection for requireCertVerification: %s", paramsErr)
+			}
+			// require only verifies against an sslrootcert for backwards compatibility, which isn't relied on
+			if params["sslmode"] != "verify-ca" && params["sslmode"] != "verify-full" {
+				return fmt.Errorf("requireCertVerification requires sslmode verify-ca or verify-full, got %q", params["sslmode"])
+			}
+		}
+	}
+	return nil
+}
+
+// postgreSQLTLSDialer negotiates TLS itself using its own tls.Config, which lib/pq doesn't allow to
+// customize. The driver is configured with sslmode=disable and speaks the protocol over the TLS connection
+type postgreSQLTLSDialer struct {
+	config *tls.Config
+	dialer net.Dialer
+}
+
+// Dial implements pq.Dialer
+func (d *postgreSQLTLSDialer) Dial(network, address string) (net.Conn, error) {
+	return d.DialContext(context.Background(), network, address)
+}
+
+// DialTimeout implements pq.Dialer
+func (d *postgreSQLTLSDialer) DialTimeout(network, address string, timeout time.Duration) (net.Conn, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return d.DialContext(ctx, network, address)
+}
+
+// DialContext implements pq.DialerContext
+func (d *postgreSQLTLSDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	conn, err := d.dialer.DialContext(ctx, network, address)
+	if err != nil {
+		return nil, err
+	}
+	tlsConn, err := upgradePostgreSQLConnectionToTLS(ctx, conn, d.config)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return tlsConn, nil
+}
+
+// upgradePostgreSQLConnectionToTLS sends the SSLRequest and performs the TLS handshake on conn
+func upgradePostgreSQLConnectionToTLS(ctx context.Context, conn net.Conn, config *tls.Config) (*tls.Conn, error) {
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return nil, err
+		}
+		defer conn.SetDeadline(time.Time{})
+	}
+
+	request := make([]byte, 8)
+	binary.BigEndian.PutUint32(request[0:4], 8)
+	binary.BigEndian.PutUint32(request[4:8], postgreSQLSSLRequestCode)
+	if _, err := conn.Write(request); err != nil {
+		return nil, fmt.Errorf("error sending SSL request: %s", err)
+	}
+
+	response := make([]byte, 1)
+	if _, err := io.ReadFull(conn, response); err != nil {
+		return nil, fmt.Errorf("error reading SSL response: %s", err)
+	}
+	if response[0] != 'S' {
+		return nil, fmt.Errorf("server does not support TLS")
+	}
+
+	tlsConn := tls.Client(conn, config)
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		return nil, fmt.Errorf("error during TLS handshake: %s", err)
+	}
+	return tlsConn, nil
+}
+
+// newPostgreSQLTLSConfig builds the TLS configuration verifying the server certificate against serverName,
+// using the sslrootcert, sslcert and sslkey files of the connection string. Files are read on every call,
+// so reconnections pick up rotated certificates. keyPassword decrypts an encrypted sslkey
+func newPostgreSQLTLSConfig(params map[string]string, serverName, keyPassword string) (*tls.Config, error) {
+	config := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: serverName,
+	}
+
+	if params["sslrootcert"] != "" {
+		caCert, err := os.ReadFile(params["sslrootcert"])
+		if err != nil {
+			return nil, fmt.Errorf("error reading sslrootcert: %s", err)
+		}
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("no certificates found in sslrootcert %s", params["sslrootcert"])
+		}
+	}
+
+	if params["sslcert"] != "" || params["sslkey"] != "" {
+		certPEM, err := os.ReadFile(params["sslcert"])
+		if err != nil {
+			return nil, fmt.Errorf("error reading sslcert: %s", err)
+		}
+		keyPEM, err := readPostgreSQLClientKey(params["sslkey"], keyPassword)
+		if err != nil {
+			return nil, err
+		}
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return nil, fmt.Errorf("error loading sslcert and sslkey: %s", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	return config, nil
+}
+
+// readPostgreSQLClientKey reads the sslkey file, decrypting it with password if it's encrypted. Both encrypted
+// PKCS #8 keys and legacy OpenSSL encrypted PEM blocks are supported, unencrypted keys are returned as they are
+func readPostgreSQLClientKey(path, password string) ([]byte, error) {
+	keyPEM, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading sslkey: %s", err)
+	}
+	if password == "" {
+		return keyPEM, nil
+	}
+
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data found in sslkey %s", path)
+	}
+	switch {
+	case block.Type == "ENCRYPTED PRIVATE KEY":
+		key, err := pkcs8.ParsePKCS8PrivateKey(block.Bytes, []byte(password))
+		if err != nil {
+			return nil, fmt.Errorf("error decrypting sslkey %s: %s", path, err)
+		}
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("error decrypting sslkey %s: %s", path, err)
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+	case x509.IsEncryptedPEMBlock(block): //nolint:staticcheck // keys encrypted by older OpenSSL versions still use it
+		der, err := x509.DecryptPEMBlock(block, []byte(password)) //nolint:staticcheck // see above
+		if err != nil {
+			return nil, fmt.Errorf("error decrypting sslkey %s: %s", path, err)
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: block.Type, Bytes: der}), nil
+	default:
+		return keyPEM, nil
+	}
+}
+
+// inlinePostgreSQLTLSFiles replaces the TLS file parameters with their content using sslinline, as lib/pq
+// can't decrypt sslkey itself. sslmode require verifies against sslrootcert when the file exists, which
+// inline certificates would silently lose, so it becomes verify-ca
+func inlinePostgreSQLTLSFiles(params map[string]string, keyPassword string) error {
+	if params["sslrootcert"] != "" {
+		caCert, err := os.ReadFile(params["sslrootcert"])
+		if err != nil {
+			return fmt.Errorf("error reading sslrootcert: %s", err)
+		}
+		params["sslrootcert"] = string(caCert)
+		if params["sslmode"] == "" || params["sslmode"] == "require" {
+			params["sslmode"] = "verify-ca"
+		}
+	}
+	if params["sslcert"] != "" {
+		certPEM, err := os.ReadFile(params["sslcert"])
+		if err != nil {
+			return fmt.Errorf("error reading sslcert: %s", err)
+		}
+		params["sslcert"] = string(certPEM)
+	}
+	if params["sslkey"] != "" {
+		keyPEM, err := readPostgreSQLClientKey(params["sslkey"], keyPassword)
+		if err != nil {
+			return err
+		}
+		params["sslkey"] = string(keyPEM)
+	}
+	params["sslinline"] = "true"
+	return nil
+}
+
+// skipsPostgreSQLCertificateVerification tells whether the connection is encrypted without verifying the
+// server certificate. lib/pq defaults to sslmode require, which only verifies against an existing sslrootcert
+func skipsPostgreSQLCertificateVerification(params map[string]string) bool {
+	if params["sslmode"] != "" && params["sslmode"] != "require" {
+		return false
+	}
+	if params["sslrootcert"] == "" {
+		return true
+	}
+	_, err := os.Stat(params["sslrootcert"])
+	return err != nil
+}
+
+// warnPostgreSQLCertificateVerification logs that the server of the connection isn't verified, so a
+// man in the middle could pose as it. requireCertVerification turns this into an error
+func warnPostgreSQLCertificateVerification(logger logr.Logger, connection string) {
+	params, err := parsePostgreSQLConnectionString(connection)
+	if err != nil || !skipsPostgreSQLCertificateVerification(params) {
+		return
+	}
+	logger.Info("postgreSQL connection is encrypted but doesn't verify the server certificate, consider sslmode verify-full",
+		"server", getPostgreSQLServerAddress(connection))
+}
@@ -0,0 +1,477 @@
+package scalers
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/binary"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/funcr"
+	"github.com/lib/pq"
+	"github.com/youmark/pkcs8"
+)
+
+var testPostgreSQLTLSMetadata = []parsePostgresMetadataTestData{
+	// sslServerName with verify-full
+	{
+		metadata:    map[string]string{"query": "query", "targetQueryValue": "12", "host": "10.0.0.5", "port": "5432", "userName": "user", "dbName": "db", "sslmode": "verify-full", "sslServerName": "db.example.com"},
+		authParams:  map[string]string{},
+		resolvedEnv: testPostgresResolvedEnv,
+		raisesError: false,
+	},
+	// sslServerName without verify-full
+	{
+		metadata:    map[string]string{"query": "query", "targetQueryValue": "12", "host": "10.0.0.5", "port": "5432", "userName": "user", "dbName": "db", "sslmode": "require", "sslServerName": "db.example.com"},
+		authParams:  map[string]string{},
+		resolvedEnv: testPostgresResolvedEnv,
+		raisesError: true,
+	},
+	// sslkeyPassword
+	{
+		metadata:    map[string]string{"query": "query", "targetQueryValue": "12"},
+		authParams:  map[string]string{"connection": "host=localhost sslmode=verify-full sslcert=/certs/client.crt sslkey=/certs/client.key", "sslkeyPassword": "secret"},
+		resolvedEnv: map[string]string{},
+		raisesError: false,
+	},
+	// sslkeyPassword without sslkey
+	{
+		metadata:    map[string]string{"query": "query", "targetQueryValue": "12"},
+		authParams:  map[string]string{"connection": "host=localhost sslmode=verify-full", "sslkeyPassword": "secret"},
+		resolvedEnv: map[string]string{},
+		raisesError: true,
+	},
+	// sslkeyPassword with sslmode disable
+	{
+		metadata:    map[string]string{"query": "query", "targetQueryValue": "12"},
+		authParams:  map[string]string{"connection": "host=localhost sslmode=disable sslcert=/certs/client.crt sslkey=/certs/client.key", "sslkeyPassword": "secret"},
+		resolvedEnv: map[string]string{},
+		raisesError: true,
+	},
+	// requireCertVerification with sslmode verify-full
+	{
+		metadata:   map[string]string{"query": "select 1", "targetQueryValue": "1", "requireCertVerification": "true"},
+		authParams: map[string]string{"connection": "host=localhost sslmode=verify-full"},
+	},
+	// requireCertVerification with sslmode require
+	{
+		metadata:    map[string]string{"query": "select 1", "targetQueryValue": "1", "requireCertVerification": "true"},
+		authParams:  map[string]string{"connection": "host=localhost sslmode=require"},
+		raisesError: true,
+	},
+	// requireCertVerification without sslmode, which defaults to require
+	{
+		metadata:    map[string]string{"query": "select 1", "targetQueryValue": "1", "requireCertVerification": "true"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		raisesError: true,
+	},
+	// requireCertVerification invalid
+	{
+		metadata:    map[string]string{"query": "select 1", "targetQueryValue": "1", "requireCertVerification": "yes please"},
+		authParams:  map[string]string{"connection": "host=localhost sslmode=verify-full"},
+		raisesError: true,
+	},
+}
+
+func TestParsePostgreSQLTLSMetadata(t *testing.T) {
+	testParsePostgreSQLMetadata(t, testPostgreSQLTLSMetadata)
+}
+
+// newPostgreSQLTestCertificates creates a CA and a server certificate for dnsName, returning
+// the CA certificate path and the server certificate
+func newPostgreSQLTestCertificates(t *testing.T, dnsName string) (string, tls.Certificate) {
+	t.Helper()
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caCert, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	serverKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serverTemplate := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: dnsName},
+		DNSNames:     []string{dnsName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	serverDER, err := x509.CreateCertificate(rand.Reader, serverTemplate, caCert, &serverKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	caPath := filepath.Join(t.TempDir(), "root.crt")
+	if err := os.WriteFile(caPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return caPath, tls.Certificate{Certificate: [][]byte{serverDER}, PrivateKey: serverKey}
+}
+
+// startPostgreSQLTestTLSServer accepts connections answering the SSLRequest and completing the TLS handshake,
+// requiring a client certificate signed by clientCAs if they are set
+func startPostgreSQLTestTLSServer(t *testing.T, cert tls.Certificate, clientCAs *x509.CertPool) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				request := make([]byte, 8)
+				if _, err := io.ReadFull(conn, request); err != nil || binary.BigEndian.Uint32(request[4:8]) != postgreSQLSSLRequestCode {
+					return
+				}
+				if _, err := conn.Write([]byte{'S'}); err != nil {
+					return
+				}
+				config := &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+				if clientCAs != nil {
+					config.ClientAuth = tls.RequireAndVerifyClientCert
+					config.ClientCAs = clientCAs
+				}
+				tlsConn := tls.Server(conn, config)
+				_ = tlsConn.Handshake()
+			}(conn)
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func TestPostgreSQLTLSDialerServerName(t *testing.T) {
+	caPath, cert := newPostgreSQLTestCertificates(t, "db.example.com")
+	address := startPostgreSQLTestTLSServer(t, cert, nil)
+
+	testData := []struct {
+		serverName  string
+		raisesError bool
+	}{
+		{serverName: "db.example.com", raisesError: false},
+		{serverName: "other.example.com", raisesError: true},
+	}
+
+	for _, testData := range testData {
+		config, err := newPostgreSQLTLSConfig(map[string]string{"sslrootcert": caPath}, testData.serverName, "")
+		if err != nil {
+			t.Fatal("Could not create TLS config:", err)
+		}
+		dialer := &postgreSQLTLSDialer{config: config}
+		conn, err := dialer.DialTimeout("tcp", address, 5*time.Second)
+		if err != nil && !testData.raisesError {
+			t.Errorf("Expected success dialing with server name %s but got error %s", testData.serverName, err)
+		}
+		if err == nil {
+			conn.Close()
+			if testData.raisesError {
+				t.Errorf("Expected error dialing with server name %s but got success", testData.serverName)
+			}
+		}
+	}
+}
+
+func TestPostgreSQLTLSDialerServerWithoutTLS(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		request := make([]byte, 8)
+		if _, err := io.ReadFull(conn, request); err == nil {
+			_, _ = conn.Write([]byte{'N'})
+		}
+	}()
+
+	dialer := &postgreSQLTLSDialer{config: &tls.Config{MinVersion: tls.VersionTLS12, ServerName: "db.example.com"}}
+	if _, err := dialer.DialContext(context.Background(), "tcp", listener.Addr().String()); err == nil {
+		t.Error("Expected error dialing a server without TLS support but got success")
+	}
+}
+
+func TestPostgreSQLDriverConnectionDialer(t *testing.T) {
+	caPath, _ := newPostgreSQLTestCertificates(t, "db.example.com")
+
+	connection, dialer, err := getPostgreSQLDriverConnection(&postgreSQLMetadata{connection: "host=10.0.0.5 sslmode=verify-full"})
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if dialer != nil || connection != "host=10.0.0.5 sslmode=verify-full" {
+		t.Errorf("Expected the driver's own dialer and the unchanged connection but got %T and %s", dialer, connection)
+	}
+
+	// the notification listener and the database handle connect through the same TLS dialer
+	connection, dialer, err = getPostgreSQLDriverConnection(&postgreSQLMetadata{
+		connection:    "host=10.0.0.5 sslmode=verify-full sslrootcert=" + caPath,
+		sslServerName: "db.example.com",
+	})
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	tlsDialer, ok := dialer.(*postgreSQLTLSDialer)
+	if !ok {
+		t.Fatalf("Expected the TLS dialer but got %T", dialer)
+	}
+	if tlsDialer.config.ServerName != "db.example.com" {
+		t.Errorf("Expected server name db.example.com but got %s", tlsDialer.config.ServerName)
+	}
+	if !strings.Contains(connection, "sslmode='disable'") || strings.Contains(connection, "sslrootcert") {
+		t.Errorf("Expected the driver to leave TLS to the dialer but got connection %s", connection)
+	}
+}
+
+func TestPostgreSQLTLSConfigMissingRootCert(t *testing.T) {
+	if _, err := newPostgreSQLTLSConfig(map[string]string{"sslrootcert": filepath.Join(t.TempDir(), "missing.crt")}, "db.example.com", ""); err == nil {
+		t.Error("Expected error for a missing sslrootcert but got success")
+	}
+}
+
+type postgreSQLTestClientKeys struct {
+	certPath        string
+	keyPath         string
+	pkcs8KeyPath    string
+	legacyKeyPath   string
+	pool            *x509.CertPool
+	encryptPassword string
+}
+
+// newPostgreSQLTestClientKeys creates a self signed client certificate with its key stored unencrypted,
+// as encrypted PKCS #8 and as a legacy encrypted PEM block
+func newPostgreSQLTestClientKeys(t *testing.T) postgreSQLTestClientKeys {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: "keda"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		IsCA:         true,
+	}
+	template.BasicConstraintsValid = true
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(certDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := postgreSQLTestClientKeys{pool: x509.NewCertPool(), encryptPassword: "s3cr3t"}
+	keys.pool.AddCert(cert)
+
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkcs8DER, err := pkcs8.MarshalPrivateKey(key, []byte(keys.encryptPassword), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacyBlock, err := x509.EncryptPEMBlock(rand.Reader, "EC PRIVATE KEY", ecDER, []byte(keys.encryptPassword), x509.PEMCipherAES256) //nolint:staticcheck // legacy keys are still supported
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	write := func(name string, block *pem.Block) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, pem.EncodeToMemory(block), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	keys.certPath = write("client.crt", &pem.Block{Type: "CERTIFICATE", Bytes: certDER})
+	keys.keyPath = write("client.key", &pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+	keys.pkcs8KeyPath = write("client-pkcs8.key", &pem.Block{Type: "ENCRYPTED PRIVATE KEY", Bytes: pkcs8DER})
+	keys.legacyKeyPath = write("client-legacy.key", legacyBlock)
+	return keys
+}
+
+func TestPostgreSQLReadClientKey(t *testing.T) {
+	keys := newPostgreSQLTestClientKeys(t)
+	notPEMPath := filepath.Join(t.TempDir(), "client.key")
+	if err := os.WriteFile(notPEMPath, []byte("not a key"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	testData := []struct {
+		name        string
+		path        string
+		password    string
+		raisesError bool
+	}{
+		{name: "PKCS #8", path: keys.pkcs8KeyPath, password: keys.encryptPassword},
+		{name: "legacy PEM", path: keys.legacyKeyPath, password: keys.encryptPassword},
+		{name: "unencrypted", path: keys.keyPath, password: keys.encryptPassword},
+		{name: "unencrypted without password", path: keys.keyPath},
+		{name: "PKCS #8 with wrong password", path: keys.pkcs8KeyPath, password: "wrong", raisesError: true},
+		{name: "legacy PEM with wrong password", path: keys.legacyKeyPath, password: "wrong", raisesError: true},
+		{name: "not PEM", path: notPEMPath, password: keys.encryptPassword, raisesError: true},
+		{name: "missing", path: filepath.Join(t.TempDir(), "missing.key"), password: keys.encryptPassword, raisesError: true},
+	}
+
+	for _, testData := range testData {
+		keyPEM, err := readPostgreSQLClientKey(testData.path, testData.password)
+		if err != nil {
+			if !testData.raisesError {
+				t.Errorf("%s: expected success but got error %s", testData.name, err)
+			}
+			continue
+		}
+		if testData.raisesError {
+			t.Errorf("%s: expected error but got success", testData.name)
+			continue
+		}
+		certPEM, err := os.ReadFile(keys.certPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tls.X509KeyPair(certPEM, keyPEM); err != nil {
+			t.Errorf("%s: decrypted key doesn't match the certificate: %s", testData.name, err)
+		}
+	}
+}
+
+func TestPostgreSQLTLSDialerEncryptedClientKey(t *testing.T) {
+	caPath, cert := newPostgreSQLTestCertificates(t, "db.example.com")
+	keys := newPostgreSQLTestClientKeys(t)
+	address := startPostgreSQLTestTLSServer(t, cert, keys.pool)
+
+	params := map[string]string{"sslrootcert": caPath, "sslcert": keys.certPath, "sslkey": keys.pkcs8KeyPath}
+	if _, err := newPostgreSQLTLSConfig(params, "db.example.com", "wrong"); err == nil {
+		t.Error("Expected error for a wrong sslkeyPassword but got success")
+	}
+	config, err := newPostgreSQLTLSConfig(params, "db.example.com", keys.encryptPassword)
+	if err != nil {
+		t.Fatal("Could not create TLS config:", err)
+	}
+	dialer := &postgreSQLTLSDialer{config: config}
+	conn, err := dialer.DialTimeout("tcp", address, 5*time.Second)
+	if err != nil {
+		t.Fatal("Expected success dialing with the decrypted client key but got error", err)
+	}
+	// the server verifies the client certificate after the client finished its handshake
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err := conn.Read(make([]byte, 1)); err != nil && err != io.EOF {
+		t.Errorf("Expected the server to accept the client certificate but got %s", err)
+	}
+	conn.Close()
+}
+
+func TestPostgreSQLInlineTLSFiles(t *testing.T) {
+	caPath, _ := newPostgreSQLTestCertificates(t, "db.example.com")
+	keys := newPostgreSQLTestClientKeys(t)
+
+	params := map[string]string{
+		"host":        "localhost",
+		"sslmode":     "require",
+		"sslrootcert": caPath,
+		"sslcert":     keys.certPath,
+		"sslkey":      keys.legacyKeyPath,
+	}
+	if err := inlinePostgreSQLTLSFiles(params, keys.encryptPassword); err != nil {
+		t.Fatal("Expected success inlining TLS files but got error", err)
+	}
+	if params["sslinline"] != "true" || params["sslmode"] != "verify-ca" {
+		t.Errorf("Expected sslinline true and sslmode verify-ca but got %q and %q", params["sslinline"], params["sslmode"])
+	}
+	if _, err := tls.X509KeyPair([]byte(params["sslcert"]), []byte(params["sslkey"])); err != nil {
+		t.Errorf("Expected inline certificate and key but got error %s", err)
+	}
+	if _, err := pq.NewConnector(formatPostgreSQLConnectionString(params)); err != nil {
+		t.Errorf("Expected the driver to accept the inline connection but got error %s", err)
+	}
+
+	if err := inlinePostgreSQLTLSFiles(map[string]string{"sslcert": keys.certPath, "sslkey": keys.pkcs8KeyPath}, "wrong"); err == nil {
+		t.Error("Expected error for a wrong sslkeyPassword but got success")
+	}
+}
+
+func TestPostgreSQLCertificateVerificationWarning(t *testing.T) {
+	caFile := filepath.Join(t.TempDir(), "ca.crt")
+	if err := os.WriteFile(caFile, []byte("ca"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	testData := []struct {
+		connection string
+		warns      bool
+	}{
+		{connection: "host=db.internal sslmode=require password=secret", warns: true},
+		// require is the default of lib/pq
+		{connection: "host=db.internal password=secret", warns: true},
+		{connection: "host=db.internal sslmode=require sslrootcert=" + filepath.Join(t.TempDir(), "missing.crt"), warns: true},
+		// an existing sslrootcert is verified for backwards compatibility
+		{connection: "host=db.internal sslmode=require sslrootcert=" + caFile},
+		{connection: "host=db.internal sslmode=verify-ca"},
+		{connection: "host=db.internal sslmode=verify-full"},
+		// unencrypted connections aren't what the warning is about
+		{connection: "host=db.internal sslmode=disable"},
+	}
+
+	for _, testData := range testData {
+		var infoLogs []string
+		logger := funcr.New(func(prefix, args string) {
+			infoLogs = append(infoLogs, args)
+		}, funcr.Options{})
+		warnPostgreSQLCertificateVerification(logger, testData.connection)
+		if testData.warns && (len(infoLogs) != 1 || !strings.Contains(infoLogs[0], "db.internal") || strings.Contains(infoLogs[0], "secret")) {
+			t.Errorf("%s: expected a warning without the password but got %v", testData.connection, infoLogs)
+		}
+		if !testData.warns && len(infoLogs) != 0 {
+			t.Errorf("%s: expected no warning but got %v", testData.connection, infoLogs)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package scalers
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// seconds per interval unit, matching EXTRACT(EPOCH FROM interval) in PostgreSQL
+const (
+	postgreSQLSecondsPerDay   = 24 * 60 * 60
+	postgreSQLSecondsPerMonth = 30 * postgreSQLSecondsPerDay
+	postgreSQLSecondsPerYear  = 365.25 * postgreSQLSecondsPerDay
+)
+
+var postgreSQLISOIntervalPattern = regexp.MustCompile(`^P(?:([-+]?[0-9.]+)Y)?(?:([-+]?[0-9.]+)M)?(?:([-+]?[0-9.]+)W)?(?:([-+]?[0-9.]+)D)?(?:T(?:([-+]?[0-9.]+)H)?(?:([-+]?[0-9.]+)M)?(?:([-+]?[0-9.]+)S)?)?$`)
+
+var postgreSQLISOIntervalUnits = []float64{postgreSQLSecondsPerYear, postgreSQLSecondsPerMonth, 7 * postgreSQLSecondsPerDay, postgreSQLSecondsPerDay, 60 * 60, 60, 1}
+
+// parsePostgreSQLValueMetadata parses how the value is read from the query result, the type it's scanned as
+// and whether the second column is subtracted from it
+func parsePostgreSQLValueMetadata(config *ScalerConfig, meta *postgreSQLMetadata) error {
+	if val, ok := config.TriggerMetadata["subtractSecondColumn"]; ok {
+		subtractSecondColumn, err := strconv.ParseBool(val)
+		if err != nil {
+			return fmt.Errorf("subtractSecondColumn parsing error %s", err.Error())
+		}
+		if subtractSecondColumn {
+			if (meta.metricMode != postgreSQLMetricModeAbsolute && meta.metricMode != postgreSQLMetricModeRate) || meta.estimateMode {
+				return fmt.Errorf("subtractSecondColumn can only be used with metricMode %s or %s without estimateMode", postgreSQLMetricModeAbsolute, postgreSQLMetricModeRate)
+			}
+			if meta.valueExpression != nil || meta.targetFromQuery {
+				return fmt.Errorf("subtractSecondColumn can't be combined with valueExpression or targetFromQuery")
+			}
+		}
+		meta.subtractSecondColumn = subtractSecondColumn
+	}
+
+	meta.valueType = postgreSQLValueTypeFloat
+	if val, ok := config.TriggerMetadata["valueType"]; ok && val != "" {
+		switch val {
+		case postgreSQLValueTypeFloat:
+		case postgreSQLValueTypeInteger:
+			if (meta.metricMode != postgreSQLMetricModeAbsolute && meta.metricMode != postgreSQLMetricModeWindowCount) || meta.estimateMode {
+				return fmt.Errorf("valueType %s can only be used with metricMode %s or %s without estimateMode", val, postgreSQLMetricModeAbsolute, postgreSQLMetricModeWindowCount)
+			}
+			if meta.valueExpression != nil || meta.capacityQuery != "" || meta.targetFromQuery || meta.subtractSecondColumn {
+				return fmt.Errorf("valueType %s can't be combined with valueExpression, capacityQuery, targetFromQuery or subtractSecondColumn", val)
+			}
+		default:
+			return fmt.Errorf("unknown valueType %s, must be one of %s, %s", val, postgreSQLValueTypeFloat, postgreSQLValueTypeInteger)
+		}
+		meta.valueType = val
+	}
+	return nil
+}
+
+// parsePostgreSQLResultValue converts the query result into the metric value. Numbers are used as they are,
+// intervals such as the result of percentile_cont over wait times are converted to seconds and NULL,
+// which aggregates return over no rows, is reported as nullValue
+func parsePostgreSQLResultValue(value sql.NullString, nullValue float64) (float64, error) {
+	if !value.Valid {
+		return nullValue, nil
+	}
+	raw := strings.TrimSpace(value.String)
+	if number, err := strconv.ParseFloat(raw, 64); err == nil {
+		return number, nil
+	}
+	seconds, err := parsePostgreSQLInterval(raw)
+	if err != nil {
+		return 0, fmt.Errorf("query result %q is neither a number nor an interval", raw)
+	}
+	return seconds, nil
+}
+
+// computePostgreSQLPendingValue subtracts the rows already in flight from the pending ones. More rows in
+// flight than pending, e.g. as both are read at slightly different times, report no backlog
+func computePostgreSQLPendingValue(pending, inFlight float64) float64 {
+	return math.Max(pending-inFlight, 0)
+}
+
+// parsePostgreSQLIntegerResultValue converts the query result into an exact integer, NULL is reported as nullValue
+func parsePostgreSQLIntegerResultValue(value sql.NullString, nullValue int64) (int64, error) {
+	if !value.Valid {
+		return nullValue, nil
+	}
+	raw := strings.TrimSpace(value.String)
+	number, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("query result %q is not an integer", raw)
+	}
+	return number, nil
+}
+
+// parsePostgreSQLInterval returns the seconds of an interval in the postgres or iso_8601 IntervalStyle,
+// e.g. "1 day 02:03:04.5", "-00:00:05" or "P1DT2H3M4.5S"
+func parsePostgreSQLInterval(interval string) (float64, error) {
+	if strings.HasPrefix(interval, "P") {
+		return parsePostgreSQLISOInterval(interval)
+	}
+
+	fields := strings.Fields(interval)
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("empty interval")
+	}
+
+	var seconds float64
+	for i := 0; i < len(fields); i++ {
+		if strings.Contains(fields[i], ":") {
+			clock, err := parsePostgreSQLIntervalClock(fields[i])
+			if err != nil {
+				return 0, err
+			}
+			seconds += clock
+			continue
+		}
+
+		if i+1 >= len(fields) {
+			return 0, fmt.Errorf("missing unit for %q in interval", fields[i])
+		}
+		amount, err := strconv.ParseFloat(fields[i], 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid amount %q in interval", fields[i])
+		}
+		i++
+		switch strings.TrimSuffix(fields[i], "s") {
+		case "year":
+			seconds += amount * postgreSQLSecondsPerYear
+		case "mon":
+			seconds += amount * postgreSQLSecondsPerMonth
+		case "day":
+			seconds += amount * postgreSQLSecondsPerDay
+		default:
+			return 0, fmt.Errorf("unknown unit %q in interval", fields[i])
+		}
+	}
+	return seconds, nil
+}
+
+// parsePostgreSQLIntervalClock returns the seconds of the [-+]HH:MM:SS[.ffffff] part of an interval
+func parsePostgreSQLIntervalClock(clock string) (float64, error) {
+	sign := 1.0
+	switch {
+	case strings.HasPrefix(clock, "-"):
+		sign = -1
+		clock = clock[1:]
+	case strings.HasPrefix(clock, "+"):
+		clock = clock[1:]
+	}
+
+	parts := strings.Split(clock, ":")
+	if len(parts) != 3 {
+		return 0, fmt.Errorf("invalid time %q in interval", clock)
+	}
+	var seconds float64
+	for i, unit := range []float64{60 * 60, 60, 1} {
+		amount, err := strconv.ParseFloat(parts[i], 64)
+		if err != nil || amount < 0 {
+			return 0, fmt.Errorf("invalid time %q in interval", clock)
+		}
+		seconds += amount * unit
+	}
+	return sign * seconds, nil
+}
+
+func parsePostgreSQLISOInterval(interval string) (float64, error) {
+	matches := postgreSQLISOIntervalPattern.FindStringSubmatch(interval)
+	if matches == nil || interval == "P" || strings.HasSuffix(interval, "T") {
+		return 0, fmt.Errorf("invalid interval %q", interval)
+	}
+
+	var seconds float64
+	for i, unit := range postgreSQLISOIntervalUnits {
+		if matches[i+1] == "" {
+			continue
+		}
+		amount, err := strconv.ParseFloat(matches[i+1], 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid amount %q in interval", matches[i+1])
+		}
+		seconds += amount * unit
+	}
+	return seconds, nil
+}
@@ -0,0 +1,264 @@
+package scalers
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+type parsePostgreSQLResultValueTestData struct {
+	value       sql.NullString
+	expected    float64
+	raisesError bool
+}
+
+var testPostgreSQLResultValues = []parsePostgreSQLResultValueTestData{
+	// numbers
+	{value: sql.NullString{String: "42", Valid: true}, expected: 42},
+	{value: sql.NullString{String: "12.5", Valid: true}, expected: 12.5},
+	// NULL, e.g. percentile_cont over no rows
+	{value: sql.NullString{}, expected: 0},
+	// intervals in the postgres IntervalStyle
+	{value: sql.NullString{String: "00:01:30", Valid: true}, expected: 90},
+	{value: sql.NullString{String: "00:00:00.25", Valid: true}, expected: 0.25},
+	{value: sql.NullString{String: "1 day 02:00:00", Valid: true}, expected: 93600},
+	{value: sql.NullString{String: "3 days", Valid: true}, expected: 259200},
+	{value: sql.NullString{String: "1 mon 1 day", Valid: true}, expected: 2678400},
+	{value: sql.NullString{String: "-00:00:05", Valid: true}, expected: -5},
+	{value: sql.NullString{String: "1 day -01:00:00", Valid: true}, expected: 82800},
+	// intervals in the iso_8601 IntervalStyle
+	{value: sql.NullString{String: "PT1M30S", Valid: true}, expected: 90},
+	{value: sql.NullString{String: "P1DT2H", Valid: true}, expected: 93600},
+	{value: sql.NullString{String: "PT-5S", Valid: true}, expected: -5},
+	// invalid
+	{value: sql.NullString{String: "", Valid: true}, raisesError: true},
+	{value: sql.NullString{String: "pending", Valid: true}, raisesError: true},
+	{value: sql.NullString{String: "3 weeks", Valid: true}, raisesError: true},
+	{value: sql.NullString{String: "1:30", Valid: true}, raisesError: true},
+	{value: sql.NullString{String: "P", Valid: true}, raisesError: true},
+	{value: sql.NullString{String: "P1DT", Valid: true}, raisesError: true},
+}
+
+var testPostgreSQLValueMetadata = []parsePostgresMetadataTestData{
+	// valueType integer
+	{
+		metadata:    map[string]string{"query": "query", "targetQueryValue": "5", "valueType": "integer"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		resolvedEnv: map[string]string{},
+		raisesError: false,
+	},
+	// unknown valueType
+	{
+		metadata:    map[string]string{"query": "query", "targetQueryValue": "5", "valueType": "decimal"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		resolvedEnv: map[string]string{},
+		raisesError: true,
+	},
+	// valueType integer with metricMode rate
+	{
+		metadata:    map[string]string{"query": "query", "targetQueryValue": "5", "metricMode": "rate", "valueType": "integer"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		resolvedEnv: map[string]string{},
+		raisesError: true,
+	},
+	// valueType integer with estimateMode
+	{
+		metadata:    map[string]string{"query": "query", "targetQueryValue": "5", "estimateMode": "true", "valueType": "integer"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		resolvedEnv: map[string]string{},
+		raisesError: true,
+	},
+	// subtractSecondColumn
+	{
+		metadata:    map[string]string{"query": "query", "targetQueryValue": "5", "subtractSecondColumn": "true"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		resolvedEnv: map[string]string{},
+		raisesError: false,
+	},
+	// subtractSecondColumn with targetFromQuery
+	{
+		metadata:    map[string]string{"query": "query", "targetQueryValue": "5", "subtractSecondColumn": "true", "targetFromQuery": "true"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		resolvedEnv: map[string]string{},
+		raisesError: true,
+	},
+	// subtractSecondColumn with metricMode age
+	{
+		metadata:    map[string]string{"query": "query", "targetQueryValue": "5", "metricMode": "age", "subtractSecondColumn": "true"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		resolvedEnv: map[string]string{},
+		raisesError: true,
+	},
+	// metricPrecision with valueType integer
+	{
+		metadata:    map[string]string{"query": "select 1", "targetQueryValue": "1", "metricPrecision": "1", "valueType": "integer"},
+		authParams:  map[string]string{"connection": "test_connection_string"},
+		raisesError: true,
+	},
+	// resultFormat with valueType integer
+	{
+		metadata:    map[string]string{"query": "select 1", "targetQueryValue": "1", "resultFormat": "hex", "valueType": "integer"},
+		authParams:  map[string]string{"connection": "test_connection_string"},
+		raisesError: true,
+	},
+}
+
+func TestParsePostgreSQLValueMetadata(t *testing.T) {
+	testParsePostgreSQLMetadata(t, testPostgreSQLValueMetadata)
+}
+
+func TestParsePostgreSQLResultValue(t *testing.T) {
+	for _, testData := range testPostgreSQLResultValues {
+		value, err := parsePostgreSQLResultValue(testData.value, 0)
+		if err != nil && !testData.raisesError {
+			t.Errorf("Expected success parsing %+v but got error %s", testData.value, err)
+		}
+		if err == nil && testData.raisesError {
+			t.Errorf("Expected error parsing %+v but got success", testData.value)
+		}
+		if err == nil && value != testData.expected {
+			t.Errorf("Expected %v parsing %+v but got %v", testData.expected, testData.value, value)
+		}
+	}
+}
+
+func TestPostgreSQLPercentileQuery(t *testing.T) {
+	const query = "SELECT percentile_cont(0.95) WITHIN GROUP (ORDER BY now() - created_at) FROM jobs"
+
+	testData := []struct {
+		name     string
+		metadata map[string]string
+		value    interface{}
+		expected float64
+	}{
+		{name: "interval", value: "00:02:00.5", expected: 120.5},
+		{name: "NULL", value: nil, expected: 0},
+		{name: "NULL with defaultValueOnNoRows", metadata: map[string]string{"defaultValueOnNoRows": "7"}, value: nil, expected: 7},
+		{name: "epoch seconds", value: 45.5, expected: 45.5},
+	}
+
+	for _, testData := range testData {
+		metadata := map[string]string{"query": query, "targetQueryValue": "60"}
+		for key, value := range testData.metadata {
+			metadata[key] = value
+		}
+		scaler, mock := newPostgreSQLMockScaler(t, &ScalerConfig{
+			TriggerMetadata: metadata,
+			AuthParams:      map[string]string{"connection": "host=localhost"},
+		})
+		mock.ExpectQuery("SELECT percentile_cont").WillReturnRows(sqlmock.NewRows([]string{"percentile_cont"}).AddRow(testData.value))
+
+		value, err := scaler.getActiveNumber(context.Background())
+		if err != nil {
+			t.Errorf("%s: unexpected error %s", testData.name, err)
+		} else if value != testData.expected {
+			t.Errorf("%s: expected %v but got %v", testData.name, testData.expected, value)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("%s: %s", testData.name, err)
+		}
+	}
+}
+
+func TestPostgreSQLIntegerResultValue(t *testing.T) {
+	testData := []struct {
+		value       sql.NullString
+		expected    int64
+		raisesError bool
+	}{
+		{value: sql.NullString{String: "42", Valid: true}, expected: 42},
+		// 2^53 + 1, which float64 rounds to 2^53
+		{value: sql.NullString{String: "9007199254740993", Valid: true}, expected: 9007199254740993},
+		{value: sql.NullString{String: " 9223372036854775807 ", Valid: true}, expected: 9223372036854775807},
+		{value: sql.NullString{}, expected: 7},
+		{value: sql.NullString{String: "12.5", Valid: true}, raisesError: true},
+		{value: sql.NullString{String: "9223372036854775808", Valid: true}, raisesError: true},
+	}
+
+	for _, testData := range testData {
+		value, err := parsePostgreSQLIntegerResultValue(testData.value, 7)
+		if testData.raisesError {
+			if err == nil {
+				t.Errorf("Expected error for %q but got success", testData.value.String)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Unexpected error for %q: %s", testData.value.String, err)
+		}
+		if value != testData.expected {
+			t.Errorf("Expected %d for %q but got %d", testData.expected, testData.value.String, value)
+		}
+	}
+}
+
+func TestPostgreSQLIntegerValueType(t *testing.T) {
+	testData := []struct {
+		valueType string
+		expected  int64
+	}{
+		{valueType: "integer", expected: 9007199254740993},
+		{valueType: "float", expected: 9007199254740992},
+	}
+
+	for _, testData := range testData {
+		scaler, mock := newPostgreSQLMockScaler(t, &ScalerConfig{
+			TriggerMetadata: map[string]string{"query": "SELECT last_value FROM events_id_seq", "targetQueryValue": "1000", "valueType": testData.valueType},
+			AuthParams:      map[string]string{"connection": "host=localhost"},
+		})
+		mock.ExpectQuery("SELECT last_value").WillReturnRows(sqlmock.NewRows([]string{"last_value"}).AddRow("9007199254740993"))
+
+		metrics, err := scaler.GetMetrics(context.Background(), "s0-postgresql")
+		if err != nil {
+			t.Fatal("Unexpected error getting metrics:", err)
+		}
+		if value := metrics[0].Value.Value(); value != testData.expected {
+			t.Errorf("valueType %s: expected metric value %d but got %d", testData.valueType, testData.expected, value)
+		}
+	}
+}
+
+func TestPostgreSQLPendingValue(t *testing.T) {
+	testData := []struct {
+		pending  float64
+		inFlight float64
+		expected float64
+	}{
+		{pending: 10, inFlight: 0, expected: 10},
+		{pending: 10, inFlight: 4, expected: 6},
+		{pending: 10, inFlight: 10, expected: 0},
+		// more in flight than pending is clamped
+		{pending: 3, inFlight: 5, expected: 0},
+		{pending: 0, inFlight: 1, expected: 0},
+	}
+
+	for _, testData := range testData {
+		if value := computePostgreSQLPendingValue(testData.pending, testData.inFlight); value != testData.expected {
+			t.Errorf("Expected %v for %v - %v but got %v", testData.expected, testData.pending, testData.inFlight, value)
+		}
+	}
+
+	scaler, mock := newPostgreSQLMockScaler(t, &ScalerConfig{
+		TriggerMetadata: map[string]string{"query": "SELECT pending, processing FROM queue_stats", "targetQueryValue": "5", "subtractSecondColumn": "true"},
+		AuthParams:      map[string]string{"connection": "host=localhost"},
+	})
+	columns := []string{"pending", "processing"}
+	mock.ExpectQuery("FROM queue_stats").WillReturnRows(sqlmock.NewRows(columns).AddRow(12, 5))
+	mock.ExpectQuery("FROM queue_stats").WillReturnRows(sqlmock.NewRows(columns).AddRow(2, 5))
+	mock.ExpectQuery("FROM queue_stats").WillReturnRows(sqlmock.NewRows(columns).AddRow(12, nil))
+
+	for _, expected := range []float64{7, 0, 12} {
+		value, err := scaler.getActiveNumber(context.Background())
+		if err != nil {
+			t.Fatal("Unexpected error querying:", err)
+		}
+		if value != expected {
+			t.Errorf("Expected value %v but got %v", expected, value)
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,40 @@
+package scalers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// postgreSQLWALPositionQuery returns the current WAL position, or the last received one on a standby
+const postgreSQLWALPositionQuery = `SELECT CASE WHEN pg_is_in_recovery() THEN pg_last_wal_receive_lsn() ELSE pg_current_wal_lsn() END::text`
+
+// parsePostgreSQLWALRateMetadata selects the query of metricMode walRate, which has no settings of its own
+func parsePostgreSQLWALRateMetadata(_ *ScalerConfig, meta *postgreSQLMetadata) error {
+	if meta.metricMode != postgreSQLMetricModeWALRate {
+		return nil
+	}
+	if meta.dialect == postgreSQLDialectCockroach {
+		return fmt.Errorf("metricMode %s can't be used with dialect %s", meta.metricMode, meta.dialect)
+	}
+	meta.query = postgreSQLWALPositionQuery
+	return nil
+}
+
+// queryWALPosition returns the WAL position in bytes, which metricMode walRate turns into bytes per second
+// like the counter of metricMode rate. A position going back, e.g. after restoring a backup, is a reset
+func (s *postgreSQLScaler) queryWALPosition(ctx context.Context, connection postgreSQLQuerier) (float64, error) {
+	var lsn sql.NullString
+	if err := connection.QueryRowContext(ctx, s.metadata.query).Scan(&lsn); err != nil {
+		return 0, err
+	}
+	if !lsn.Valid {
+		// a standby which didn't receive any WAL since it started
+		return 0, fmt.Errorf("no WAL position, the standby didn't receive WAL yet")
+	}
+	position, err := parsePostgreSQLLSN(lsn.String)
+	if err != nil {
+		return 0, err
+	}
+	return float64(position), nil
+}
@@ -0,0 +1,67 @@
+package scalers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+var testPostgreSQLWALRateMetadata = []parsePostgresMetadataTestData{
+	// walRate
+	{
+		metadata:   map[string]string{"metricMode": "walRate", "targetQueryValue": "1048576"},
+		authParams: map[string]string{"connection": "test_connection_string"},
+	},
+	// walRate with a query
+	{
+		metadata:    map[string]string{"metricMode": "walRate", "query": "SELECT 1", "targetQueryValue": "1048576"},
+		authParams:  map[string]string{"connection": "test_connection_string"},
+		raisesError: true,
+	},
+	// walRate with dialect cockroach
+	{
+		metadata:    map[string]string{"metricMode": "walRate", "dialect": "cockroach", "targetQueryValue": "1048576"},
+		authParams:  map[string]string{"connection": "test_connection_string"},
+		raisesError: true,
+	},
+}
+
+func TestParsePostgreSQLWALRateMetadata(t *testing.T) {
+	testParsePostgreSQLMetadata(t, testPostgreSQLWALRateMetadata)
+}
+
+func TestPostgreSQLWALRate(t *testing.T) {
+	scaler, mock := newPostgreSQLMockScaler(t, &ScalerConfig{
+		TriggerMetadata: map[string]string{"metricMode": "walRate", "targetQueryValue": "1048576"},
+		AuthParams:      map[string]string{"connection": "host=localhost"},
+	})
+	columns := []string{"lsn"}
+	// 0x100000 bytes apart, then back to an earlier position after restoring a backup
+	for _, lsn := range []string{"0/3000000", "0/3100000", "0/1000000"} {
+		mock.ExpectQuery("pg_current_wal_lsn").WillReturnRows(sqlmock.NewRows(columns).AddRow(lsn))
+	}
+
+	// the first sample has nothing to compare with, the reset reports 0 too
+	for _, expected := range []float64{0, 0x100000 / 10.0, 0} {
+		value, err := scaler.getActiveNumber(context.Background())
+		if err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+		// the samples are about 10 seconds apart
+		if value > expected || value < expected*0.99 {
+			t.Errorf("Expected WAL rate of about %v but got %v", expected, value)
+		}
+		scaler.rateTracker.previousTime = scaler.rateTracker.previousTime.Add(-10 * time.Second)
+	}
+
+	// a standby which didn't receive WAL yet
+	mock.ExpectQuery("pg_current_wal_lsn").WillReturnRows(sqlmock.NewRows(columns).AddRow(nil))
+	if _, err := scaler.getActiveNumber(context.Background()); err == nil {
+		t.Error("Expected error for a NULL WAL position but got success")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
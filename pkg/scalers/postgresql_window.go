@@ -0,0 +1,69 @@
+package scalers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// postgreSQLWindowCountMetadataKeys are the settings of metricMode windowCount besides the table
+var postgreSQLWindowCountMetadataKeys = []string{"timestampColumn", "windowSeconds"}
+
+// parsePostgreSQLWindowCountMetadata builds the query of metricMode windowCount from the table, its
+// timestampColumn and the windowSeconds
+func parsePostgreSQLWindowCountMetadata(config *ScalerConfig, meta *postgreSQLMetadata) error {
+	if meta.metricMode != postgreSQLMetricModeWindowCount {
+		for _, key := range postgreSQLWindowCountMetadataKeys {
+			if _, ok := config.TriggerMetadata[key]; ok {
+				return fmt.Errorf("%s can only be used with metricMode %s", key, postgreSQLMetricModeWindowCount)
+			}
+		}
+		return nil
+	}
+	table, column := config.TriggerMetadata["table"], config.TriggerMetadata["timestampColumn"]
+	if table == "" || column == "" {
+		return fmt.Errorf("metricMode %s requires table and timestampColumn", meta.metricMode)
+	}
+	windowSeconds, err := strconv.Atoi(config.TriggerMetadata["windowSeconds"])
+	if err != nil {
+		return fmt.Errorf("windowSeconds parsing error %s", err.Error())
+	}
+	if windowSeconds <= 0 {
+		return fmt.Errorf("windowSeconds must be positive, got %d", windowSeconds)
+	}
+	meta.query, err = buildPostgreSQLWindowCountQuery(table, column)
+	if err != nil {
+		return err
+	}
+	meta.queryArgs = []interface{}{windowSeconds}
+	return nil
+}
+
+// buildPostgreSQLWindowCountQuery returns the query counting the rows of table whose column lies within
+// the last $1 seconds. The identifiers are quoted, a schema qualified table is quoted per part, and the
+// window is bound as parameter
+func buildPostgreSQLWindowCountQuery(table, column string) (string, error) {
+	quotedTable, err := quotePostgreSQLTable(table)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("SELECT count(*) FROM %s WHERE %s >= now() - $1::int * interval '1 second'",
+		quotedTable, pq.QuoteIdentifier(column)), nil
+}
+
+// quotePostgreSQLTable quotes a table name, optionally qualified by its schema
+func quotePostgreSQLTable(table string) (string, error) {
+	parts := strings.Split(table, ".")
+	if len(parts) > 2 {
+		return "", fmt.Errorf("table %q must be a table name, optionally qualified by its schema", table)
+	}
+	for i, part := range parts {
+		if part == "" {
+			return "", fmt.Errorf("table %q contains an empty name", table)
+		}
+		parts[i] = pq.QuoteIdentifier(part)
+	}
+	return strings.Join(parts, "."), nil
+}
@@ -0,0 +1,109 @@
+package scalers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+var testPostgreSQLWindowCountMetadata = []parsePostgresMetadataTestData{
+	// metricMode windowCount
+	{
+		metadata:    map[string]string{"metricMode": "windowCount", "table": "jobs", "timestampColumn": "created_at", "windowSeconds": "300", "targetQueryValue": "10"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		resolvedEnv: map[string]string{},
+		raisesError: false,
+	},
+	// metricMode windowCount without timestampColumn
+	{
+		metadata:    map[string]string{"metricMode": "windowCount", "table": "jobs", "windowSeconds": "300", "targetQueryValue": "10"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		resolvedEnv: map[string]string{},
+		raisesError: true,
+	},
+	// metricMode windowCount with invalid windowSeconds
+	{
+		metadata:    map[string]string{"metricMode": "windowCount", "table": "jobs", "timestampColumn": "created_at", "windowSeconds": "5m", "targetQueryValue": "10"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		resolvedEnv: map[string]string{},
+		raisesError: true,
+	},
+	// metricMode windowCount with windowSeconds not positive
+	{
+		metadata:    map[string]string{"metricMode": "windowCount", "table": "jobs", "timestampColumn": "created_at", "windowSeconds": "0", "targetQueryValue": "10"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		resolvedEnv: map[string]string{},
+		raisesError: true,
+	},
+	// metricMode windowCount with query
+	{
+		metadata:    map[string]string{"metricMode": "windowCount", "query": "query", "table": "jobs", "timestampColumn": "created_at", "windowSeconds": "300", "targetQueryValue": "10"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		resolvedEnv: map[string]string{},
+		raisesError: true,
+	},
+	// windowSeconds without metricMode windowCount
+	{
+		metadata:    map[string]string{"query": "query", "windowSeconds": "300", "targetQueryValue": "10"},
+		authParams:  map[string]string{"connection": "host=localhost"},
+		resolvedEnv: map[string]string{},
+		raisesError: true,
+	},
+}
+
+func TestParsePostgreSQLWindowCountMetadata(t *testing.T) {
+	testParsePostgreSQLMetadata(t, testPostgreSQLWindowCountMetadata)
+}
+
+func TestPostgreSQLWindowCountQuery(t *testing.T) {
+	testData := []struct {
+		table       string
+		column      string
+		expected    string
+		raisesError bool
+	}{
+		{table: "jobs", column: "created_at", expected: `SELECT count(*) FROM "jobs" WHERE "created_at" >= now() - $1::int * interval '1 second'`},
+		{table: "queue.Jobs", column: "createdAt", expected: `SELECT count(*) FROM "queue"."Jobs" WHERE "createdAt" >= now() - $1::int * interval '1 second'`},
+		// identifiers can't break out of their quotes
+		{table: `jobs"; DROP TABLE jobs; --`, column: "created_at", expected: `SELECT count(*) FROM "jobs""; DROP TABLE jobs; --" WHERE "created_at" >= now() - $1::int * interval '1 second'`},
+		{table: "db.queue.jobs", column: "created_at", raisesError: true},
+		{table: "queue.", column: "created_at", raisesError: true},
+	}
+
+	for _, testData := range testData {
+		query, err := buildPostgreSQLWindowCountQuery(testData.table, testData.column)
+		if testData.raisesError {
+			if err == nil {
+				t.Errorf("Expected error for table %q but got success", testData.table)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Unexpected error for table %q: %s", testData.table, err)
+		}
+		if query != testData.expected {
+			t.Errorf("Expected query %s but got %s", testData.expected, query)
+		}
+	}
+}
+
+func TestPostgreSQLWindowCount(t *testing.T) {
+	scaler, mock := newPostgreSQLMockScaler(t, &ScalerConfig{
+		TriggerMetadata: map[string]string{"metricMode": "windowCount", "table": "jobs", "timestampColumn": "created_at", "windowSeconds": "300", "targetQueryValue": "10"},
+		AuthParams:      map[string]string{"connection": "host=localhost"},
+	})
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "jobs" WHERE "created_at" >= now\(\) - \$1::int \* interval '1 second'`).
+		WithArgs(300).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(42))
+
+	metrics, err := scaler.GetMetrics(context.Background(), "s0-postgresql")
+	if err != nil {
+		t.Fatal("Unexpected error getting metrics:", err)
+	}
+	if metrics[0].Value.Value() != 42 {
+		t.Errorf("Expected metric value 42 but got %d", metrics[0].Value.Value())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -55,6 +55,28 @@ type Scaler interface {
 	Close(ctx context.Context) error
 }
 
+// ConditionReasonError is implemented by scaler errors which know their cause, e.g. a failed authentication,
+// so it can be reported as the reason of the ScaledObject Ready condition
+type ConditionReasonError interface {
+	error
+	ConditionReason() string
+}
+
+// TriggerError is the error of a failed trigger with the name of the trigger, or the type of its scaler
+// if the trigger has no name
+type TriggerError struct {
+	TriggerName string
+	Err         error
+}
+
+func (e *TriggerError) Error() string {
+	return fmt.Sprintf("trigger %s: %s", e.TriggerName, e.Err)
+}
+
+func (e *TriggerError) Unwrap() error {
+	return e.Err
+}
+
 // PushScaler interface
 type PushScaler interface {
 	Scaler
@@ -20,6 +20,7 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"strings"
 
 	"github.com/go-logr/logr"
 	v2 "k8s.io/api/autoscaling/v2"
@@ -85,9 +86,13 @@ func (c *ScalersCache) GetMetricsForScaler(ctx context.Context, id int, metricNa
 	return ns.GetMetrics(ctx, metricName)
 }
 
-func (c *ScalersCache) IsScaledObjectActive(ctx context.Context, scaledObject *kedav1alpha1.ScaledObject) (bool, bool, []external_metrics.ExternalMetricValue) {
+// IsScaledObjectActive returns whether any scaler is active, whether any scaler failed and the first error of the
+// failed scalers as scalers.TriggerError. An error doesn't stop checking the other scalers, so the ScaledObject may
+// still be active
+func (c *ScalersCache) IsScaledObjectActive(ctx context.Context, scaledObject *kedav1alpha1.ScaledObject) (bool, bool, []external_metrics.ExternalMetricValue, error) {
 	isActive := false
 	isError := false
+	var triggerErr error
 	// Let's collect status of all scalers, no matter if any scaler raises error or is active
 	for i, s := range c.Scalers {
 		isTriggerActive, err := s.Scaler.IsActive(ctx)
@@ -104,6 +109,13 @@ func (c *ScalersCache) IsScaledObjectActive(ctx context.Context, scaledObject *k
 
 		if err != nil {
 			isError = true
+			if triggerErr == nil {
+				triggerName := strings.Replace(fmt.Sprintf("%T", s.Scaler), "*scalers.", "", 1)
+				if s.ScalerConfig.TriggerName != "" {
+					triggerName = s.ScalerConfig.TriggerName
+				}
+				triggerErr = &scalers.TriggerError{TriggerName: triggerName, Err: err}
+			}
 			logger.Error(err, "Error getting scale decision")
 			c.Recorder.Event(scaledObject, corev1.EventTypeWarning, eventreason.KEDAScalerFailed, err.Error())
 		} else if isTriggerActive {
@@ -117,7 +129,7 @@ func (c *ScalersCache) IsScaledObjectActive(ctx context.Context, scaledObject *k
 		}
 	}
 
-	return isActive, isError, []external_metrics.ExternalMetricValue{}
+	return isActive, isError, []external_metrics.ExternalMetricValue{}, triggerErr
 }
 
 func (c *ScalersCache) IsScaledJobActive(ctx context.Context, scaledJob *kedav1alpha1.ScaledJob) (bool, int64, int64) {
@@ -18,6 +18,8 @@ package executor
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"strconv"
 	"time"
 
@@ -31,9 +33,10 @@ import (
 	kedav1alpha1 "github.com/kedacore/keda/v2/apis/keda/v1alpha1"
 	kedacontrollerutil "github.com/kedacore/keda/v2/controllers/keda/util"
 	"github.com/kedacore/keda/v2/pkg/eventreason"
+	"github.com/kedacore/keda/v2/pkg/scalers"
 )
 
-func (e *scaleExecutor) RequestScale(ctx context.Context, scaledObject *kedav1alpha1.ScaledObject, isActive bool, isError bool) {
+func (e *scaleExecutor) RequestScale(ctx context.Context, scaledObject *kedav1alpha1.ScaledObject, isActive bool, isError bool, triggerErr error) {
 	logger := e.logger.WithValues("scaledobject.Name", scaledObject.Name,
 		"scaledObject.Namespace", scaledObject.Namespace,
 		"scaleTarget.Name", scaledObject.Spec.ScaleTargetRef.Name)
@@ -140,10 +143,11 @@ func (e *scaleExecutor) RequestScale(ctx context.Context, scaledObject *kedav1al
 			// some triggers are active, but some responded with error
 
 			// Set ScaledObject.Status.ReadyCondition to Unknown
-			msg := "Some triggers defined in ScaledObject are not working correctly"
+			reason, msg := getTriggerErrorCondition(triggerErr, "PartialTriggerError", "Some triggers defined in ScaledObject are not working correctly")
 			logger.V(1).Info(msg)
-			if !readyCondition.IsUnknown() {
-				if err := e.setReadyCondition(ctx, logger, scaledObject, metav1.ConditionUnknown, "PartialTriggerError", msg); err != nil {
+			if !readyCondition.IsUnknown() || readyCondition.Reason != reason {
+				logger.Error(triggerErr, msg, "reason", reason)
+				if err := e.setReadyCondition(ctx, logger, scaledObject, metav1.ConditionUnknown, reason, msg); err != nil {
 					logger.Error(err, "error setting ready condition")
 				}
 			}
@@ -173,10 +177,11 @@ func (e *scaleExecutor) RequestScale(ctx context.Context, scaledObject *kedav1al
 			// there is not a fallback replicas count defined
 
 			// Set ScaledObject.Status.ReadyCondition to false
-			msg := "Triggers defined in ScaledObject are not working correctly"
+			reason, msg := getTriggerErrorCondition(triggerErr, "TriggerError", "Triggers defined in ScaledObject are not working correctly")
 			logger.V(1).Info(msg)
-			if !readyCondition.IsFalse() {
-				if err := e.setReadyCondition(ctx, logger, scaledObject, metav1.ConditionFalse, "TriggerError", msg); err != nil {
+			if !readyCondition.IsFalse() || readyCondition.Reason != reason {
+				logger.Error(triggerErr, msg, "reason", reason)
+				if err := e.setReadyCondition(ctx, logger, scaledObject, metav1.ConditionFalse, reason, msg); err != nil {
 					logger.Error(err, "error setting ready condition")
 				}
 			}
@@ -344,6 +349,22 @@ func (e *scaleExecutor) updateScaleOnScaleTarget(ctx context.Context, scaledObje
 	return currentReplicas, err
 }
 
+// getTriggerErrorCondition returns the reason and message of the Ready condition for failing triggers. Scaler errors
+// which know their cause replace the default reason. The message only names the failed trigger, the error itself
+// may contain details of the scaler's configuration which don't belong into the status and is logged instead
+func getTriggerErrorCondition(triggerErr error, defaultReason string, msg string) (string, string) {
+	reason := defaultReason
+	var reasonErr scalers.ConditionReasonError
+	if errors.As(triggerErr, &reasonErr) {
+		reason = reasonErr.ConditionReason()
+	}
+	var trigger *scalers.TriggerError
+	if errors.As(triggerErr, &trigger) {
+		return reason, fmt.Sprintf("%s: trigger %s failed with %s", msg, trigger.TriggerName, reason)
+	}
+	return reason, msg
+}
+
 // getIdleOrMinimumReplicaCount returns true if the second value returned is from IdleReplicaCount
 // it returns false if it is from MinReplicaCount followed by the actual value
 func getIdleOrMinimumReplicaCount(scaledObject *kedav1alpha1.ScaledObject) (bool, int32) {
@@ -18,6 +18,8 @@ package executor
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/golang/mock/gomock"
@@ -30,6 +32,7 @@ import (
 	"github.com/kedacore/keda/v2/apis/keda/v1alpha1"
 	"github.com/kedacore/keda/v2/pkg/mock/mock_client"
 	"github.com/kedacore/keda/v2/pkg/mock/mock_scale"
+	"github.com/kedacore/keda/v2/pkg/scalers"
 )
 
 func TestScaleToFallbackReplicasWhenNotActiveAndIsError(t *testing.T) {
@@ -87,7 +90,7 @@ func TestScaleToFallbackReplicasWhenNotActiveAndIsError(t *testing.T) {
 	client.EXPECT().Status().Times(2).Return(statusWriter)
 	statusWriter.EXPECT().Patch(gomock.Any(), gomock.Any(), gomock.Any()).Times(2)
 
-	scaleExecutor.RequestScale(context.TODO(), &scaledObject, false, true)
+	scaleExecutor.RequestScale(context.TODO(), &scaledObject, false, true, errors.New("trigger failed"))
 
 	assert.Equal(t, int32(5), scale.Spec.Replicas)
 	condition := scaledObject.Status.Conditions.GetFallbackCondition()
@@ -148,7 +151,7 @@ func TestScaleToMinReplicasWhenNotActive(t *testing.T) {
 	client.EXPECT().Status().Return(statusWriter).Times(2)
 	statusWriter.EXPECT().Patch(gomock.Any(), gomock.Any(), gomock.Any()).Times(2)
 
-	scaleExecutor.RequestScale(context.TODO(), &scaledObject, false, false)
+	scaleExecutor.RequestScale(context.TODO(), &scaledObject, false, false, nil)
 
 	assert.Equal(t, minReplicas, scale.Spec.Replicas)
 	condition := scaledObject.Status.Conditions.GetActiveCondition()
@@ -209,7 +212,7 @@ func TestScaleToMinReplicasFromLowerInitialReplicaCount(t *testing.T) {
 	client.EXPECT().Status().Return(statusWriter).Times(2)
 	statusWriter.EXPECT().Patch(gomock.Any(), gomock.Any(), gomock.Any()).Times(2)
 
-	scaleExecutor.RequestScale(context.TODO(), &scaledObject, false, false)
+	scaleExecutor.RequestScale(context.TODO(), &scaledObject, false, false, nil)
 
 	assert.Equal(t, minReplicas, scale.Spec.Replicas)
 	condition := scaledObject.Status.Conditions.GetActiveCondition()
@@ -268,7 +271,7 @@ func TestScaleFromMinReplicasWhenActive(t *testing.T) {
 	client.EXPECT().Status().Times(2).Return(statusWriter).Times(3)
 	statusWriter.EXPECT().Patch(gomock.Any(), gomock.Any(), gomock.Any()).Times(3)
 
-	scaleExecutor.RequestScale(context.TODO(), &scaledObject, true, false)
+	scaleExecutor.RequestScale(context.TODO(), &scaledObject, true, false, nil)
 
 	assert.Equal(t, int32(1), scale.Spec.Replicas)
 	condition := scaledObject.Status.Conditions.GetActiveCondition()
@@ -331,7 +334,7 @@ func TestScaleToIdleReplicasWhenNotActive(t *testing.T) {
 	client.EXPECT().Status().Return(statusWriter).Times(2)
 	statusWriter.EXPECT().Patch(gomock.Any(), gomock.Any(), gomock.Any()).Times(2)
 
-	scaleExecutor.RequestScale(context.TODO(), &scaledObject, false, false)
+	scaleExecutor.RequestScale(context.TODO(), &scaledObject, false, false, nil)
 
 	assert.Equal(t, idleReplicas, scale.Spec.Replicas)
 	condition := scaledObject.Status.Conditions.GetActiveCondition()
@@ -392,7 +395,7 @@ func TestScaleFromIdleToMinReplicasWhenActive(t *testing.T) {
 	client.EXPECT().Status().Times(2).Return(statusWriter).Times(3)
 	statusWriter.EXPECT().Patch(gomock.Any(), gomock.Any(), gomock.Any()).Times(3)
 
-	scaleExecutor.RequestScale(context.TODO(), &scaledObject, true, false)
+	scaleExecutor.RequestScale(context.TODO(), &scaledObject, true, false, nil)
 
 	assert.Equal(t, minReplicas, scale.Spec.Replicas)
 	condition := scaledObject.Status.Conditions.GetActiveCondition()
@@ -454,9 +457,35 @@ func TestScaleToPausedReplicasCount(t *testing.T) {
 	client.EXPECT().Status().Return(statusWriter).Times(2)
 	statusWriter.EXPECT().Patch(gomock.Any(), gomock.Any(), gomock.Any()).Times(2)
 
-	scaleExecutor.RequestScale(context.TODO(), &scaledObject, true, false)
+	scaleExecutor.RequestScale(context.TODO(), &scaledObject, true, false, nil)
 
 	assert.Equal(t, pausedReplicaCount, scale.Spec.Replicas)
 	condition := scaledObject.Status.Conditions.GetActiveCondition()
 	assert.Equal(t, false, condition.IsTrue())
 }
+
+type conditionReasonTestError struct{}
+
+func (conditionReasonTestError) Error() string {
+	return "password authentication failed"
+}
+
+func (conditionReasonTestError) ConditionReason() string {
+	return "AuthenticationFailed"
+}
+
+func TestGetTriggerErrorCondition(t *testing.T) {
+	reason, msg := getTriggerErrorCondition(errors.New("trigger failed"), "TriggerError", "Triggers defined in ScaledObject are not working correctly")
+	assert.Equal(t, "TriggerError", reason)
+	assert.Equal(t, "Triggers defined in ScaledObject are not working correctly", msg)
+
+	reason, msg = getTriggerErrorCondition(&scalers.TriggerError{TriggerName: "orders", Err: errors.New("connection refused")}, "TriggerError", "Triggers defined in ScaledObject are not working correctly")
+	assert.Equal(t, "TriggerError", reason)
+	assert.Equal(t, "Triggers defined in ScaledObject are not working correctly: trigger orders failed with TriggerError", msg)
+
+	// the error text isn't part of the condition
+	triggerErr := &scalers.TriggerError{TriggerName: "orders", Err: fmt.Errorf("error inspecting: %w", conditionReasonTestError{})}
+	reason, msg = getTriggerErrorCondition(triggerErr, "TriggerError", "Triggers defined in ScaledObject are not working correctly")
+	assert.Equal(t, "AuthenticationFailed", reason)
+	assert.Equal(t, "Triggers defined in ScaledObject are not working correctly: trigger orders failed with AuthenticationFailed", msg)
+}
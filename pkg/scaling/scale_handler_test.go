@@ -62,18 +62,22 @@ func TestCheckScaledObjectScalersWithError(t *testing.T) {
 
 	cache := cache.ScalersCache{
 		Scalers: []cache.ScalerBuilder{{
-			Scaler:  scaler,
-			Factory: factory,
+			Scaler:       scaler,
+			ScalerConfig: scalers.ScalerConfig{TriggerName: "orders"},
+			Factory:      factory,
 		}},
 		Logger:   logf.Log.WithName("scalehandler"),
 		Recorder: recorder,
 	}
 
-	isActive, isError, _ := cache.IsScaledObjectActive(context.TODO(), &scaledObject)
+	isActive, isError, _, triggerErr := cache.IsScaledObjectActive(context.TODO(), &scaledObject)
 	cache.Close(context.Background())
 
 	assert.Equal(t, false, isActive)
 	assert.Equal(t, true, isError)
+	var trigger *scalers.TriggerError
+	assert.True(t, errors.As(triggerErr, &trigger))
+	assert.Equal(t, "orders", trigger.TriggerName)
 }
 
 func TestCheckScaledObjectFindFirstActiveNotIgnoreOthers(t *testing.T) {
@@ -127,7 +131,7 @@ func TestCheckScaledObjectFindFirstActiveNotIgnoreOthers(t *testing.T) {
 		Recorder: recorder,
 	}
 
-	isActive, isError, _ := scalersCache.IsScaledObjectActive(context.TODO(), scaledObject)
+	isActive, isError, _, _ := scalersCache.IsScaledObjectActive(context.TODO(), scaledObject)
 	scalersCache.Close(context.Background())
 
 	assert.Equal(t, true, isActive)